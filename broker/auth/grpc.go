@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys that pass the user of the JWT to the services, which check with them that the user owns
+// what the request reads or changes. The broker never names itself as an internal service, every call it
+// makes is made for a user and the services reject calls that carry neither a user nor a service.
+const (
+	MetadataUserID = "x-user-id"
+	MetadataRole   = "x-user-role"
+)
+
+// UnaryClientInterceptor adds the user and role that ProtectedEndpoint put in the context to the
+// metadata of every call. Calls whose context has no user go out without a caller and are rejected.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withCaller(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is UnaryClientInterceptor for streaming calls.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withCaller(ctx), desc, cc, method, opts...)
+	}
+}
+
+func withCaller(ctx context.Context) context.Context {
+	userID, ok := ctx.Value(UserKey).(int)
+	if !ok {
+		return ctx
+	}
+	role, _ := ctx.Value(RoleKey).(string)
+	return metadata.AppendToOutgoingContext(ctx, MetadataUserID, strconv.Itoa(userID), MetadataRole, role)
+}
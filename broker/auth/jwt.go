@@ -1,78 +1,141 @@
-package auth
-
-import (
-	"context"
-	"fmt"
-	"net/http"
-	"os"
-	"strconv"
-	"strings"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-)
-
-type contextKey string
-
-var UserKey contextKey = "userID"
-
-func ProtectedEndpoint() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		header := c.GetHeader("Authorization")
-
-		parts := strings.Split(header, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token missing"})
-			c.Abort()
-			return
-		}
-
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid claims"})
-			c.Abort()
-			return
-		}
-
-		expiredAt := int64(claims["exp"].(float64))
-		if time.Now().Unix() > expiredAt {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
-			c.Abort()
-			return
-		}
-
-		userIDClaims := claims["userID"].(string)
-		userID, err := strconv.Atoi(userIDClaims)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Can't convert userID to int"})
-			c.Abort()
-			return
-		}
-		// user, err := repo.GetUserByID(userID)
-		// if err != nil {
-		// 	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		// 	c.Abort()
-		// 	return
-		// }
-
-		ctx := c.Request.Context()
-		ctx = context.WithValue(ctx, UserKey, userID)
-		c.Request = c.Request.WithContext(ctx)
-
-		c.Next()
-	}
-}
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type contextKey string
+
+var UserKey contextKey = "userID"
+var RoleKey contextKey = "role"
+
+const RoleAdmin = "admin"
+
+func ProtectedEndpoint() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+
+		parts := strings.Split(header, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token missing"})
+			c.Abort()
+			return
+		}
+
+		tokenString := parts[1]
+		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+			return []byte(os.Getenv("JWT_SECRET")), nil
+		})
+		if err != nil || !token.Valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid claims"})
+			c.Abort()
+			return
+		}
+
+		expiredAt := int64(claims["exp"].(float64))
+		if time.Now().Unix() > expiredAt {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
+			c.Abort()
+			return
+		}
+
+		userIDClaims := claims["userID"].(string)
+		userID, err := strconv.Atoi(userIDClaims)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Can't convert userID to int"})
+			c.Abort()
+			return
+		}
+		// user, err := repo.GetUserByID(userID)
+		// if err != nil {
+		// 	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		// 	c.Abort()
+		// 	return
+		// }
+
+		role, _ := claims["role"].(string)
+
+		ctx := c.Request.Context()
+		ctx = context.WithValue(ctx, UserKey, userID)
+		ctx = context.WithValue(ctx, RoleKey, role)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// QueryTokenEndpoint must run before ProtectedEndpoint, it takes the token from the access_token query
+// parameter for clients that cannot set headers, like browser EventSource and WebSocket.
+func QueryTokenEndpoint() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		c.Next()
+	}
+}
+
+// Logger is gin's request logger with the access_token query parameter masked, so tokens that
+// QueryTokenEndpoint accepts do not end up in the logs.
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			param.Method,
+			redactToken(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
+
+func redactToken(path string) string {
+	target, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	query := target.Query()
+	if !query.Has("access_token") {
+		return path
+	}
+	query.Set("access_token", "REDACTED")
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+// AdminEndpoint must run after ProtectedEndpoint, it only lets tokens with the admin role through.
+func AdminEndpoint() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Request.Context().Value(RoleKey).(string)
+		if role != RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin role required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
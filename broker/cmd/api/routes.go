@@ -1,30 +1,59 @@
-package routes
-
-import (
-	"broker/handler"
-	"broker/repository"
-
-	"github.com/gin-gonic/gin"
-)
-
-func Routes() *gin.Engine {
-	r := gin.Default()
-
-	userRepo := repository.NewUserRepository()
-	userHandler := handler.NewUserHandler(userRepo)
-	userHandler.RegisterRoutes(r)
-
-	productRepo := repository.NewProductRepository()
-	productHandler := handler.NewProductHandler(productRepo)
-	productHandler.RegisterRoutes(r)
-
-	orderRepo := repository.NewOrderRepository()
-	orderHandler := handler.NewOrderHandler(userRepo, productRepo, orderRepo)
-	orderHandler.RegisterRoutes(r)
-
-	paymentRepo := repository.NewPaymentRepository()
-	paymentHandler := handler.NewPaymentHandler(paymentRepo)
-	paymentHandler.RegisterRoutes(r)
-
-	return r
-}
+package routes
+
+import (
+	"broker/auth"
+	"broker/handler"
+	"broker/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Routes() *gin.Engine {
+	r := gin.New()
+	r.Use(auth.Logger(), gin.Recovery())
+
+	userRepo := repository.NewUserRepository()
+	userHandler := handler.NewUserHandler(userRepo)
+	userHandler.RegisterRoutes(r)
+
+	orderRepo := repository.NewOrderRepository()
+
+	productRepo := repository.NewProductRepository()
+	productHandler := handler.NewProductHandler(productRepo, orderRepo)
+	productHandler.RegisterRoutes(r)
+
+	orderHandler := handler.NewOrderHandler(userRepo, productRepo, orderRepo)
+	orderHandler.RegisterRoutes(r)
+
+	returnHandler := handler.NewReturnHandler(orderRepo)
+	returnHandler.RegisterRoutes(r)
+
+	promotionHandler := handler.NewPromotionHandler(orderRepo)
+	promotionHandler.RegisterRoutes(r)
+
+	taxHandler := handler.NewTaxHandler(orderRepo)
+	taxHandler.RegisterRoutes(r)
+
+	exchangeRateHandler := handler.NewExchangeRateHandler(orderRepo)
+	exchangeRateHandler.RegisterRoutes(r)
+
+	shipmentHandler := handler.NewShipmentHandler(orderRepo)
+	shipmentHandler.RegisterRoutes(r)
+
+	cartRepo := repository.NewCartRepository()
+	cartHandler := handler.NewCartHandler(cartRepo)
+	cartHandler.RegisterRoutes(r)
+
+	subscriptionRepo := repository.NewSubscriptionRepository()
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionRepo)
+	subscriptionHandler.RegisterRoutes(r)
+
+	paymentRepo := repository.NewPaymentRepository()
+	paymentHandler := handler.NewPaymentHandler(paymentRepo)
+	paymentHandler.RegisterRoutes(r)
+
+	reportHandler := handler.NewReportHandler(orderRepo, paymentRepo)
+	reportHandler.RegisterRoutes(r)
+
+	return r
+}
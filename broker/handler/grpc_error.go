@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// idempotencyKeyHeader lets clients retry a request without creating a second order or payment.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// preferredCurrencyHeader lets clients see prices and place orders in their own currency.
+const preferredCurrencyHeader = "X-Currency"
+
+// preferredCurrency returns the currency query parameter or else the X-Currency header, empty when the
+// client keeps the currency of the products.
+func preferredCurrency(c *gin.Context) string {
+	if currency := c.Query("currency"); currency != "" {
+		return strings.ToUpper(currency)
+	}
+	return strings.ToUpper(c.GetHeader(preferredCurrencyHeader))
+}
+
+// httpStatusFromError maps the gRPC code returned by a service to the matching HTTP status.
+func httpStatusFromError(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"broker/repository"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+type CartHandler struct {
+	cartRepo repository.CartRepository
+}
+
+func NewCartHandler(cartRepo repository.CartRepository) *CartHandler {
+	return &CartHandler{
+		cartRepo: cartRepo,
+	}
+}
+
+func (u *CartHandler) RegisterRoutes(r *gin.Engine) {
+	cartRoutes := r.Group("/cart")
+	cartRoutes.Use(auth.ProtectedEndpoint())
+
+	cartRoutes.GET("/", u.GetCart)
+	cartRoutes.DELETE("/", u.ClearCart)
+	cartRoutes.POST("/item", u.AddCartItem)
+	cartRoutes.PATCH("/item", u.UpdateCartItem)
+	cartRoutes.DELETE("/item", u.RemoveCartItem)
+	cartRoutes.POST("/checkout", u.Checkout)
+}
+
+func (u *CartHandler) GetCart(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	cart, err := u.cartRepo.GetCart(c.Request.Context(), &proto.GetCartRequest{
+		UserId:   int32(userID),
+		Currency: preferredCurrency(c),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, cart)
+}
+
+func (u *CartHandler) ClearCart(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	cart, err := u.cartRepo.ClearCart(c.Request.Context(), &proto.GetCartRequest{UserId: int32(userID)})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, cart)
+}
+
+func (u *CartHandler) AddCartItem(c *gin.Context) {
+	payload, ok := bindCartItem(c)
+	if !ok {
+		return
+	}
+
+	cart, err := u.cartRepo.AddCartItem(c.Request.Context(), payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, cart)
+}
+
+func (u *CartHandler) UpdateCartItem(c *gin.Context) {
+	payload, ok := bindCartItem(c)
+	if !ok {
+		return
+	}
+
+	cart, err := u.cartRepo.UpdateCartItem(c.Request.Context(), payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, cart)
+}
+
+func (u *CartHandler) RemoveCartItem(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Query("product_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	cart, err := u.cartRepo.RemoveCartItem(c.Request.Context(), &proto.RemoveCartItemRequest{
+		UserId:    int32(userID),
+		ProductId: int32(productID),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, cart)
+}
+
+func (u *CartHandler) Checkout(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	// The body is optional, it carries the coupon code, shipping and currency
+	var payload proto.CheckoutRequest
+	if err := c.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	payload.UserId = int32(userID)
+	payload.IdempotencyKey = c.GetHeader(idempotencyKeyHeader)
+	if payload.Currency == "" {
+		payload.Currency = preferredCurrency(c)
+	}
+
+	logrus.Infof("Checking out cart for user ID: %d", userID)
+	order, err := u.cartRepo.Checkout(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, order)
+}
+
+func bindCartItem(c *gin.Context) (*proto.CartItemRequest, bool) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return nil, false
+	}
+
+	var payload proto.CartItemRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if payload.ProductId <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid product ID"})
+		return nil, false
+	}
+	payload.UserId = int32(userID)
+
+	return &payload, true
+}
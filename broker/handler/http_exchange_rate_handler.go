@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"broker/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+type ExchangeRateHandler struct {
+	orderRepo repository.OrderRepository
+}
+
+func NewExchangeRateHandler(orderRepo repository.OrderRepository) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		orderRepo: orderRepo,
+	}
+}
+
+func (u *ExchangeRateHandler) RegisterRoutes(r *gin.Engine) {
+	exchangeRateRoutes := r.Group("/exchange-rate")
+	exchangeRateRoutes.Use(auth.ProtectedEndpoint())
+
+	exchangeRateRoutes.GET("/", u.GetExchangeRate)
+	exchangeRateRoutes.GET("/list", u.ListExchangeRates)
+
+	adminRoutes := r.Group("/admin/exchange-rate")
+	adminRoutes.Use(auth.ProtectedEndpoint(), auth.AdminEndpoint())
+
+	adminRoutes.POST("/", u.SetExchangeRate)
+}
+
+func (u *ExchangeRateHandler) GetExchangeRate(c *gin.Context) {
+	exchangeRate, err := u.orderRepo.GetExchangeRate(c.Request.Context(), &proto.GetExchangeRateRequest{
+		BaseCurrency:  c.Query("base"),
+		QuoteCurrency: c.Query("quote"),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, exchangeRate)
+}
+
+func (u *ExchangeRateHandler) ListExchangeRates(c *gin.Context) {
+	response, err := u.orderRepo.ListExchangeRates(c.Request.Context(), &proto.ListExchangeRatesRequest{
+		BaseCurrency: c.Query("base"),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+func (u *ExchangeRateHandler) SetExchangeRate(c *gin.Context) {
+	var payload proto.ExchangeRate
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	payload.Source = ""
+
+	logrus.Infof("Setting exchange rate %s/%s to %v", payload.BaseCurrency, payload.QuoteCurrency, payload.Rate)
+	exchangeRate, err := u.orderRepo.SetExchangeRate(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, exchangeRate)
+}
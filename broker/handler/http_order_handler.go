@@ -1,90 +1,322 @@
-package handler
-
-import (
-	"broker/auth"
-	"broker/proto"
-	"broker/repository"
-	"strconv"
-
-	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
-)
-
-type OrderHandler struct {
-	userRepo    repository.UserRepository
-	productRepo repository.ProductRepository
-	orderRepo   repository.OrderRepository
-}
-
-func NewOrderHandler(userRepo repository.UserRepository, productRepo repository.ProductRepository, orderRepo repository.OrderRepository) *OrderHandler {
-	return &OrderHandler{
-		userRepo:    userRepo,
-		productRepo: productRepo,
-		orderRepo:   orderRepo,
-	}
-}
-
-func (u *OrderHandler) RegisterRoutes(r *gin.Engine) {
-	orderRoutes := r.Group("/order")
-	orderRoutes.Use(auth.ProtectedEndpoint())
-
-	orderRoutes.POST("/", u.CreateOrder)
-	orderRoutes.GET("/", u.GetOrder)
-}
-
-func (u *OrderHandler) CreateOrder(c *gin.Context) {
-	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
-	if !ok {
-		c.JSON(401, gin.H{"error": "User ID not found"})
-		return
-	}
-
-	logrus.Infof("Creating payload for user ID: %d", userID)
-	var payload proto.CreateOrderRequest
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
-		return
-	}
-	payload.UserId = int32(userID)
-
-	logrus.Infof("Creating order for user ID: %d", userID)
-	order, err := u.orderRepo.CreateOrder(&payload)
-	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-
-	orderResponse := &proto.OrderResponse{
-		Order: &proto.Order{
-			Id:         order.Order.Id,
-			UserId:     int32(userID),
-			Status:     "Pending",
-			TotalPrice: order.Order.TotalPrice,
-			CreatedAt:  order.Order.CreatedAt,
-			UpdatedAt:  order.Order.CreatedAt,
-		},
-	}
-
-	c.JSON(200, orderResponse)
-}
-
-func (u *OrderHandler) GetOrder(c *gin.Context) {
-	logrus.Info("Fetching order by ID")
-	query := c.Query("order_id")
-	orderID, err := strconv.Atoi(query)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid order ID"})
-		return
-	}
-
-	logrus.Infof("calling repo")
-	order, err := u.orderRepo.GetOrder(&proto.GetOrderRequest{
-		OrderId: int32(orderID),
-	})
-	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(200, order)
-}
+package handler
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"broker/repository"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+type OrderHandler struct {
+	userRepo    repository.UserRepository
+	productRepo repository.ProductRepository
+	orderRepo   repository.OrderRepository
+}
+
+func NewOrderHandler(userRepo repository.UserRepository, productRepo repository.ProductRepository, orderRepo repository.OrderRepository) *OrderHandler {
+	return &OrderHandler{
+		userRepo:    userRepo,
+		productRepo: productRepo,
+		orderRepo:   orderRepo,
+	}
+}
+
+func (u *OrderHandler) RegisterRoutes(r *gin.Engine) {
+	orderRoutes := r.Group("/order")
+	orderRoutes.Use(auth.ProtectedEndpoint())
+
+	orderRoutes.POST("/", u.CreateOrder)
+	orderRoutes.GET("/", u.GetOrder)
+	orderRoutes.GET("/list", u.ListOrders)
+	orderRoutes.POST("/cancel", u.CancelOrder)
+	orderRoutes.GET("/history", u.GetOrderHistory)
+	orderRoutes.POST("/item", u.AddOrderItem)
+	orderRoutes.PATCH("/item", u.UpdateOrderItemQuantity)
+	orderRoutes.DELETE("/item", u.RemoveOrderItem)
+	orderRoutes.POST("/shipping-quotes", u.GetShippingQuotes)
+
+	watchRoutes := r.Group("/order/watch")
+	watchRoutes.Use(auth.QueryTokenEndpoint(), auth.ProtectedEndpoint())
+
+	watchRoutes.GET("/sse", u.WatchOrderEvents)
+	watchRoutes.GET("/ws", u.WatchOrderSocket)
+}
+
+func (u *OrderHandler) CreateOrder(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	logrus.Infof("Creating payload for user ID: %d", userID)
+	var payload proto.CreateOrderRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	payload.UserId = int32(userID)
+	payload.IdempotencyKey = c.GetHeader(idempotencyKeyHeader)
+	if payload.Currency == "" {
+		payload.Currency = preferredCurrency(c)
+	}
+
+	logrus.Infof("Creating order for user ID: %d", userID)
+	order, err := u.orderRepo.CreateOrder(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	orderResponse := &proto.OrderResponse{
+		Order: &proto.Order{
+			Id:         order.Order.Id,
+			UserId:     int32(userID),
+			Status:     order.Order.Status,
+			TotalPrice: order.Order.TotalPrice,
+			Currency:   order.Order.Currency,
+			CreatedAt:  order.Order.CreatedAt,
+			UpdatedAt:  order.Order.CreatedAt,
+		},
+	}
+
+	c.JSON(200, orderResponse)
+}
+
+func (u *OrderHandler) GetOrder(c *gin.Context) {
+	logrus.Info("Fetching order by ID")
+	query := c.Query("order_id")
+	orderID, err := strconv.Atoi(query)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	logrus.Infof("calling repo")
+	order, err := u.orderRepo.GetOrder(c.Request.Context(), &proto.GetOrderRequest{
+		OrderId: int32(orderID),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, order)
+}
+
+func (u *OrderHandler) ListOrders(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	payload := &proto.ListOrdersRequest{
+		UserId:      int32(userID),
+		CreatedFrom: c.Query("from"),
+		CreatedTo:   c.Query("to"),
+		SortBy:      c.Query("sort_by"),
+		SortOrder:   c.Query("sort_order"),
+		Cursor:      c.Query("cursor"),
+	}
+
+	if query := c.Query("status"); query != "" {
+		orderStatus, ok := proto.OrderStatus_value["ORDER_STATUS_"+strings.ToUpper(query)]
+		if !ok {
+			c.JSON(400, gin.H{"error": "Invalid status"})
+			return
+		}
+		payload.Status = proto.OrderStatus(orderStatus)
+	}
+
+	if query := c.Query("min_total"); query != "" {
+		minTotal, err := strconv.ParseInt(query, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid min_total"})
+			return
+		}
+		payload.MinTotal = minTotal
+	}
+
+	if query := c.Query("max_total"); query != "" {
+		maxTotal, err := strconv.ParseInt(query, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid max_total"})
+			return
+		}
+		payload.MaxTotal = maxTotal
+	}
+
+	if query := c.Query("limit"); query != "" {
+		limit, err := strconv.Atoi(query)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid limit"})
+			return
+		}
+		payload.Limit = int32(limit)
+	}
+
+	logrus.Infof("Listing orders for user ID: %d", userID)
+	response, err := u.orderRepo.ListOrders(c.Request.Context(), payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+func (u *OrderHandler) CancelOrder(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var payload proto.CancelOrderRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if payload.OrderId <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid order ID"})
+		return
+	}
+	payload.UserId = int32(userID)
+
+	logrus.Infof("Cancelling order %d for user ID: %d", payload.OrderId, userID)
+	order, err := u.orderRepo.CancelOrder(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, order)
+}
+
+func (u *OrderHandler) GetOrderHistory(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Query("order_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	logrus.Infof("Fetching history of order %d for user ID: %d", orderID, userID)
+	response, err := u.orderRepo.GetOrderHistory(c.Request.Context(), &proto.GetOrderHistoryRequest{
+		OrderId: int32(orderID),
+		UserId:  int32(userID),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+func (u *OrderHandler) AddOrderItem(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var payload proto.AddOrderItemRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	payload.UserId = int32(userID)
+
+	order, err := u.orderRepo.AddOrderItem(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, order)
+}
+
+func (u *OrderHandler) UpdateOrderItemQuantity(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var payload proto.UpdateOrderItemQuantityRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	payload.UserId = int32(userID)
+
+	order, err := u.orderRepo.UpdateOrderItemQuantity(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, order)
+}
+
+func (u *OrderHandler) RemoveOrderItem(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Query("order_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid order ID"})
+		return
+	}
+	orderItemID, err := strconv.Atoi(c.Query("order_item_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid order item ID"})
+		return
+	}
+
+	order, err := u.orderRepo.RemoveOrderItem(c.Request.Context(), &proto.RemoveOrderItemRequest{
+		OrderId:     int32(orderID),
+		UserId:      int32(userID),
+		OrderItemId: int32(orderItemID),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, order)
+}
+
+func (u *OrderHandler) GetShippingQuotes(c *gin.Context) {
+	var payload proto.GetShippingQuotesRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if payload.Currency == "" {
+		payload.Currency = preferredCurrency(c)
+	}
+
+	quotes, err := u.orderRepo.GetShippingQuotes(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, quotes)
+}
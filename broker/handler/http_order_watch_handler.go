@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"broker/types"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/status"
+)
+
+// orderWatchKeepAlive keeps idle Server-Sent Events connections open through proxies.
+const orderWatchKeepAlive = 15 * time.Second
+
+// Events of an order watch, the SSE event names and the event field of WebSocket messages.
+const (
+	orderWatchEventStatus = "status"
+	orderWatchEventEnd    = "end"
+	orderWatchEventError  = "error"
+)
+
+// WatchOrderEvents streams the status changes of an order as Server-Sent Events.
+func (u *OrderHandler) WatchOrderEvents(c *gin.Context) {
+	first, stream, ok := u.watchOrder(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent(orderWatchEventStatus, first)
+	c.Writer.Flush()
+
+	changes, done := receiveOrderChanges(stream)
+	ticker := time.NewTicker(orderWatchKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case change, ok := <-changes:
+			if !ok {
+				event := orderWatchEnd(<-done)
+				c.SSEvent(event.Event, event.Data)
+				c.Writer.Flush()
+				return
+			}
+			c.SSEvent(orderWatchEventStatus, change)
+		}
+		c.Writer.Flush()
+	}
+}
+
+// WatchOrderSocket streams the status changes of an order over a WebSocket, one JSON
+// types.OrderWatchEvent per message.
+func (u *OrderHandler) WatchOrderSocket(c *gin.Context) {
+	first, stream, ok := u.watchOrder(c)
+	if !ok {
+		return
+	}
+
+	server := websocket.Server{Handshake: checkOrigin, Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		if err := websocket.JSON.Send(ws, types.OrderWatchEvent{Event: orderWatchEventStatus, Data: first}); err != nil {
+			return
+		}
+
+		// The client only listens, a read error means it closed the socket
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			io.Copy(io.Discard, ws)
+		}()
+
+		changes, done := receiveOrderChanges(stream)
+		for {
+			select {
+			case <-closed:
+				return
+			case change, ok := <-changes:
+				if !ok {
+					websocket.JSON.Send(ws, orderWatchEnd(<-done))
+					return
+				}
+				if err := websocket.JSON.Send(ws, types.OrderWatchEvent{Event: orderWatchEventStatus, Data: change}); err != nil {
+					return
+				}
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkOrigin lets WebSocket handshakes from pages of the broker's own host and of the origins listed
+// in WS_ALLOWED_ORIGINS through. Clients that send no Origin are not browsers and pass.
+func checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	config.Origin = originURL
+	if strings.EqualFold(originURL.Host, r.Host) {
+		return nil
+	}
+	for _, allowed := range strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",") {
+		if allowed = strings.TrimSpace(allowed); allowed != "" && strings.EqualFold(allowed, origin) {
+			return nil
+		}
+	}
+	return errors.New("origin " + origin + " is not allowed")
+}
+
+// watchOrder opens the stream of the order and waits for its first change, so errors like an unknown
+// order are still answered with an HTTP status.
+func (u *OrderHandler) watchOrder(c *gin.Context) (*proto.OrderStatusChange, proto.OrderService_WatchOrderClient, bool) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return nil, nil, false
+	}
+
+	orderID, err := strconv.Atoi(c.Query("order_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid order ID"})
+		return nil, nil, false
+	}
+
+	logrus.Infof("Watching order %d for user ID: %d", orderID, userID)
+	stream, err := u.orderRepo.WatchOrder(c.Request.Context(), &proto.WatchOrderRequest{
+		OrderId: int32(orderID),
+		UserId:  int32(userID),
+	})
+	if err == nil {
+		var first *proto.OrderStatusChange
+		if first, err = stream.Recv(); err == nil {
+			return first, stream, true
+		}
+	}
+	c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+	return nil, nil, false
+}
+
+// receiveOrderChanges reads the stream until it ends, then closes changes and sends why it ended on done.
+func receiveOrderChanges(stream proto.OrderService_WatchOrderClient) (<-chan *proto.OrderStatusChange, <-chan error) {
+	changes := make(chan *proto.OrderStatusChange)
+	done := make(chan error, 1)
+	go func() {
+		defer close(changes)
+		for {
+			change, err := stream.Recv()
+			if err != nil {
+				done <- err
+				return
+			}
+			select {
+			case changes <- change:
+			case <-stream.Context().Done():
+				done <- stream.Context().Err()
+				return
+			}
+		}
+	}()
+	return changes, done
+}
+
+// orderWatchEnd is the last event of a watch, io.EOF means the order reached a final status.
+func orderWatchEnd(err error) types.OrderWatchEvent {
+	if errors.Is(err, io.EOF) {
+		return types.OrderWatchEvent{Event: orderWatchEventEnd, Data: gin.H{"message": "order reached a final status"}}
+	}
+	return types.OrderWatchEvent{Event: orderWatchEventError, Data: gin.H{"error": status.Convert(err).Message()}}
+}
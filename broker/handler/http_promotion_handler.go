@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"broker/repository"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+type PromotionHandler struct {
+	orderRepo repository.OrderRepository
+}
+
+func NewPromotionHandler(orderRepo repository.OrderRepository) *PromotionHandler {
+	return &PromotionHandler{
+		orderRepo: orderRepo,
+	}
+}
+
+func (u *PromotionHandler) RegisterRoutes(r *gin.Engine) {
+	adminRoutes := r.Group("/admin/promotion")
+	adminRoutes.Use(auth.ProtectedEndpoint(), auth.AdminEndpoint())
+
+	adminRoutes.POST("/", u.CreatePromotion)
+	adminRoutes.GET("/list", u.ListPromotions)
+	adminRoutes.POST("/deactivate", u.DeactivatePromotion)
+}
+
+func (u *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var payload proto.Promotion
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	logrus.Infof("Creating %s promotion %s", payload.Type, payload.Name)
+	promotion, err := u.orderRepo.CreatePromotion(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, promotion)
+}
+
+func (u *PromotionHandler) ListPromotions(c *gin.Context) {
+	response, err := u.orderRepo.ListPromotions(c.Request.Context(), &proto.ListPromotionsRequest{
+		IncludeInactive: c.Query("include_inactive") == "true",
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+func (u *PromotionHandler) DeactivatePromotion(c *gin.Context) {
+	promotionID, err := strconv.Atoi(c.Query("promotion_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	logrus.Infof("Deactivating promotion %d", promotionID)
+	promotion, err := u.orderRepo.DeactivatePromotion(c.Request.Context(), &proto.GetPromotionRequest{PromotionId: int32(promotionID)})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, promotion)
+}
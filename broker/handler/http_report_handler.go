@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"broker/repository"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/status"
+)
+
+type ReportHandler struct {
+	orderRepo   repository.OrderRepository
+	paymentRepo repository.PaymentRepository
+}
+
+func NewReportHandler(orderRepo repository.OrderRepository, paymentRepo repository.PaymentRepository) *ReportHandler {
+	return &ReportHandler{
+		orderRepo:   orderRepo,
+		paymentRepo: paymentRepo,
+	}
+}
+
+// RegisterRoutes adds the sales reports. They answer JSON, or CSV with format=csv; amounts are in minor
+// units of their currency.
+func (u *ReportHandler) RegisterRoutes(r *gin.Engine) {
+	adminRoutes := r.Group("/admin/report")
+	adminRoutes.Use(auth.ProtectedEndpoint(), auth.AdminEndpoint())
+
+	adminRoutes.GET("/revenue", u.GetRevenueReport)
+	adminRoutes.GET("/products", u.GetTopProducts)
+	adminRoutes.GET("/order-value", u.GetOrderValueReport)
+	adminRoutes.GET("/payments", u.GetPaymentReport)
+}
+
+func (u *ReportHandler) GetRevenueReport(c *gin.Context) {
+	payload, ok := bindReportRequest(c)
+	if !ok {
+		return
+	}
+
+	report, err := u.orderRepo.GetRevenueReport(c.Request.Context(), payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		rows := [][]string{{"period", "currency", "orders", "revenue", "average_order_value"}}
+		for _, period := range report.Periods {
+			rows = append(rows, []string{
+				period.Period,
+				period.Currency,
+				strconv.Itoa(int(period.Orders)),
+				strconv.FormatInt(period.Revenue, 10),
+				strconv.FormatInt(period.AverageOrderValue, 10),
+			})
+		}
+		writeCSV(c, fmt.Sprintf("revenue_%s_%s.csv", report.From, report.To), rows)
+		return
+	}
+
+	c.JSON(200, report)
+}
+
+func (u *ReportHandler) GetTopProducts(c *gin.Context) {
+	payload, ok := bindReportRequest(c)
+	if !ok {
+		return
+	}
+
+	report, err := u.orderRepo.GetTopProducts(c.Request.Context(), payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		rows := [][]string{{"product_id", "product_name", "currency", "quantity", "revenue", "orders"}}
+		for _, product := range report.Products {
+			rows = append(rows, []string{
+				strconv.Itoa(int(product.ProductId)),
+				product.ProductName,
+				product.Currency,
+				strconv.FormatInt(product.Quantity, 10),
+				strconv.FormatInt(product.Revenue, 10),
+				strconv.Itoa(int(product.Orders)),
+			})
+		}
+		writeCSV(c, fmt.Sprintf("top_products_%s_%s.csv", report.From, report.To), rows)
+		return
+	}
+
+	c.JSON(200, report)
+}
+
+func (u *ReportHandler) GetOrderValueReport(c *gin.Context) {
+	payload, ok := bindReportRequest(c)
+	if !ok {
+		return
+	}
+
+	report, err := u.orderRepo.GetOrderValueReport(c.Request.Context(), payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		rows := [][]string{{"currency", "orders", "revenue", "average_order_value"}}
+		for _, value := range report.Currencies {
+			rows = append(rows, []string{
+				value.Currency,
+				strconv.Itoa(int(value.Orders)),
+				strconv.FormatInt(value.Revenue, 10),
+				strconv.FormatInt(value.AverageOrderValue, 10),
+			})
+		}
+		writeCSV(c, fmt.Sprintf("order_value_%s_%s.csv", report.From, report.To), rows)
+		return
+	}
+
+	c.JSON(200, report)
+}
+
+func (u *ReportHandler) GetPaymentReport(c *gin.Context) {
+	report, err := u.paymentRepo.GetPaymentReport(c.Request.Context(), &proto.PaymentReportRequest{
+		From:    c.Query("from"),
+		To:      c.Query("to"),
+		GroupBy: c.Query("group_by"),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		rows := [][]string{{"period", "total", "succeeded", "failed", "pending", "cancelled", "success_rate"}}
+		for _, stats := range report.Periods {
+			rows = append(rows, paymentStatsRow(stats.Period, stats))
+		}
+		if report.Total != nil {
+			rows = append(rows, paymentStatsRow("total", report.Total))
+		}
+		writeCSV(c, fmt.Sprintf("payments_%s_%s.csv", report.From, report.To), rows)
+		return
+	}
+
+	c.JSON(200, report)
+}
+
+func paymentStatsRow(period string, stats *proto.PaymentStats) []string {
+	return []string{
+		period,
+		strconv.Itoa(int(stats.Total)),
+		strconv.Itoa(int(stats.Succeeded)),
+		strconv.Itoa(int(stats.Failed)),
+		strconv.Itoa(int(stats.Pending)),
+		strconv.Itoa(int(stats.Cancelled)),
+		strconv.FormatFloat(stats.SuccessRate, 'f', 4, 64),
+	}
+}
+
+func bindReportRequest(c *gin.Context) (*proto.ReportRequest, bool) {
+	payload := &proto.ReportRequest{
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		GroupBy:  c.Query("group_by"),
+		SortBy:   c.Query("sort_by"),
+		Currency: c.Query("currency"),
+	}
+
+	if query := c.Query("limit"); query != "" {
+		limit, err := strconv.Atoi(query)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid limit"})
+			return nil, false
+		}
+		payload.Limit = int32(limit)
+	}
+
+	return payload, true
+}
+
+// writeCSV answers rows as a CSV attachment named filename.
+func writeCSV(c *gin.Context, filename string, rows [][]string) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(200)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.WriteAll(rows); err != nil {
+		c.Error(err)
+	}
+}
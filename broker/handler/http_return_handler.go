@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"broker/repository"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+type ReturnHandler struct {
+	orderRepo repository.OrderRepository
+}
+
+func NewReturnHandler(orderRepo repository.OrderRepository) *ReturnHandler {
+	return &ReturnHandler{
+		orderRepo: orderRepo,
+	}
+}
+
+func (u *ReturnHandler) RegisterRoutes(r *gin.Engine) {
+	returnRoutes := r.Group("/return")
+	returnRoutes.Use(auth.ProtectedEndpoint())
+
+	returnRoutes.POST("/", u.CreateReturn)
+	returnRoutes.GET("/", u.GetReturn)
+	returnRoutes.GET("/list", u.ListReturns)
+
+	adminRoutes := r.Group("/admin/return")
+	adminRoutes.Use(auth.ProtectedEndpoint(), auth.AdminEndpoint())
+
+	adminRoutes.GET("/list", u.ListAllReturns)
+	adminRoutes.POST("/approve", u.ApproveReturn)
+	adminRoutes.POST("/reject", u.RejectReturn)
+}
+
+func (u *ReturnHandler) CreateReturn(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var payload proto.CreateReturnRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	payload.UserId = int32(userID)
+
+	logrus.Infof("Requesting return of order %d for user ID: %d", payload.OrderId, userID)
+	orderReturn, err := u.orderRepo.CreateReturn(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, orderReturn)
+}
+
+func (u *ReturnHandler) GetReturn(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	returnID, err := strconv.Atoi(c.Query("return_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid return ID"})
+		return
+	}
+
+	orderReturn, err := u.orderRepo.GetReturn(c.Request.Context(), &proto.GetReturnRequest{
+		ReturnId: int32(returnID),
+		UserId:   int32(userID),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, orderReturn)
+}
+
+func (u *ReturnHandler) ListReturns(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	u.listReturns(c, int32(userID))
+}
+
+func (u *ReturnHandler) ListAllReturns(c *gin.Context) {
+	u.listReturns(c, 0)
+}
+
+func (u *ReturnHandler) listReturns(c *gin.Context, userID int32) {
+	payload := &proto.ListReturnsRequest{
+		UserId: userID,
+		Status: c.Query("status"),
+	}
+
+	if query := c.Query("order_id"); query != "" {
+		orderID, err := strconv.Atoi(query)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid order ID"})
+			return
+		}
+		payload.OrderId = int32(orderID)
+	}
+
+	response, err := u.orderRepo.ListReturns(c.Request.Context(), payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+func (u *ReturnHandler) ApproveReturn(c *gin.Context) {
+	payload, ok := bindReviewReturn(c)
+	if !ok {
+		return
+	}
+
+	logrus.Infof("Approving return %d by %s", payload.ReturnId, payload.Actor)
+	orderReturn, err := u.orderRepo.ApproveReturn(c.Request.Context(), payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, orderReturn)
+}
+
+func (u *ReturnHandler) RejectReturn(c *gin.Context) {
+	payload, ok := bindReviewReturn(c)
+	if !ok {
+		return
+	}
+
+	logrus.Infof("Rejecting return %d by %s", payload.ReturnId, payload.Actor)
+	orderReturn, err := u.orderRepo.RejectReturn(c.Request.Context(), payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, orderReturn)
+}
+
+func bindReviewReturn(c *gin.Context) (*proto.ReviewReturnRequest, bool) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return nil, false
+	}
+
+	var payload proto.ReviewReturnRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if payload.ReturnId <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid return ID"})
+		return nil, false
+	}
+	payload.Actor = fmt.Sprintf("admin:%d", userID)
+
+	return &payload, true
+}
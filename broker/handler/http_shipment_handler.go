@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"broker/repository"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+type ShipmentHandler struct {
+	orderRepo repository.OrderRepository
+}
+
+func NewShipmentHandler(orderRepo repository.OrderRepository) *ShipmentHandler {
+	return &ShipmentHandler{
+		orderRepo: orderRepo,
+	}
+}
+
+func (u *ShipmentHandler) RegisterRoutes(r *gin.Engine) {
+	shipmentRoutes := r.Group("/shipment")
+	shipmentRoutes.Use(auth.ProtectedEndpoint())
+
+	shipmentRoutes.GET("/", u.GetShipment)
+	shipmentRoutes.GET("/list", u.ListShipments)
+
+	adminRoutes := r.Group("/admin/shipment")
+	adminRoutes.Use(auth.ProtectedEndpoint(), auth.AdminEndpoint())
+
+	adminRoutes.GET("/list", u.ListAllShipments)
+	adminRoutes.POST("/", u.CreateShipment)
+	adminRoutes.POST("/status", u.UpdateShipmentStatus)
+}
+
+// GetShipment returns a shipment of the user with its tracking events.
+func (u *ShipmentHandler) GetShipment(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	shipmentID, err := strconv.Atoi(c.Query("shipment_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid shipment ID"})
+		return
+	}
+
+	shipment, err := u.orderRepo.GetShipment(c.Request.Context(), &proto.GetShipmentRequest{
+		ShipmentId: int32(shipmentID),
+		UserId:     int32(userID),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, shipment)
+}
+
+// ListShipments returns the shipments of the user, the order_id query narrows them to one order.
+func (u *ShipmentHandler) ListShipments(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	u.listShipments(c, int32(userID))
+}
+
+func (u *ShipmentHandler) ListAllShipments(c *gin.Context) {
+	u.listShipments(c, 0)
+}
+
+func (u *ShipmentHandler) listShipments(c *gin.Context, userID int32) {
+	payload := &proto.ListShipmentsRequest{
+		UserId: userID,
+		Status: c.Query("status"),
+	}
+
+	if query := c.Query("order_id"); query != "" {
+		orderID, err := strconv.Atoi(query)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid order ID"})
+			return
+		}
+		payload.OrderId = int32(orderID)
+	}
+
+	response, err := u.orderRepo.ListShipments(c.Request.Context(), payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+func (u *ShipmentHandler) CreateShipment(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var payload proto.CreateShipmentRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if payload.OrderId <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid order ID"})
+		return
+	}
+	payload.Actor = fmt.Sprintf("admin:%d", userID)
+
+	logrus.Infof("Creating shipment for order %d by %s", payload.OrderId, payload.Actor)
+	shipment, err := u.orderRepo.CreateShipment(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, shipment)
+}
+
+func (u *ShipmentHandler) UpdateShipmentStatus(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var payload proto.UpdateShipmentStatusRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if payload.ShipmentId <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid shipment ID"})
+		return
+	}
+	payload.Actor = fmt.Sprintf("admin:%d", userID)
+
+	logrus.Infof("Updating shipment %d to %s by %s", payload.ShipmentId, payload.Status, payload.Actor)
+	shipment, err := u.orderRepo.UpdateShipmentStatus(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, shipment)
+}
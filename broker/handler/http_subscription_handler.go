@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"broker/repository"
+	"context"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+type SubscriptionHandler struct {
+	subscriptionRepo repository.SubscriptionRepository
+}
+
+func NewSubscriptionHandler(subscriptionRepo repository.SubscriptionRepository) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subscriptionRepo: subscriptionRepo,
+	}
+}
+
+func (u *SubscriptionHandler) RegisterRoutes(r *gin.Engine) {
+	subscriptionRoutes := r.Group("/subscription")
+	subscriptionRoutes.Use(auth.ProtectedEndpoint())
+
+	subscriptionRoutes.POST("/", u.CreateSubscription)
+	subscriptionRoutes.GET("/", u.GetSubscription)
+	subscriptionRoutes.GET("/list", u.ListSubscriptions)
+	subscriptionRoutes.POST("/pause", u.PauseSubscription)
+	subscriptionRoutes.POST("/resume", u.ResumeSubscription)
+	subscriptionRoutes.POST("/skip", u.SkipSubscriptionCycle)
+	subscriptionRoutes.POST("/cancel", u.CancelSubscription)
+}
+
+func (u *SubscriptionHandler) CreateSubscription(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var payload proto.CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	payload.UserId = int32(userID)
+	if payload.Currency == "" {
+		payload.Currency = preferredCurrency(c)
+	}
+
+	logrus.Infof("Creating subscription for user ID: %d", userID)
+	subscription, err := u.subscriptionRepo.CreateSubscription(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, subscription)
+}
+
+func (u *SubscriptionHandler) GetSubscription(c *gin.Context) {
+	u.withSubscription(c, u.subscriptionRepo.GetSubscription)
+}
+
+func (u *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	response, err := u.subscriptionRepo.ListSubscriptions(c.Request.Context(), &proto.ListSubscriptionsRequest{
+		UserId: int32(userID),
+		Status: c.Query("status"),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+func (u *SubscriptionHandler) PauseSubscription(c *gin.Context) {
+	u.withSubscription(c, u.subscriptionRepo.PauseSubscription)
+}
+
+func (u *SubscriptionHandler) ResumeSubscription(c *gin.Context) {
+	u.withSubscription(c, u.subscriptionRepo.ResumeSubscription)
+}
+
+// SkipSubscriptionCycle skips the next order of a subscription.
+func (u *SubscriptionHandler) SkipSubscriptionCycle(c *gin.Context) {
+	u.withSubscription(c, u.subscriptionRepo.SkipSubscriptionCycle)
+}
+
+func (u *SubscriptionHandler) CancelSubscription(c *gin.Context) {
+	u.withSubscription(c, u.subscriptionRepo.CancelSubscription)
+}
+
+// withSubscription calls call with the subscription_id of the query for the user and answers the
+// subscription it returns.
+func (u *SubscriptionHandler) withSubscription(c *gin.Context, call func(context.Context, *proto.GetSubscriptionRequest) (*proto.Subscription, error)) {
+	userID, ok := c.Request.Context().Value(auth.UserKey).(int)
+	if !ok {
+		c.JSON(401, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	subscriptionID, err := strconv.Atoi(c.Query("subscription_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	subscription, err := call(c.Request.Context(), &proto.GetSubscriptionRequest{
+		SubscriptionId: int32(subscriptionID),
+		UserId:         int32(userID),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, subscription)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"broker/repository"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+type TaxHandler struct {
+	orderRepo repository.OrderRepository
+}
+
+func NewTaxHandler(orderRepo repository.OrderRepository) *TaxHandler {
+	return &TaxHandler{
+		orderRepo: orderRepo,
+	}
+}
+
+func (u *TaxHandler) RegisterRoutes(r *gin.Engine) {
+	adminRoutes := r.Group("/admin/tax")
+	adminRoutes.Use(auth.ProtectedEndpoint(), auth.AdminEndpoint())
+
+	adminRoutes.POST("/", u.CreateTaxRule)
+	adminRoutes.GET("/list", u.ListTaxRules)
+	adminRoutes.POST("/deactivate", u.DeactivateTaxRule)
+}
+
+func (u *TaxHandler) CreateTaxRule(c *gin.Context) {
+	var payload proto.TaxRule
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	logrus.Infof("Creating tax rule %s for region %q", payload.Name, payload.Region)
+	taxRule, err := u.orderRepo.CreateTaxRule(c.Request.Context(), &payload)
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, taxRule)
+}
+
+func (u *TaxHandler) ListTaxRules(c *gin.Context) {
+	response, err := u.orderRepo.ListTaxRules(c.Request.Context(), &proto.ListTaxRulesRequest{
+		IncludeInactive: c.Query("include_inactive") == "true",
+		Region:          c.Query("region"),
+	})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+func (u *TaxHandler) DeactivateTaxRule(c *gin.Context) {
+	taxRuleID, err := strconv.Atoi(c.Query("tax_rule_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid tax rule ID"})
+		return
+	}
+
+	logrus.Infof("Deactivating tax rule %d", taxRuleID)
+	taxRule, err := u.orderRepo.DeactivateTaxRule(c.Request.Context(), &proto.GetTaxRuleRequest{TaxRuleId: int32(taxRuleID)})
+	if err != nil {
+		c.JSON(httpStatusFromError(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(200, taxRule)
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type CartRepository interface {
+	GetCart(context.Context, *proto.GetCartRequest) (*proto.Cart, error)
+	AddCartItem(context.Context, *proto.CartItemRequest) (*proto.Cart, error)
+	UpdateCartItem(context.Context, *proto.CartItemRequest) (*proto.Cart, error)
+	RemoveCartItem(context.Context, *proto.RemoveCartItemRequest) (*proto.Cart, error)
+	ClearCart(context.Context, *proto.GetCartRequest) (*proto.Cart, error)
+	Checkout(context.Context, *proto.CheckoutRequest) (*proto.OrderResponse, error)
+}
+
+type CartRepositoryImpl struct {
+	client proto.CartServiceClient
+}
+
+// NewCartRepository connects to the cart service, which is served by the order service.
+func NewCartRepository() *CartRepositoryImpl {
+	conn, err := grpc.NewClient(
+		"order-service:30001",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(auth.StreamClientInterceptor()),
+	)
+	if err != nil {
+		logrus.Fatalf("Failed to connect: %v", err)
+	}
+
+	logrus.Info("Connected to cart service")
+	client := proto.NewCartServiceClient(conn)
+
+	return &CartRepositoryImpl{client: client}
+}
+
+func (u *CartRepositoryImpl) GetCart(ctx context.Context, payload *proto.GetCartRequest) (*proto.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.GetCart(ctx, payload)
+}
+
+func (u *CartRepositoryImpl) AddCartItem(ctx context.Context, payload *proto.CartItemRequest) (*proto.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Add product %d to the cart of user ID: %d", payload.ProductId, payload.UserId)
+	return u.client.AddCartItem(ctx, payload)
+}
+
+func (u *CartRepositoryImpl) UpdateCartItem(ctx context.Context, payload *proto.CartItemRequest) (*proto.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Update product %d in the cart of user ID: %d", payload.ProductId, payload.UserId)
+	return u.client.UpdateCartItem(ctx, payload)
+}
+
+func (u *CartRepositoryImpl) RemoveCartItem(ctx context.Context, payload *proto.RemoveCartItemRequest) (*proto.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Remove product %d from the cart of user ID: %d", payload.ProductId, payload.UserId)
+	return u.client.RemoveCartItem(ctx, payload)
+}
+
+func (u *CartRepositoryImpl) ClearCart(ctx context.Context, payload *proto.GetCartRequest) (*proto.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Clear the cart of user ID: %d", payload.UserId)
+	return u.client.ClearCart(ctx, payload)
+}
+
+func (u *CartRepositoryImpl) Checkout(ctx context.Context, payload *proto.CheckoutRequest) (*proto.OrderResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Check out the cart of user ID: %d", payload.UserId)
+	return u.client.Checkout(ctx, payload)
+}
@@ -1,48 +1,311 @@
-package repository
-
-import (
-	"broker/proto"
-	"context"
-	"time"
-
-	"github.com/sirupsen/logrus"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-)
-
-type OrderRepository interface {
-	CreateOrder(*proto.CreateOrderRequest) (*proto.OrderResponse, error)
-	GetOrder(*proto.GetOrderRequest) (*proto.OrderResponse, error)
-}
-
-type OrderRepositoryImpl struct {
-	client proto.OrderServiceClient
-}
-
-func NewOrderRepository() *OrderRepositoryImpl {
-	conn, err := grpc.NewClient("order-service:30001", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		logrus.Fatalf("Failed to connect: %v", err)
-	}
-
-	logrus.Info("Connected to product service")
-	client := proto.NewOrderServiceClient(conn)
-
-	return &OrderRepositoryImpl{client: client}
-}
-
-func (u *OrderRepositoryImpl) CreateOrder(payload *proto.CreateOrderRequest) (*proto.OrderResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	logrus.Infof("Create the order and pass to order service with")
-	return u.client.CreateOrder(ctx, payload)
-}
-
-func (u *OrderRepositoryImpl) GetOrder(payload *proto.GetOrderRequest) (*proto.OrderResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	logrus.Infof("Get the order by ID and pass to order service with ID: %d", payload.OrderId)
-	return u.client.GetOrder(ctx, payload)
-}
+package repository
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type OrderRepository interface {
+	CreateOrder(context.Context, *proto.CreateOrderRequest) (*proto.OrderResponse, error)
+	GetOrder(context.Context, *proto.GetOrderRequest) (*proto.OrderResponse, error)
+	ListOrders(context.Context, *proto.ListOrdersRequest) (*proto.ListOrdersResponse, error)
+	CancelOrder(context.Context, *proto.CancelOrderRequest) (*proto.OrderResponse, error)
+	GetOrderHistory(context.Context, *proto.GetOrderHistoryRequest) (*proto.GetOrderHistoryResponse, error)
+	WatchOrder(context.Context, *proto.WatchOrderRequest) (proto.OrderService_WatchOrderClient, error)
+	CreateReturn(context.Context, *proto.CreateReturnRequest) (*proto.OrderReturn, error)
+	ApproveReturn(context.Context, *proto.ReviewReturnRequest) (*proto.OrderReturn, error)
+	RejectReturn(context.Context, *proto.ReviewReturnRequest) (*proto.OrderReturn, error)
+	GetReturn(context.Context, *proto.GetReturnRequest) (*proto.OrderReturn, error)
+	ListReturns(context.Context, *proto.ListReturnsRequest) (*proto.ListReturnsResponse, error)
+	AddOrderItem(context.Context, *proto.AddOrderItemRequest) (*proto.OrderResponse, error)
+	UpdateOrderItemQuantity(context.Context, *proto.UpdateOrderItemQuantityRequest) (*proto.OrderResponse, error)
+	RemoveOrderItem(context.Context, *proto.RemoveOrderItemRequest) (*proto.OrderResponse, error)
+	CreatePromotion(context.Context, *proto.Promotion) (*proto.Promotion, error)
+	ListPromotions(context.Context, *proto.ListPromotionsRequest) (*proto.ListPromotionsResponse, error)
+	DeactivatePromotion(context.Context, *proto.GetPromotionRequest) (*proto.Promotion, error)
+	CreateTaxRule(context.Context, *proto.TaxRule) (*proto.TaxRule, error)
+	ListTaxRules(context.Context, *proto.ListTaxRulesRequest) (*proto.ListTaxRulesResponse, error)
+	DeactivateTaxRule(context.Context, *proto.GetTaxRuleRequest) (*proto.TaxRule, error)
+	GetShippingQuotes(context.Context, *proto.GetShippingQuotesRequest) (*proto.GetShippingQuotesResponse, error)
+	SetExchangeRate(context.Context, *proto.ExchangeRate) (*proto.ExchangeRate, error)
+	GetExchangeRate(context.Context, *proto.GetExchangeRateRequest) (*proto.ExchangeRate, error)
+	ListExchangeRates(context.Context, *proto.ListExchangeRatesRequest) (*proto.ListExchangeRatesResponse, error)
+	ConvertAmounts(context.Context, *proto.ConvertAmountsRequest) (*proto.ConvertAmountsResponse, error)
+	CreateShipment(context.Context, *proto.CreateShipmentRequest) (*proto.Shipment, error)
+	UpdateShipmentStatus(context.Context, *proto.UpdateShipmentStatusRequest) (*proto.Shipment, error)
+	GetShipment(context.Context, *proto.GetShipmentRequest) (*proto.Shipment, error)
+	ListShipments(context.Context, *proto.ListShipmentsRequest) (*proto.ListShipmentsResponse, error)
+	GetRevenueReport(context.Context, *proto.ReportRequest) (*proto.RevenueReport, error)
+	GetTopProducts(context.Context, *proto.ReportRequest) (*proto.TopProductsReport, error)
+	GetOrderValueReport(context.Context, *proto.ReportRequest) (*proto.OrderValueReport, error)
+}
+
+type OrderRepositoryImpl struct {
+	client proto.OrderServiceClient
+}
+
+func NewOrderRepository() *OrderRepositoryImpl {
+	conn, err := grpc.NewClient(
+		"order-service:30001",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(auth.StreamClientInterceptor()),
+	)
+	if err != nil {
+		logrus.Fatalf("Failed to connect: %v", err)
+	}
+
+	logrus.Info("Connected to product service")
+	client := proto.NewOrderServiceClient(conn)
+
+	return &OrderRepositoryImpl{client: client}
+}
+
+func (u *OrderRepositoryImpl) CreateOrder(ctx context.Context, payload *proto.CreateOrderRequest) (*proto.OrderResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Create the order and pass to order service with")
+	return u.client.CreateOrder(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) GetOrder(ctx context.Context, payload *proto.GetOrderRequest) (*proto.OrderResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Get the order by ID and pass to order service with ID: %d", payload.OrderId)
+	return u.client.GetOrder(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) ListOrders(ctx context.Context, payload *proto.ListOrdersRequest) (*proto.ListOrdersResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("List orders and pass to order service for user ID: %d", payload.UserId)
+	return u.client.ListOrders(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) CancelOrder(ctx context.Context, payload *proto.CancelOrderRequest) (*proto.OrderResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Cancel order %d and pass to order service", payload.OrderId)
+	return u.client.CancelOrder(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) GetOrderHistory(ctx context.Context, payload *proto.GetOrderHistoryRequest) (*proto.GetOrderHistoryResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Get history of order %d from order service", payload.OrderId)
+	return u.client.GetOrderHistory(ctx, payload)
+}
+
+// WatchOrder has no timeout, the stream lasts until ctx is done or the order reaches a final status.
+func (u *OrderRepositoryImpl) WatchOrder(ctx context.Context, payload *proto.WatchOrderRequest) (proto.OrderService_WatchOrderClient, error) {
+	logrus.Infof("Watch order %d from order service", payload.OrderId)
+	return u.client.WatchOrder(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) CreateReturn(ctx context.Context, payload *proto.CreateReturnRequest) (*proto.OrderReturn, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Create return for order %d and pass to order service", payload.OrderId)
+	return u.client.CreateReturn(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) ApproveReturn(ctx context.Context, payload *proto.ReviewReturnRequest) (*proto.OrderReturn, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Approve return %d and pass to order service", payload.ReturnId)
+	return u.client.ApproveReturn(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) RejectReturn(ctx context.Context, payload *proto.ReviewReturnRequest) (*proto.OrderReturn, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Reject return %d and pass to order service", payload.ReturnId)
+	return u.client.RejectReturn(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) GetReturn(ctx context.Context, payload *proto.GetReturnRequest) (*proto.OrderReturn, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Get return %d from order service", payload.ReturnId)
+	return u.client.GetReturn(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) ListReturns(ctx context.Context, payload *proto.ListReturnsRequest) (*proto.ListReturnsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("List returns from order service for user ID: %d", payload.UserId)
+	return u.client.ListReturns(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) AddOrderItem(ctx context.Context, payload *proto.AddOrderItemRequest) (*proto.OrderResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Add product %d to order %d and pass to order service", payload.ProductId, payload.OrderId)
+	return u.client.AddOrderItem(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) UpdateOrderItemQuantity(ctx context.Context, payload *proto.UpdateOrderItemQuantityRequest) (*proto.OrderResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Update item %d of order %d and pass to order service", payload.OrderItemId, payload.OrderId)
+	return u.client.UpdateOrderItemQuantity(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) RemoveOrderItem(ctx context.Context, payload *proto.RemoveOrderItemRequest) (*proto.OrderResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Remove item %d from order %d and pass to order service", payload.OrderItemId, payload.OrderId)
+	return u.client.RemoveOrderItem(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) CreatePromotion(ctx context.Context, payload *proto.Promotion) (*proto.Promotion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Create %s promotion and pass to order service", payload.Type)
+	return u.client.CreatePromotion(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) ListPromotions(ctx context.Context, payload *proto.ListPromotionsRequest) (*proto.ListPromotionsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.ListPromotions(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) DeactivatePromotion(ctx context.Context, payload *proto.GetPromotionRequest) (*proto.Promotion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Deactivate promotion %d and pass to order service", payload.PromotionId)
+	return u.client.DeactivatePromotion(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) CreateTaxRule(ctx context.Context, payload *proto.TaxRule) (*proto.TaxRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Create tax rule for region %q and pass to order service", payload.Region)
+	return u.client.CreateTaxRule(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) ListTaxRules(ctx context.Context, payload *proto.ListTaxRulesRequest) (*proto.ListTaxRulesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.ListTaxRules(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) DeactivateTaxRule(ctx context.Context, payload *proto.GetTaxRuleRequest) (*proto.TaxRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Deactivate tax rule %d and pass to order service", payload.TaxRuleId)
+	return u.client.DeactivateTaxRule(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) GetShippingQuotes(ctx context.Context, payload *proto.GetShippingQuotesRequest) (*proto.GetShippingQuotesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.GetShippingQuotes(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) SetExchangeRate(ctx context.Context, payload *proto.ExchangeRate) (*proto.ExchangeRate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Set exchange rate %s/%s and pass to order service", payload.BaseCurrency, payload.QuoteCurrency)
+	return u.client.SetExchangeRate(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) GetExchangeRate(ctx context.Context, payload *proto.GetExchangeRateRequest) (*proto.ExchangeRate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.GetExchangeRate(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) ListExchangeRates(ctx context.Context, payload *proto.ListExchangeRatesRequest) (*proto.ListExchangeRatesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.ListExchangeRates(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) ConvertAmounts(ctx context.Context, payload *proto.ConvertAmountsRequest) (*proto.ConvertAmountsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.ConvertAmounts(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) CreateShipment(ctx context.Context, payload *proto.CreateShipmentRequest) (*proto.Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Create shipment for order %d and pass to order service", payload.OrderId)
+	return u.client.CreateShipment(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) UpdateShipmentStatus(ctx context.Context, payload *proto.UpdateShipmentStatusRequest) (*proto.Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	logrus.Infof("Update shipment %d to %s and pass to order service", payload.ShipmentId, payload.Status)
+	return u.client.UpdateShipmentStatus(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) GetShipment(ctx context.Context, payload *proto.GetShipmentRequest) (*proto.Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.GetShipment(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) ListShipments(ctx context.Context, payload *proto.ListShipmentsRequest) (*proto.ListShipmentsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.ListShipments(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) GetRevenueReport(ctx context.Context, payload *proto.ReportRequest) (*proto.RevenueReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.GetRevenueReport(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) GetTopProducts(ctx context.Context, payload *proto.ReportRequest) (*proto.TopProductsReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.GetTopProducts(ctx, payload)
+}
+
+func (u *OrderRepositoryImpl) GetOrderValueReport(ctx context.Context, payload *proto.ReportRequest) (*proto.OrderValueReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.GetOrderValueReport(ctx, payload)
+}
@@ -1,46 +1,60 @@
-package repository
-
-import (
-	"broker/proto"
-	"context"
-	"time"
-
-	"github.com/sirupsen/logrus"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-)
-
-type PaymentRepository interface {
-	PayOrder(*proto.CreatePaymentRequest) (*proto.OrderPayment, error)
-	Transaction(*proto.PaymentTransaction) (*proto.EmptyPayment, error)
-}
-
-type PaymentRepositoryImpl struct {
-	client proto.PaymentServiceClient
-}
-
-func NewPaymentRepository() *PaymentRepositoryImpl {
-	conn, err := grpc.NewClient("payment-service:60001", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		logrus.Fatalf("Failed to connect: %v", err)
-	}
-
-	logrus.Info("Connected to payment service")
-	client := proto.NewPaymentServiceClient(conn)
-
-	return &PaymentRepositoryImpl{client: client}
-}
-
-func (u *PaymentRepositoryImpl) PayOrder(payload *proto.CreatePaymentRequest) (*proto.OrderPayment, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	return u.client.PayOrder(ctx, payload)
-}
-
-func (u *PaymentRepositoryImpl) Transaction(payload *proto.PaymentTransaction) (*proto.EmptyPayment, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	return u.client.Transaction(ctx, payload)
-}
+package repository
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type PaymentRepository interface {
+	PayOrder(context.Context, *proto.CreatePaymentRequest) (*proto.OrderPayment, error)
+	Transaction(context.Context, *proto.PaymentTransaction) (*proto.EmptyPayment, error)
+	GetPaymentReport(context.Context, *proto.PaymentReportRequest) (*proto.PaymentReport, error)
+}
+
+type PaymentRepositoryImpl struct {
+	client proto.PaymentServiceClient
+}
+
+func NewPaymentRepository() *PaymentRepositoryImpl {
+	conn, err := grpc.NewClient(
+		"payment-service:60001",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(auth.StreamClientInterceptor()),
+	)
+	if err != nil {
+		logrus.Fatalf("Failed to connect: %v", err)
+	}
+
+	logrus.Info("Connected to payment service")
+	client := proto.NewPaymentServiceClient(conn)
+
+	return &PaymentRepositoryImpl{client: client}
+}
+
+func (u *PaymentRepositoryImpl) PayOrder(ctx context.Context, payload *proto.CreatePaymentRequest) (*proto.OrderPayment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.PayOrder(ctx, payload)
+}
+
+func (u *PaymentRepositoryImpl) Transaction(ctx context.Context, payload *proto.PaymentTransaction) (*proto.EmptyPayment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.Transaction(ctx, payload)
+}
+
+func (u *PaymentRepositoryImpl) GetPaymentReport(ctx context.Context, payload *proto.PaymentReportRequest) (*proto.PaymentReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.GetPaymentReport(ctx, payload)
+}
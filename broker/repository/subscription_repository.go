@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"broker/auth"
+	"broker/proto"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type SubscriptionRepository interface {
+	CreateSubscription(context.Context, *proto.CreateSubscriptionRequest) (*proto.Subscription, error)
+	GetSubscription(context.Context, *proto.GetSubscriptionRequest) (*proto.Subscription, error)
+	ListSubscriptions(context.Context, *proto.ListSubscriptionsRequest) (*proto.ListSubscriptionsResponse, error)
+	PauseSubscription(context.Context, *proto.GetSubscriptionRequest) (*proto.Subscription, error)
+	ResumeSubscription(context.Context, *proto.GetSubscriptionRequest) (*proto.Subscription, error)
+	SkipSubscriptionCycle(context.Context, *proto.GetSubscriptionRequest) (*proto.Subscription, error)
+	CancelSubscription(context.Context, *proto.GetSubscriptionRequest) (*proto.Subscription, error)
+}
+
+type SubscriptionRepositoryImpl struct {
+	client proto.SubscriptionServiceClient
+}
+
+// NewSubscriptionRepository connects to the subscription service, which is served by the order service.
+func NewSubscriptionRepository() *SubscriptionRepositoryImpl {
+	conn, err := grpc.NewClient(
+		"order-service:30001",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(auth.StreamClientInterceptor()),
+	)
+	if err != nil {
+		logrus.Fatalf("Failed to connect: %v", err)
+	}
+
+	logrus.Info("Connected to subscription service")
+	client := proto.NewSubscriptionServiceClient(conn)
+
+	return &SubscriptionRepositoryImpl{client: client}
+}
+
+func (u *SubscriptionRepositoryImpl) CreateSubscription(ctx context.Context, payload *proto.CreateSubscriptionRequest) (*proto.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.CreateSubscription(ctx, payload)
+}
+
+func (u *SubscriptionRepositoryImpl) GetSubscription(ctx context.Context, payload *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.GetSubscription(ctx, payload)
+}
+
+func (u *SubscriptionRepositoryImpl) ListSubscriptions(ctx context.Context, payload *proto.ListSubscriptionsRequest) (*proto.ListSubscriptionsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.ListSubscriptions(ctx, payload)
+}
+
+func (u *SubscriptionRepositoryImpl) PauseSubscription(ctx context.Context, payload *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.PauseSubscription(ctx, payload)
+}
+
+func (u *SubscriptionRepositoryImpl) ResumeSubscription(ctx context.Context, payload *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.ResumeSubscription(ctx, payload)
+}
+
+func (u *SubscriptionRepositoryImpl) SkipSubscriptionCycle(ctx context.Context, payload *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.SkipSubscriptionCycle(ctx, payload)
+}
+
+func (u *SubscriptionRepositoryImpl) CancelSubscription(ctx context.Context, payload *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return u.client.CancelSubscription(ctx, payload)
+}
@@ -0,0 +1,8 @@
+package types
+
+// OrderWatchEvent is a message of an order watch: a status change, the end of the watch when the order
+// reached a final status, or an error.
+type OrderWatchEvent struct {
+	Event string `json:"event"`
+	Data  any    `json:"data"`
+}
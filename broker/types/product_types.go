@@ -1,13 +1,20 @@
 package types
 
 type ProductDTO struct {
-	Id          uint32  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Stock       int32   `json:"stock"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	Id          uint32 `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       int64  `json:"price"`
+	Currency    string `json:"currency"`
+	// The price in the currency of the product when Price was converted for the client
+	BasePrice    int64  `json:"base_price,omitempty"`
+	BaseCurrency string `json:"base_currency,omitempty"`
+	Stock        int32  `json:"stock"`
+	Category     string `json:"category"`
+	TaxClass     string `json:"tax_class"`
+	WeightGrams  int32  `json:"weight_grams"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
 }
 
 type ProductListDTO struct {
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+)
+
+var ErrCartItemNotFound = errors.New("cart item not found")
+
+type CartRepository interface {
+	GetCartItems(userID int, db *sql.DB) ([]*proto.CartItem, error)
+	GetCartItemsForUpdate(userID int, tx *sql.Tx) ([]*proto.CartItem, error)
+	AddCartItem(userID int, productID int, quantity int, db *sql.DB) error
+	UpdateCartItemQuantity(userID int, productID int, quantity int, db *sql.DB) error
+	DeleteCartItem(userID int, productID int, db *sql.DB) error
+	DeleteCartItems(userID int, productIDs []int32, tx *sql.Tx) error
+	ClearCart(userID int, db *sql.DB) error
+}
+
+type CartRepositoryImpl struct{}
+
+func NewCartRepositoryImpl() *CartRepositoryImpl {
+	return &CartRepositoryImpl{}
+}
+
+const selectCartItemsSQL = "SELECT product_id, quantity, created_at FROM cart_items WHERE user_id = $1 ORDER BY created_at ASC, product_id ASC"
+
+// scanCartItems reads the stored part of cart items, product details are filled in by the service.
+func scanCartItems(rows *sql.Rows) ([]*proto.CartItem, error) {
+	defer rows.Close()
+
+	var items []*proto.CartItem
+	for rows.Next() {
+		item := &proto.CartItem{}
+		if err := rows.Scan(&item.ProductId, &item.Quantity, &item.AddedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (u *CartRepositoryImpl) GetCartItems(userID int, db *sql.DB) ([]*proto.CartItem, error) {
+	rows, err := db.Query(selectCartItemsSQL, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanCartItems(rows)
+}
+
+func (u *CartRepositoryImpl) GetCartItemsForUpdate(userID int, tx *sql.Tx) ([]*proto.CartItem, error) {
+	rows, err := tx.Query(selectCartItemsSQL+" FOR UPDATE", userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanCartItems(rows)
+}
+
+// AddCartItem puts a product in the cart or adds the quantity to the line it already has.
+func (u *CartRepositoryImpl) AddCartItem(userID int, productID int, quantity int, db *sql.DB) error {
+	SQL := `INSERT INTO cart_items(user_id, product_id, quantity) VALUES ($1, $2, $3)
+        ON CONFLICT (user_id, product_id)
+        DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = CURRENT_TIMESTAMP`
+	if _, err := db.Exec(SQL, userID, productID, quantity); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *CartRepositoryImpl) UpdateCartItemQuantity(userID int, productID int, quantity int, db *sql.DB) error {
+	SQL := "UPDATE cart_items SET quantity = $3, updated_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND product_id = $2"
+	result, err := db.Exec(SQL, userID, productID, quantity)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (u *CartRepositoryImpl) DeleteCartItem(userID int, productID int, db *sql.DB) error {
+	SQL := "DELETE FROM cart_items WHERE user_id = $1 AND product_id = $2"
+	result, err := db.Exec(SQL, userID, productID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (u *CartRepositoryImpl) DeleteCartItems(userID int, productIDs []int32, tx *sql.Tx) error {
+	SQL := "DELETE FROM cart_items WHERE user_id = $1 AND product_id = ANY($2)"
+	if _, err := tx.Exec(SQL, userID, productIDs); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *CartRepositoryImpl) ClearCart(userID int, db *sql.DB) error {
+	SQL := "DELETE FROM cart_items WHERE user_id = $1"
+	if _, err := db.Exec(SQL, userID); err != nil {
+		return err
+	}
+	return nil
+}
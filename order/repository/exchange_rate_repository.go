@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+)
+
+var ErrExchangeRateNotFound = errors.New("exchange rate not found")
+
+type ExchangeRateRepository interface {
+	UpsertExchangeRate(payload *proto.ExchangeRate, db *sql.DB) error
+	GetExchangeRate(baseCurrency string, quoteCurrency string, db *sql.DB) (*proto.ExchangeRate, error)
+	ListExchangeRates(baseCurrency string, db *sql.DB) ([]*proto.ExchangeRate, error)
+}
+
+type ExchangeRateRepositoryImpl struct{}
+
+func NewExchangeRateRepositoryImpl() *ExchangeRateRepositoryImpl {
+	return &ExchangeRateRepositoryImpl{}
+}
+
+const selectExchangeRateSQL = "SELECT base_currency, quote_currency, rate, source, updated_at FROM exchange_rates"
+
+func scanExchangeRate(row interface{ Scan(...any) error }) (*proto.ExchangeRate, error) {
+	exchangeRate := &proto.ExchangeRate{}
+	if err := row.Scan(
+		&exchangeRate.BaseCurrency,
+		&exchangeRate.QuoteCurrency,
+		&exchangeRate.Rate,
+		&exchangeRate.Source,
+		&exchangeRate.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return exchangeRate, nil
+}
+
+func (u *ExchangeRateRepositoryImpl) UpsertExchangeRate(payload *proto.ExchangeRate, db *sql.DB) error {
+	SQL := `INSERT INTO exchange_rates(base_currency, quote_currency, rate, source) VALUES ($1, $2, $3, $4)
+        ON CONFLICT (base_currency, quote_currency)
+        DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source, updated_at = NOW()`
+	if _, err := db.Exec(SQL, payload.BaseCurrency, payload.QuoteCurrency, payload.Rate, payload.Source); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *ExchangeRateRepositoryImpl) GetExchangeRate(baseCurrency string, quoteCurrency string, db *sql.DB) (*proto.ExchangeRate, error) {
+	exchangeRate, err := scanExchangeRate(db.QueryRow(selectExchangeRateSQL+" WHERE base_currency = $1 AND quote_currency = $2", baseCurrency, quoteCurrency))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrExchangeRateNotFound
+		}
+		return nil, err
+	}
+	return exchangeRate, nil
+}
+
+func (u *ExchangeRateRepositoryImpl) ListExchangeRates(baseCurrency string, db *sql.DB) ([]*proto.ExchangeRate, error) {
+	SQL := selectExchangeRateSQL
+	var args []any
+	if baseCurrency != "" {
+		SQL += " WHERE base_currency = $1"
+		args = append(args, baseCurrency)
+	}
+	rows, err := db.Query(SQL+" ORDER BY base_currency ASC, quote_currency ASC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exchangeRates []*proto.ExchangeRate
+	for rows.Next() {
+		exchangeRate, err := scanExchangeRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		exchangeRates = append(exchangeRates, exchangeRate)
+	}
+
+	return exchangeRates, rows.Err()
+}
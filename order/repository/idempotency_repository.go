@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+type IdempotencyRecord struct {
+	UserID      int32
+	Key         string
+	RequestHash string
+	Response    []byte
+}
+
+type IdempotencyRepository interface {
+	GetIdempotencyKey(userID int32, key string, db *sql.DB) (*IdempotencyRecord, error)
+	CreateIdempotencyKey(record *IdempotencyRecord, tx *sql.Tx) (bool, error)
+}
+
+type IdempotencyRepositoryImpl struct{}
+
+func NewIdempotencyRepositoryImpl() *IdempotencyRepositoryImpl {
+	return &IdempotencyRepositoryImpl{}
+}
+
+func (u *IdempotencyRepositoryImpl) GetIdempotencyKey(userID int32, key string, db *sql.DB) (*IdempotencyRecord, error) {
+	SQL := "SELECT user_id, idempotency_key, request_hash, response FROM order_idempotency_keys WHERE user_id = $1 AND idempotency_key = $2"
+	record := &IdempotencyRecord{}
+	if err := db.QueryRow(SQL, userID, key).Scan(&record.UserID, &record.Key, &record.RequestHash, &record.Response); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrIdempotencyKeyNotFound
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// CreateIdempotencyKey returns false when another request already stored the key, a concurrent
+// insert waits for the first transaction to finish before it knows.
+func (u *IdempotencyRepositoryImpl) CreateIdempotencyKey(record *IdempotencyRecord, tx *sql.Tx) (bool, error) {
+	SQL := `INSERT INTO order_idempotency_keys(user_id, idempotency_key, request_hash, response) VALUES ($1, $2, $3, $4)
+        ON CONFLICT (user_id, idempotency_key) DO NOTHING`
+	result, err := tx.Exec(SQL, record.UserID, record.Key, record.RequestHash, record.Response)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
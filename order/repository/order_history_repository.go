@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"database/sql"
+	"order/proto"
+)
+
+type OrderHistoryRepository interface {
+	CreateStatusChange(change *proto.OrderStatusChange, tx *sql.Tx) error
+	GetOrderHistory(orderID int, db *sql.DB) ([]*proto.OrderStatusChange, error)
+	GetStatusChangesAfter(afterID int, limit int, db *sql.DB) ([]*proto.OrderStatusChange, error)
+	GetLastStatusChangeID(db *sql.DB) (int, error)
+}
+
+type OrderHistoryRepositoryImpl struct{}
+
+func NewOrderHistoryRepositoryImpl() *OrderHistoryRepositoryImpl {
+	return &OrderHistoryRepositoryImpl{}
+}
+
+func (u *OrderHistoryRepositoryImpl) CreateStatusChange(change *proto.OrderStatusChange, tx *sql.Tx) error {
+	SQL := `INSERT INTO order_status_history(order_id, old_status, new_status, actor, reason)
+        VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''))`
+	if _, err := tx.Exec(SQL, change.OrderId, change.OldStatus, change.NewStatus, change.Actor, change.Reason); err != nil {
+		return err
+	}
+	return nil
+}
+
+const selectStatusChangeSQL = `SELECT id, order_id, COALESCE(old_status, ''), new_status, actor, COALESCE(reason, ''), created_at
+        FROM order_status_history`
+
+func (u *OrderHistoryRepositoryImpl) GetOrderHistory(orderID int, db *sql.DB) ([]*proto.OrderStatusChange, error) {
+	rows, err := db.Query(selectStatusChangeSQL+" WHERE order_id = $1 ORDER BY id ASC", orderID)
+	if err != nil {
+		return nil, err
+	}
+	return scanStatusChanges(rows)
+}
+
+// GetStatusChangesAfter returns the changes of every order with an ID above afterID, oldest first.
+func (u *OrderHistoryRepositoryImpl) GetStatusChangesAfter(afterID int, limit int, db *sql.DB) ([]*proto.OrderStatusChange, error) {
+	rows, err := db.Query(selectStatusChangeSQL+" WHERE id > $1 ORDER BY id ASC LIMIT $2", afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanStatusChanges(rows)
+}
+
+func (u *OrderHistoryRepositoryImpl) GetLastStatusChangeID(db *sql.DB) (int, error) {
+	var lastID int
+	if err := db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM order_status_history").Scan(&lastID); err != nil {
+		return 0, err
+	}
+	return lastID, nil
+}
+
+func scanStatusChanges(rows *sql.Rows) ([]*proto.OrderStatusChange, error) {
+	defer rows.Close()
+
+	var history []*proto.OrderStatusChange
+	for rows.Next() {
+		change := &proto.OrderStatusChange{}
+		if err := rows.Scan(
+			&change.Id,
+			&change.OrderId,
+			&change.OldStatus,
+			&change.NewStatus,
+			&change.Actor,
+			&change.Reason,
+			&change.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	return history, rows.Err()
+}
@@ -1,35 +1,147 @@
-package repository
-
-import (
-	"database/sql"
-	"order/proto"
-)
-
-type OrderItemsRepository interface {
-	CreateOrderItems(payload *proto.OrderItemRequest, tx *sql.Tx) error
-	DeleteOrderItems(payload *proto.GetOrderItemRequest, tx *sql.Tx) error
-}
-
-type OrderItemsRepositoryImpl struct{}
-
-func NewOrderItemsRepositoryImpl() *OrderItemsRepositoryImpl {
-	return &OrderItemsRepositoryImpl{}
-}
-
-func (u *OrderItemsRepositoryImpl) CreateOrderItems(payload *proto.OrderItemRequest, tx *sql.Tx) error {
-	SQL := "INSERT INTO order_items(order_id, product_id, quantity) VALUES ($1, $2, $3)"
-	if _, err := tx.Exec(SQL, payload.OrderId, payload.ProductId, payload.Quantity); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (u *OrderItemsRepositoryImpl) DeleteOrderItems(payload *proto.GetOrderItemRequest, tx *sql.Tx) error {
-	SQL := "DELETE FROM order_items WHERE id = $1"
-	if _, err := tx.Exec(SQL, payload.OrderItemId); err != nil {
-		return err
-	}
-
-	return nil
-}
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+)
+
+var ErrOrderItemNotFound = errors.New("order item not found")
+
+type OrderItemsRepository interface {
+	CreateOrderItems(payload *proto.OrderItem, tx *sql.Tx) error
+	GetOrderItems(orderID int, db *sql.DB) ([]*proto.OrderItem, error)
+	GetOrderItemsForUpdate(orderID int, tx *sql.Tx) ([]*proto.OrderItem, error)
+	GetOrderItem(orderItemID int, tx *sql.Tx) (*proto.OrderItem, error)
+	GetOrderItemByProductID(orderID int, productID int, tx *sql.Tx) (*proto.OrderItem, error)
+	UpdateOrderItemQuantity(orderItemID int, quantity int, tx *sql.Tx) error
+	UpdateOrderItemTax(orderItemID int, taxAmount int64, tx *sql.Tx) error
+	SumOrderItems(orderID int, tx *sql.Tx) (int64, int, error)
+	DeleteOrderItems(payload *proto.GetOrderItemRequest, tx *sql.Tx) error
+}
+
+type OrderItemsRepositoryImpl struct{}
+
+func NewOrderItemsRepositoryImpl() *OrderItemsRepositoryImpl {
+	return &OrderItemsRepositoryImpl{}
+}
+
+func (u *OrderItemsRepositoryImpl) CreateOrderItems(payload *proto.OrderItem, tx *sql.Tx) error {
+	SQL := `INSERT INTO order_items(order_id, product_id, quantity, price, product_name, category, tax_class, weight_grams)
+        VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'standard'), $8) RETURNING id, tax_class`
+	if err := tx.QueryRow(SQL, payload.OrderId, payload.ProductId, payload.Quantity, payload.Price, payload.ProductName, payload.Category, payload.TaxClass, payload.WeightGrams).Scan(&payload.Id, &payload.TaxClass); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *OrderItemsRepositoryImpl) GetOrderItems(orderID int, db *sql.DB) ([]*proto.OrderItem, error) {
+	return getOrderItems(db.Query, orderID, "")
+}
+
+// GetOrderItemsForUpdate reads the items of an order inside a transaction that is changing them.
+func (u *OrderItemsRepositoryImpl) GetOrderItemsForUpdate(orderID int, tx *sql.Tx) ([]*proto.OrderItem, error) {
+	return getOrderItems(tx.Query, orderID, " FOR UPDATE")
+}
+
+func getOrderItems(query func(string, ...any) (*sql.Rows, error), orderID int, lock string) ([]*proto.OrderItem, error) {
+	SQL := "SELECT id, order_id, product_id, quantity, price, product_name, category, tax_class, tax_amount, weight_grams FROM order_items WHERE order_id = $1 ORDER BY id ASC" + lock
+	rows, err := query(SQL, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orderItems []*proto.OrderItem
+	for rows.Next() {
+		orderItem := &proto.OrderItem{}
+		if err := rows.Scan(
+			&orderItem.Id,
+			&orderItem.OrderId,
+			&orderItem.ProductId,
+			&orderItem.Quantity,
+			&orderItem.Price,
+			&orderItem.ProductName,
+			&orderItem.Category,
+			&orderItem.TaxClass,
+			&orderItem.TaxAmount,
+			&orderItem.WeightGrams,
+		); err != nil {
+			return nil, err
+		}
+		orderItems = append(orderItems, orderItem)
+	}
+
+	return orderItems, rows.Err()
+}
+
+func (u *OrderItemsRepositoryImpl) GetOrderItem(orderItemID int, tx *sql.Tx) (*proto.OrderItem, error) {
+	SQL := "SELECT id, order_id, product_id, quantity, price, product_name, category, tax_class, tax_amount, weight_grams FROM order_items WHERE id = $1"
+	return scanOrderItem(tx.QueryRow(SQL, orderItemID))
+}
+
+func (u *OrderItemsRepositoryImpl) GetOrderItemByProductID(orderID int, productID int, tx *sql.Tx) (*proto.OrderItem, error) {
+	SQL := "SELECT id, order_id, product_id, quantity, price, product_name, category, tax_class, tax_amount, weight_grams FROM order_items WHERE order_id = $1 AND product_id = $2"
+	return scanOrderItem(tx.QueryRow(SQL, orderID, productID))
+}
+
+func scanOrderItem(row *sql.Row) (*proto.OrderItem, error) {
+	orderItem := &proto.OrderItem{}
+	if err := row.Scan(
+		&orderItem.Id,
+		&orderItem.OrderId,
+		&orderItem.ProductId,
+		&orderItem.Quantity,
+		&orderItem.Price,
+		&orderItem.ProductName,
+		&orderItem.Category,
+		&orderItem.TaxClass,
+		&orderItem.TaxAmount,
+		&orderItem.WeightGrams,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOrderItemNotFound
+		}
+		return nil, err
+	}
+	return orderItem, nil
+}
+
+func (u *OrderItemsRepositoryImpl) UpdateOrderItemQuantity(orderItemID int, quantity int, tx *sql.Tx) error {
+	SQL := "UPDATE order_items SET quantity = $1 WHERE id = $2"
+	if _, err := tx.Exec(SQL, quantity, orderItemID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *OrderItemsRepositoryImpl) UpdateOrderItemTax(orderItemID int, taxAmount int64, tx *sql.Tx) error {
+	SQL := "UPDATE order_items SET tax_amount = $1 WHERE id = $2"
+	if _, err := tx.Exec(SQL, taxAmount, orderItemID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SumOrderItems returns the total price and the number of items of an order.
+func (u *OrderItemsRepositoryImpl) SumOrderItems(orderID int, tx *sql.Tx) (int64, int, error) {
+	SQL := "SELECT COALESCE(SUM(quantity * price), 0), COUNT(*) FROM order_items WHERE order_id = $1"
+	var total int64
+	var count int
+	if err := tx.QueryRow(SQL, orderID).Scan(&total, &count); err != nil {
+		return 0, 0, err
+	}
+
+	return total, count, nil
+}
+
+func (u *OrderItemsRepositoryImpl) DeleteOrderItems(payload *proto.GetOrderItemRequest, tx *sql.Tx) error {
+	SQL := "DELETE FROM order_items WHERE id = $1"
+	if _, err := tx.Exec(SQL, payload.OrderItemId); err != nil {
+		return err
+	}
+
+	return nil
+}
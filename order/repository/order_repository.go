@@ -1,58 +1,242 @@
-package repository
-
-import (
-	"database/sql"
-	"errors"
-	"order/proto"
-	"time"
-)
-
-type OrderRepository interface {
-	CreateOrder(payload *proto.CreateOrderRequest, price float64, tx *sql.Tx) (int, error)
-	GetOrderByID(payload *proto.GetOrderRequest, db *sql.DB) (*proto.Order, error)
-	UpdateOrderStatus(status string, orderID int, tx *sql.Tx) error
-}
-
-type OrderRepositoryImpl struct{}
-
-func NewOrderRepositoryImpl() *OrderRepositoryImpl {
-	return &OrderRepositoryImpl{}
-}
-
-func (u *OrderRepositoryImpl) CreateOrder(payload *proto.CreateOrderRequest, price float64, tx *sql.Tx) (int, error) {
-	SQL := "INSERT INTO orders(user_id, total_price, status) VALUES ($1, $2, $3) RETURNING id"
-	var orderID int
-	err := tx.QueryRow(SQL, payload.UserId, price, "pending").Scan(&orderID)
-	if err != nil {
-		return 0, err
-	}
-	return orderID, nil
-}
-
-func (u *OrderRepositoryImpl) GetOrderByID(payload *proto.GetOrderRequest, db *sql.DB) (*proto.Order, error) {
-	SQL := "SELECT id, user_id, status, total_price, created_at, updated_at FROM orders WHERE id = $1"
-	rows := db.QueryRow(SQL, payload.OrderId)
-
-	orderResponse := &proto.Order{}
-	if err := rows.Scan(
-		&orderResponse.Id,
-		&orderResponse.UserId,
-		&orderResponse.Status,
-		&orderResponse.TotalPrice,
-		&orderResponse.CreatedAt,
-		&orderResponse.UpdatedAt,
-	); err != nil {
-		return nil, errors.New("order not found")
-	}
-	return orderResponse, nil
-}
-
-func (u *OrderRepositoryImpl) UpdateOrderStatus(status string, orderID int, tx *sql.Tx) error {
-	loc := time.FixedZone("WIB", 7*60*60)
-	SQL := `UPDATE orders SET status = $1, updated_at = $2 WHERE id = $3`
-	now := time.Now().In(loc)
-	if _, err := tx.Exec(SQL, status, now, orderID); err != nil {
-		return err
-	}
-	return nil
-}
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"order/proto"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+
+type OrderListFilter struct {
+	UserID      int
+	Status      string
+	CreatedFrom string
+	CreatedTo   string
+	MinTotal    int64
+	MaxTotal    int64
+	SortBy      string
+	Descending  bool
+	Limit       int
+	AfterValue  string
+	AfterID     int
+}
+
+type OrderRepository interface {
+	CreateOrder(payload *proto.CreateOrderRequest, price int64, rate *proto.ExchangeRate, status string, reservationID string, tx *sql.Tx) (int, error)
+	GetOrderByID(payload *proto.GetOrderRequest, db *sql.DB) (*proto.Order, error)
+	ListOrders(filter *OrderListFilter, db *sql.DB) ([]*proto.Order, error)
+	GetOrderStatusForUpdate(orderID int, tx *sql.Tx) (string, error)
+	GetOrderReservationID(orderID int, tx *sql.Tx) (string, error)
+	GetExpiredPendingOrderIDs(ttlSeconds float64, limit int, db *sql.DB) ([]int, error)
+	UpdateOrderTotals(orderID int, subtotal int64, discountTotal int64, taxTotal int64, shippingCost int64, totalPrice int64, tx *sql.Tx) error
+	UpdateOrderStatus(status string, orderID int, tx *sql.Tx) error
+}
+
+type OrderRepositoryImpl struct{}
+
+func NewOrderRepositoryImpl() *OrderRepositoryImpl {
+	return &OrderRepositoryImpl{}
+}
+
+// CreateOrder stores an order priced in the quote currency of rate, converted from the base currency
+// of its products.
+func (u *OrderRepositoryImpl) CreateOrder(payload *proto.CreateOrderRequest, price int64, rate *proto.ExchangeRate, status string, reservationID string, tx *sql.Tx) (int, error) {
+	SQL := `INSERT INTO orders(user_id, subtotal, total_price, currency, base_currency, exchange_rate, status, reservation_id,
+            coupon_code, region)
+        VALUES ($1, $2, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, '')) RETURNING id`
+	var orderID int
+	err := tx.QueryRow(SQL, payload.UserId, price, rate.QuoteCurrency, rate.BaseCurrency, rate.Rate, status, reservationID,
+		payload.CouponCode, payload.Region).Scan(&orderID)
+	if err != nil {
+		return 0, err
+	}
+	return orderID, nil
+}
+
+func (u *OrderRepositoryImpl) GetOrderByID(payload *proto.GetOrderRequest, db *sql.DB) (*proto.Order, error) {
+	SQL := `SELECT id, user_id, status, subtotal, discount_total, tax_total, shipping_cost, total_price, currency, base_currency,
+        exchange_rate, COALESCE(coupon_code, ''), COALESCE(region, ''), created_at, updated_at
+        FROM orders WHERE id = $1`
+	rows := db.QueryRow(SQL, payload.OrderId)
+
+	orderResponse := &proto.Order{}
+	if err := rows.Scan(
+		&orderResponse.Id,
+		&orderResponse.UserId,
+		&orderResponse.Status,
+		&orderResponse.Subtotal,
+		&orderResponse.DiscountTotal,
+		&orderResponse.TaxTotal,
+		&orderResponse.ShippingCost,
+		&orderResponse.TotalPrice,
+		&orderResponse.Currency,
+		&orderResponse.BaseCurrency,
+		&orderResponse.ExchangeRate,
+		&orderResponse.CouponCode,
+		&orderResponse.Region,
+		&orderResponse.CreatedAt,
+		&orderResponse.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, ErrOrderNotFound.Error())
+		}
+		return nil, err
+	}
+	return orderResponse, nil
+}
+
+// ListOrders uses keyset pagination on (sort column, id); SortBy must already be validated by the caller.
+func (u *OrderRepositoryImpl) ListOrders(filter *OrderListFilter, db *sql.DB) ([]*proto.Order, error) {
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.CreatedFrom != "" {
+		addCondition("created_at >= $%d::timestamp", filter.CreatedFrom)
+	}
+	if filter.CreatedTo != "" {
+		addCondition("created_at < $%d::timestamp", filter.CreatedTo)
+	}
+	if filter.MinTotal > 0 {
+		addCondition("total_price >= $%d", filter.MinTotal)
+	}
+	if filter.MaxTotal > 0 {
+		addCondition("total_price <= $%d", filter.MaxTotal)
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	cast := "::timestamp"
+	if filter.SortBy == "total_price" {
+		cast = "::bigint"
+	}
+	if filter.AfterID != 0 {
+		args = append(args, filter.AfterValue, filter.AfterID)
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d%s, $%d)", filter.SortBy, comparison, len(args)-1, cast, len(args)))
+	}
+
+	SQL := `SELECT id, user_id, status, subtotal, discount_total, tax_total, shipping_cost, total_price, currency, base_currency,
+        exchange_rate, COALESCE(coupon_code, ''), COALESCE(region, ''), created_at, updated_at FROM orders`
+	if len(conditions) > 0 {
+		SQL += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	SQL += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT $%d", filter.SortBy, direction, direction, len(args))
+
+	rows, err := db.Query(SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*proto.Order
+	for rows.Next() {
+		order := &proto.Order{}
+		if err := rows.Scan(
+			&order.Id,
+			&order.UserId,
+			&order.Status,
+			&order.Subtotal,
+			&order.DiscountTotal,
+			&order.TaxTotal,
+			&order.ShippingCost,
+			&order.TotalPrice,
+			&order.Currency,
+			&order.BaseCurrency,
+			&order.ExchangeRate,
+			&order.CouponCode,
+			&order.Region,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+func (u *OrderRepositoryImpl) GetOrderStatusForUpdate(orderID int, tx *sql.Tx) (string, error) {
+	SQL := "SELECT status FROM orders WHERE id = $1 FOR UPDATE"
+	var status string
+	if err := tx.QueryRow(SQL, orderID).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrOrderNotFound
+		}
+		return "", err
+	}
+	return status, nil
+}
+
+func (u *OrderRepositoryImpl) GetOrderReservationID(orderID int, tx *sql.Tx) (string, error) {
+	SQL := "SELECT COALESCE(reservation_id::text, '') FROM orders WHERE id = $1"
+	var reservationID string
+	if err := tx.QueryRow(SQL, orderID).Scan(&reservationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrOrderNotFound
+		}
+		return "", err
+	}
+	return reservationID, nil
+}
+
+func (u *OrderRepositoryImpl) GetExpiredPendingOrderIDs(ttlSeconds float64, limit int, db *sql.DB) ([]int, error) {
+	SQL := `SELECT id FROM orders
+        WHERE status = 'pending' AND created_at <= NOW() - make_interval(secs => $1)
+        ORDER BY created_at ASC
+        LIMIT $2`
+	rows, err := db.Query(SQL, ttlSeconds, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orderIDs []int
+	for rows.Next() {
+		var orderID int
+		if err := rows.Scan(&orderID); err != nil {
+			return nil, err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+
+	return orderIDs, rows.Err()
+}
+
+func (u *OrderRepositoryImpl) UpdateOrderTotals(orderID int, subtotal int64, discountTotal int64, taxTotal int64, shippingCost int64, totalPrice int64, tx *sql.Tx) error {
+	SQL := `UPDATE orders SET subtotal = $1, discount_total = $2, tax_total = $3, shipping_cost = $4, total_price = $5, updated_at = NOW()
+        WHERE id = $6`
+	if _, err := tx.Exec(SQL, subtotal, discountTotal, taxTotal, shippingCost, totalPrice, orderID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *OrderRepositoryImpl) UpdateOrderStatus(status string, orderID int, tx *sql.Tx) error {
+	loc := time.FixedZone("WIB", 7*60*60)
+	SQL := `UPDATE orders SET status = $1, updated_at = $2 WHERE id = $3`
+	now := time.Now().In(loc)
+	if _, err := tx.Exec(SQL, status, now, orderID); err != nil {
+		return err
+	}
+	return nil
+}
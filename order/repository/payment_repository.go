@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"order/proto"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataService names the calling service to the payment service, which only trusts calls without a
+// user when they carry it.
+const metadataService = "x-internal-service"
+
+type PaymentRepository interface {
+	PayOrder(payload *proto.CreatePaymentRequest) (*proto.OrderPayment, error)
+	GetPayment(payload *proto.GetPaymentRequest) (*proto.OrderPayment, error)
+	Transaction(payload *proto.PaymentTransaction) (*proto.EmptyPayment, error)
+	VoidPayment(payload *proto.GetPaymentRequest) (*proto.OrderPayment, error)
+	ExpirePayment(payload *proto.GetPaymentRequest) (*proto.OrderPayment, error)
+	RefundPayment(payload *proto.RefundPaymentRequest) (*proto.PaymentRefund, error)
+	UpdatePaymentAmount(payload *proto.UpdatePaymentAmountRequest) (*proto.OrderPayment, error)
+}
+
+type PaymentRepositoryImpl struct {
+	client proto.PaymentServiceClient
+}
+
+func NewPaymentRepositoryImpl() *PaymentRepositoryImpl {
+	conn, err := grpc.NewClient(
+		"payment-service:60001",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(internalServiceInterceptor),
+	)
+	if err != nil {
+		logrus.Fatalf("Failed to connect: %v", err)
+	}
+	logrus.Info("Connected to payment service")
+
+	client := proto.NewPaymentServiceClient(conn)
+	return &PaymentRepositoryImpl{client: client}
+}
+
+func internalServiceInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(metadata.AppendToOutgoingContext(ctx, metadataService, "order-service"), method, req, reply, cc, opts...)
+}
+
+func (u *PaymentRepositoryImpl) PayOrder(payload *proto.CreatePaymentRequest) (*proto.OrderPayment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.PayOrder(ctx, payload)
+}
+
+func (u *PaymentRepositoryImpl) GetPayment(payload *proto.GetPaymentRequest) (*proto.OrderPayment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.GetPayment(ctx, payload)
+}
+
+func (u *PaymentRepositoryImpl) Transaction(payload *proto.PaymentTransaction) (*proto.EmptyPayment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.Transaction(ctx, payload)
+}
+
+func (u *PaymentRepositoryImpl) VoidPayment(payload *proto.GetPaymentRequest) (*proto.OrderPayment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.VoidPayment(ctx, payload)
+}
+
+func (u *PaymentRepositoryImpl) ExpirePayment(payload *proto.GetPaymentRequest) (*proto.OrderPayment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.ExpirePayment(ctx, payload)
+}
+
+func (u *PaymentRepositoryImpl) RefundPayment(payload *proto.RefundPaymentRequest) (*proto.PaymentRefund, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.RefundPayment(ctx, payload)
+}
+
+func (u *PaymentRepositoryImpl) UpdatePaymentAmount(payload *proto.UpdatePaymentAmountRequest) (*proto.OrderPayment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.UpdatePaymentAmount(ctx, payload)
+}
@@ -1,69 +1,109 @@
-package repository
-
-import (
-	"context"
-	"order/proto"
-	"time"
-
-	"github.com/sirupsen/logrus"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-)
-
-type ProductRepository interface {
-	Create(payload *proto.ProductRequest) (*proto.Empty, error)
-	GetProduct(payload *proto.GetProductRequest) (*proto.Product, error)
-	ListProducts(offset *proto.Offset) (*proto.ProductList, error)
-	UpdateProduct(payload *proto.Product) (*proto.Product, error)
-	DeleteProduct(ID *proto.GetProductRequest) (*proto.Empty, error)
-}
-
-type ProductRepositoryImpl struct {
-	client proto.ProductServiceClient
-}
-
-func NewProductRepositoryImpl() *ProductRepositoryImpl {
-	conn, err := grpc.NewClient("product-service:40001", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		logrus.Fatalf("Failed to connect: %v", err)
-	}
-	logrus.Info("Connected to product service")
-
-	client := proto.NewProductServiceClient(conn)
-	return &ProductRepositoryImpl{client: client}
-}
-
-func (u *ProductRepositoryImpl) Create(payload *proto.ProductRequest) (*proto.Empty, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	return u.client.CreateProduct(ctx, payload)
-}
-
-func (u *ProductRepositoryImpl) GetProduct(payload *proto.GetProductRequest) (*proto.Product, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	return u.client.GetProduct(ctx, payload)
-}
-
-func (u *ProductRepositoryImpl) ListProducts(offset *proto.Offset) (*proto.ProductList, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	return u.client.ListProducts(ctx, offset)
-}
-
-func (u *ProductRepositoryImpl) UpdateProduct(payload *proto.Product) (*proto.Product, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	return u.client.UpdateProduct(ctx, payload)
-}
-
-func (u *ProductRepositoryImpl) DeleteProduct(ID *proto.GetProductRequest) (*proto.Empty, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	return u.client.DeleteProduct(ctx, ID)
-}
+package repository
+
+import (
+	"context"
+	"order/proto"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type ProductRepository interface {
+	Create(payload *proto.ProductRequest) (*proto.Empty, error)
+	GetProduct(payload *proto.GetProductRequest) (*proto.Product, error)
+	ListProducts(offset *proto.Offset) (*proto.ProductList, error)
+	UpdateProduct(payload *proto.Product) (*proto.Product, error)
+	DeleteProduct(ID *proto.GetProductRequest) (*proto.Empty, error)
+	ReserveStock(payload *proto.ReserveStockRequest) (*proto.Reservation, error)
+	CommitReservation(payload *proto.ReservationRequest) (*proto.Empty, error)
+	ReleaseReservation(payload *proto.ReservationRequest) (*proto.Empty, error)
+	RestockProducts(payload *proto.RestockRequest) (*proto.Empty, error)
+	AdjustReservation(payload *proto.AdjustReservationRequest) (*proto.ReservationItem, error)
+}
+
+type ProductRepositoryImpl struct {
+	client proto.ProductServiceClient
+}
+
+func NewProductRepositoryImpl() *ProductRepositoryImpl {
+	conn, err := grpc.NewClient("product-service:40001", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		logrus.Fatalf("Failed to connect: %v", err)
+	}
+	logrus.Info("Connected to product service")
+
+	client := proto.NewProductServiceClient(conn)
+	return &ProductRepositoryImpl{client: client}
+}
+
+func (u *ProductRepositoryImpl) Create(payload *proto.ProductRequest) (*proto.Empty, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.CreateProduct(ctx, payload)
+}
+
+func (u *ProductRepositoryImpl) GetProduct(payload *proto.GetProductRequest) (*proto.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.GetProduct(ctx, payload)
+}
+
+func (u *ProductRepositoryImpl) ListProducts(offset *proto.Offset) (*proto.ProductList, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.ListProducts(ctx, offset)
+}
+
+func (u *ProductRepositoryImpl) UpdateProduct(payload *proto.Product) (*proto.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.UpdateProduct(ctx, payload)
+}
+
+func (u *ProductRepositoryImpl) DeleteProduct(ID *proto.GetProductRequest) (*proto.Empty, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.DeleteProduct(ctx, ID)
+}
+
+func (u *ProductRepositoryImpl) ReserveStock(payload *proto.ReserveStockRequest) (*proto.Reservation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.ReserveStock(ctx, payload)
+}
+
+func (u *ProductRepositoryImpl) CommitReservation(payload *proto.ReservationRequest) (*proto.Empty, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.CommitReservation(ctx, payload)
+}
+
+func (u *ProductRepositoryImpl) ReleaseReservation(payload *proto.ReservationRequest) (*proto.Empty, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.ReleaseReservation(ctx, payload)
+}
+
+func (u *ProductRepositoryImpl) RestockProducts(payload *proto.RestockRequest) (*proto.Empty, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.RestockProducts(ctx, payload)
+}
+
+func (u *ProductRepositoryImpl) AdjustReservation(payload *proto.AdjustReservationRequest) (*proto.ReservationItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return u.client.AdjustReservation(ctx, payload)
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrPromotionNotFound   = errors.New("promotion not found")
+	ErrPromotionCodeExists = errors.New("promotion code already exists")
+)
+
+type PromotionRepository interface {
+	CreatePromotion(payload *proto.Promotion, db *sql.DB) (int, error)
+	GetPromotion(promotionID int, db *sql.DB) (*proto.Promotion, error)
+	ListPromotions(includeInactive bool, db *sql.DB) ([]*proto.Promotion, error)
+	DeactivatePromotion(promotionID int, db *sql.DB) error
+	GetApplicablePromotions(couponCode string, at string, tx *sql.Tx) ([]*proto.Promotion, error)
+	RedeemPromotion(promotion *proto.Promotion, orderID int, userID int, tx *sql.Tx) (bool, error)
+	ReleaseOrderRedemptions(orderID int, tx *sql.Tx) error
+	CreateOrderDiscount(payload *proto.OrderDiscount, tx *sql.Tx) error
+	DeleteOrderDiscounts(orderID int, tx *sql.Tx) error
+	GetOrderDiscounts(orderID int, db *sql.DB) ([]*proto.OrderDiscount, error)
+}
+
+type PromotionRepositoryImpl struct{}
+
+func NewPromotionRepositoryImpl() *PromotionRepositoryImpl {
+	return &PromotionRepositoryImpl{}
+}
+
+const selectPromotionSQL = `SELECT id, COALESCE(code, ''), name, type, value, COALESCE(product_id, 0), COALESCE(category, ''),
+        buy_quantity, get_quantity, amount, currency, min_order_amount, COALESCE(usage_limit, 0), COALESCE(per_user_limit, 0), times_used,
+        COALESCE(TO_CHAR(starts_at, 'YYYY-MM-DD HH24:MI:SS'), ''), COALESCE(TO_CHAR(ends_at, 'YYYY-MM-DD HH24:MI:SS'), ''),
+        active, created_at, updated_at
+        FROM promotions`
+
+func scanPromotion(row interface{ Scan(...any) error }) (*proto.Promotion, error) {
+	promotion := &proto.Promotion{}
+	if err := row.Scan(
+		&promotion.Id,
+		&promotion.Code,
+		&promotion.Name,
+		&promotion.Type,
+		&promotion.Value,
+		&promotion.ProductId,
+		&promotion.Category,
+		&promotion.BuyQuantity,
+		&promotion.GetQuantity,
+		&promotion.Amount,
+		&promotion.Currency,
+		&promotion.MinOrderAmount,
+		&promotion.UsageLimit,
+		&promotion.PerUserLimit,
+		&promotion.TimesUsed,
+		&promotion.StartsAt,
+		&promotion.EndsAt,
+		&promotion.Active,
+		&promotion.CreatedAt,
+		&promotion.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func scanPromotions(rows *sql.Rows) ([]*proto.Promotion, error) {
+	defer rows.Close()
+
+	var promotions []*proto.Promotion
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+
+	return promotions, rows.Err()
+}
+
+func (u *PromotionRepositoryImpl) CreatePromotion(payload *proto.Promotion, db *sql.DB) (int, error) {
+	SQL := `INSERT INTO promotions(code, name, type, value, product_id, category, buy_quantity, get_quantity,
+            amount, currency, min_order_amount, usage_limit, per_user_limit, starts_at, ends_at)
+        VALUES (NULLIF($1, ''), $2, $3, $4, NULLIF($5, 0), NULLIF($6, ''), $7, $8, $9, $10, $11, NULLIF($12, 0),
+            NULLIF($13, 0), NULLIF($14, '')::timestamp, NULLIF($15, '')::timestamp)
+        RETURNING id`
+	var promotionID int
+	if err := db.QueryRow(SQL,
+		payload.Code,
+		payload.Name,
+		payload.Type,
+		payload.Value,
+		payload.ProductId,
+		payload.Category,
+		payload.BuyQuantity,
+		payload.GetQuantity,
+		payload.Amount,
+		payload.Currency,
+		payload.MinOrderAmount,
+		payload.UsageLimit,
+		payload.PerUserLimit,
+		payload.StartsAt,
+		payload.EndsAt,
+	).Scan(&promotionID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, ErrPromotionCodeExists
+		}
+		return 0, err
+	}
+	return promotionID, nil
+}
+
+func (u *PromotionRepositoryImpl) GetPromotion(promotionID int, db *sql.DB) (*proto.Promotion, error) {
+	promotion, err := scanPromotion(db.QueryRow(selectPromotionSQL+" WHERE id = $1", promotionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (u *PromotionRepositoryImpl) ListPromotions(includeInactive bool, db *sql.DB) ([]*proto.Promotion, error) {
+	SQL := selectPromotionSQL
+	if !includeInactive {
+		SQL += " WHERE active"
+	}
+	rows, err := db.Query(SQL + " ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	return scanPromotions(rows)
+}
+
+func (u *PromotionRepositoryImpl) DeactivatePromotion(promotionID int, db *sql.DB) error {
+	SQL := "UPDATE promotions SET active = FALSE, updated_at = NOW() WHERE id = $1"
+	result, err := db.Exec(SQL, promotionID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrPromotionNotFound
+	}
+	return nil
+}
+
+// GetApplicablePromotions returns the active automatic promotions and the promotion of the coupon code
+// that are valid at the given time, an empty time means now.
+func (u *PromotionRepositoryImpl) GetApplicablePromotions(couponCode string, at string, tx *sql.Tx) ([]*proto.Promotion, error) {
+	SQL := selectPromotionSQL + `
+        WHERE active
+        AND (code IS NULL OR UPPER(code) = UPPER(NULLIF($1, '')))
+        AND (starts_at IS NULL OR starts_at <= COALESCE(NULLIF($2, '')::timestamp, NOW()))
+        AND (ends_at IS NULL OR ends_at > COALESCE(NULLIF($2, '')::timestamp, NOW()))
+        ORDER BY id ASC`
+	rows, err := tx.Query(SQL, couponCode, at)
+	if err != nil {
+		return nil, err
+	}
+	return scanPromotions(rows)
+}
+
+// RedeemPromotion counts one use of a promotion by an order. The counter update locks the promotion,
+// so concurrent orders check the global and the per-user limit one after the other. It returns false
+// when a limit is reached.
+func (u *PromotionRepositoryImpl) RedeemPromotion(promotion *proto.Promotion, orderID int, userID int, tx *sql.Tx) (bool, error) {
+	SQL := `UPDATE promotions SET times_used = times_used + 1, updated_at = NOW()
+        WHERE id = $1 AND (usage_limit IS NULL OR times_used < usage_limit)`
+	result, err := tx.Exec(SQL, promotion.Id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	if promotion.PerUserLimit > 0 {
+		var used int32
+		SQL = "SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2"
+		if err := tx.QueryRow(SQL, promotion.Id, userID).Scan(&used); err != nil {
+			return false, err
+		}
+		if used >= promotion.PerUserLimit {
+			SQL = "UPDATE promotions SET times_used = times_used - 1 WHERE id = $1"
+			if _, err := tx.Exec(SQL, promotion.Id); err != nil {
+				return false, err
+			}
+			return false, nil
+		}
+	}
+
+	SQL = "INSERT INTO promotion_redemptions(promotion_id, order_id, user_id) VALUES ($1, $2, $3)"
+	if _, err := tx.Exec(SQL, promotion.Id, orderID, userID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseOrderRedemptions gives back the uses of every promotion redeemed by an order.
+func (u *PromotionRepositoryImpl) ReleaseOrderRedemptions(orderID int, tx *sql.Tx) error {
+	SQL := `UPDATE promotions p SET times_used = p.times_used - r.used, updated_at = NOW()
+        FROM (SELECT promotion_id, COUNT(*) AS used FROM promotion_redemptions WHERE order_id = $1 GROUP BY promotion_id) r
+        WHERE p.id = r.promotion_id`
+	if _, err := tx.Exec(SQL, orderID); err != nil {
+		return err
+	}
+
+	SQL = "DELETE FROM promotion_redemptions WHERE order_id = $1"
+	if _, err := tx.Exec(SQL, orderID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *PromotionRepositoryImpl) CreateOrderDiscount(payload *proto.OrderDiscount, tx *sql.Tx) error {
+	SQL := `INSERT INTO order_discounts(order_id, promotion_id, code, type, description, product_id, amount)
+        VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6, 0), $7)`
+	if _, err := tx.Exec(SQL, payload.OrderId, payload.PromotionId, payload.Code, payload.Type, payload.Description, payload.ProductId, payload.Amount); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *PromotionRepositoryImpl) DeleteOrderDiscounts(orderID int, tx *sql.Tx) error {
+	SQL := "DELETE FROM order_discounts WHERE order_id = $1"
+	if _, err := tx.Exec(SQL, orderID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *PromotionRepositoryImpl) GetOrderDiscounts(orderID int, db *sql.DB) ([]*proto.OrderDiscount, error) {
+	SQL := `SELECT id, order_id, COALESCE(promotion_id, 0), COALESCE(code, ''), type, COALESCE(description, ''),
+        COALESCE(product_id, 0), amount
+        FROM order_discounts WHERE order_id = $1 ORDER BY id ASC`
+	rows, err := db.Query(SQL, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var discounts []*proto.OrderDiscount
+	for rows.Next() {
+		discount := &proto.OrderDiscount{}
+		if err := rows.Scan(
+			&discount.Id,
+			&discount.OrderId,
+			&discount.PromotionId,
+			&discount.Code,
+			&discount.Type,
+			&discount.Description,
+			&discount.ProductId,
+			&discount.Amount,
+		); err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, discount)
+	}
+
+	return discounts, rows.Err()
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"order/proto"
+	"strings"
+)
+
+// ReportFilter selects the orders of a report: created from From up to but not including To, both
+// formatted as timestamps. GroupBy is a DATE_TRUNC field and SortBy a ProductSales column.
+type ReportFilter struct {
+	From     string
+	To       string
+	GroupBy  string
+	SortBy   string
+	Currency string
+	Limit    int
+}
+
+type ReportRepository interface {
+	GetRevenue(filter *ReportFilter, db *sql.DB) ([]*proto.RevenuePeriod, error)
+	GetTopProducts(filter *ReportFilter, db *sql.DB) ([]*proto.ProductSales, error)
+	GetOrderValue(filter *ReportFilter, db *sql.DB) ([]*proto.OrderValue, error)
+}
+
+type ReportRepositoryImpl struct{}
+
+func NewReportRepositoryImpl() *ReportRepositoryImpl {
+	return &ReportRepositoryImpl{}
+}
+
+// reportConditions keeps the sales of a report: orders that were paid, created in the range of filter.
+// currencyColumn is the column filter.Currency applies to.
+func reportConditions(filter *ReportFilter, currencyColumn string, args []any) (string, []any) {
+	args = append(args, filter.From, filter.To)
+	conditions := []string{
+		"o.status IN ('paid', 'fulfilled', 'completed')",
+		fmt.Sprintf("o.created_at >= $%d::timestamp", len(args)-1),
+		fmt.Sprintf("o.created_at < $%d::timestamp", len(args)),
+	}
+	if filter.Currency != "" {
+		args = append(args, filter.Currency)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", currencyColumn, len(args)))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func (u *ReportRepositoryImpl) GetRevenue(filter *ReportFilter, db *sql.DB) ([]*proto.RevenuePeriod, error) {
+	conditions, args := reportConditions(filter, "o.currency", []any{filter.GroupBy})
+	SQL := `SELECT TO_CHAR(DATE_TRUNC($1, o.created_at), 'YYYY-MM-DD') AS period, o.currency, COUNT(*), SUM(o.total_price)
+        FROM orders o
+        WHERE ` + conditions + `
+        GROUP BY period, o.currency
+        ORDER BY period ASC, o.currency ASC`
+	rows, err := db.Query(SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var periods []*proto.RevenuePeriod
+	for rows.Next() {
+		period := &proto.RevenuePeriod{}
+		if err := rows.Scan(&period.Period, &period.Currency, &period.Orders, &period.Revenue); err != nil {
+			return nil, err
+		}
+		periods = append(periods, period)
+	}
+
+	return periods, rows.Err()
+}
+
+// GetTopProducts sums the order items per product, revenue is the unit price times the quantity in the
+// currency of the product prices, without taxes and order discounts.
+func (u *ReportRepositoryImpl) GetTopProducts(filter *ReportFilter, db *sql.DB) ([]*proto.ProductSales, error) {
+	conditions, args := reportConditions(filter, "o.base_currency", nil)
+	args = append(args, filter.Limit)
+	SQL := fmt.Sprintf(`SELECT i.product_id, MAX(i.product_name), o.base_currency,
+            SUM(i.quantity) AS quantity, SUM(i.price * i.quantity) AS revenue, COUNT(DISTINCT o.id)
+        FROM order_items i
+        JOIN orders o ON o.id = i.order_id
+        WHERE %s
+        GROUP BY i.product_id, o.base_currency
+        ORDER BY %s DESC, i.product_id ASC
+        LIMIT $%d`, conditions, filter.SortBy, len(args))
+	rows, err := db.Query(SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*proto.ProductSales
+	for rows.Next() {
+		product := &proto.ProductSales{}
+		if err := rows.Scan(&product.ProductId, &product.ProductName, &product.Currency, &product.Quantity, &product.Revenue, &product.Orders); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+func (u *ReportRepositoryImpl) GetOrderValue(filter *ReportFilter, db *sql.DB) ([]*proto.OrderValue, error) {
+	conditions, args := reportConditions(filter, "o.currency", nil)
+	SQL := `SELECT o.currency, COUNT(*), SUM(o.total_price)
+        FROM orders o
+        WHERE ` + conditions + `
+        GROUP BY o.currency
+        ORDER BY o.currency ASC`
+	rows, err := db.Query(SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []*proto.OrderValue
+	for rows.Next() {
+		value := &proto.OrderValue{}
+		if err := rows.Scan(&value.Currency, &value.Orders, &value.Revenue); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"order/proto"
+	"strings"
+)
+
+var ErrReturnNotFound = errors.New("order return not found")
+
+type ReturnFilter struct {
+	OrderID int
+	UserID  int
+	Status  string
+	Limit   int
+}
+
+type ReturnRepository interface {
+	CreateReturn(payload *proto.OrderReturn, tx *sql.Tx) (int, error)
+	CreateReturnItem(returnID int, item *proto.ReturnItem, tx *sql.Tx) error
+	GetReturn(returnID int, db *sql.DB) (*proto.OrderReturn, error)
+	GetReturnForUpdate(returnID int, tx *sql.Tx) (*proto.OrderReturn, error)
+	ListReturns(filter *ReturnFilter, db *sql.DB) ([]*proto.OrderReturn, error)
+	GetReturnedQuantities(orderID int, tx *sql.Tx) (map[int32]int32, error)
+	UpdateReturn(payload *proto.OrderReturn, tx *sql.Tx) error
+}
+
+type ReturnRepositoryImpl struct{}
+
+func NewReturnRepositoryImpl() *ReturnRepositoryImpl {
+	return &ReturnRepositoryImpl{}
+}
+
+const selectReturnSQL = `SELECT id, order_id, user_id, status, COALESCE(reason, ''), COALESCE(admin_note, ''), refund_amount,
+        (SELECT currency FROM orders WHERE orders.id = order_returns.order_id), COALESCE(refund_id, 0),
+        COALESCE(reviewed_by, ''), created_at, updated_at
+        FROM order_returns`
+
+func scanReturn(row interface{ Scan(...any) error }) (*proto.OrderReturn, error) {
+	orderReturn := &proto.OrderReturn{}
+	if err := row.Scan(
+		&orderReturn.Id,
+		&orderReturn.OrderId,
+		&orderReturn.UserId,
+		&orderReturn.Status,
+		&orderReturn.Reason,
+		&orderReturn.AdminNote,
+		&orderReturn.RefundAmount,
+		&orderReturn.Currency,
+		&orderReturn.RefundId,
+		&orderReturn.ReviewedBy,
+		&orderReturn.CreatedAt,
+		&orderReturn.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return orderReturn, nil
+}
+
+// getReturnItems reads the items of a return with either a *sql.DB or a *sql.Tx query function.
+func getReturnItems(query func(string, ...any) (*sql.Rows, error), returnID int32) ([]*proto.ReturnItem, error) {
+	SQL := "SELECT order_item_id, product_id, quantity, price FROM order_return_items WHERE return_id = $1 ORDER BY id ASC"
+	rows, err := query(SQL, returnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*proto.ReturnItem
+	for rows.Next() {
+		item := &proto.ReturnItem{}
+		if err := rows.Scan(&item.OrderItemId, &item.ProductId, &item.Quantity, &item.Price); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (u *ReturnRepositoryImpl) CreateReturn(payload *proto.OrderReturn, tx *sql.Tx) (int, error) {
+	SQL := `INSERT INTO order_returns(order_id, user_id, status, reason, refund_amount)
+        VALUES ($1, $2, $3, NULLIF($4, ''), $5) RETURNING id`
+	var returnID int
+	if err := tx.QueryRow(SQL, payload.OrderId, payload.UserId, payload.Status, payload.Reason, payload.RefundAmount).Scan(&returnID); err != nil {
+		return 0, err
+	}
+	return returnID, nil
+}
+
+func (u *ReturnRepositoryImpl) CreateReturnItem(returnID int, item *proto.ReturnItem, tx *sql.Tx) error {
+	SQL := "INSERT INTO order_return_items(return_id, order_item_id, product_id, quantity, price) VALUES ($1, $2, $3, $4, $5)"
+	if _, err := tx.Exec(SQL, returnID, item.OrderItemId, item.ProductId, item.Quantity, item.Price); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *ReturnRepositoryImpl) GetReturn(returnID int, db *sql.DB) (*proto.OrderReturn, error) {
+	orderReturn, err := scanReturn(db.QueryRow(selectReturnSQL+" WHERE id = $1", returnID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReturnNotFound
+		}
+		return nil, err
+	}
+
+	orderReturn.Items, err = getReturnItems(db.Query, orderReturn.Id)
+	if err != nil {
+		return nil, err
+	}
+	return orderReturn, nil
+}
+
+func (u *ReturnRepositoryImpl) GetReturnForUpdate(returnID int, tx *sql.Tx) (*proto.OrderReturn, error) {
+	orderReturn, err := scanReturn(tx.QueryRow(selectReturnSQL+" WHERE id = $1 FOR UPDATE", returnID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReturnNotFound
+		}
+		return nil, err
+	}
+
+	orderReturn.Items, err = getReturnItems(tx.Query, orderReturn.Id)
+	if err != nil {
+		return nil, err
+	}
+	return orderReturn, nil
+}
+
+func (u *ReturnRepositoryImpl) ListReturns(filter *ReturnFilter, db *sql.DB) ([]*proto.OrderReturn, error) {
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrderID != 0 {
+		addCondition("order_id = $%d", filter.OrderID)
+	}
+	if filter.UserID != 0 {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+
+	SQL := selectReturnSQL
+	if len(conditions) > 0 {
+		SQL += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	SQL += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := db.Query(SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orderReturns []*proto.OrderReturn
+	for rows.Next() {
+		orderReturn, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		orderReturns = append(orderReturns, orderReturn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, orderReturn := range orderReturns {
+		orderReturn.Items, err = getReturnItems(db.Query, orderReturn.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return orderReturns, nil
+}
+
+// GetReturnedQuantities sums the quantities per order item of every return that was not rejected.
+func (u *ReturnRepositoryImpl) GetReturnedQuantities(orderID int, tx *sql.Tx) (map[int32]int32, error) {
+	SQL := `SELECT i.order_item_id, SUM(i.quantity)
+        FROM order_return_items i
+        JOIN order_returns r ON r.id = i.return_id
+        WHERE r.order_id = $1 AND r.status <> 'rejected'
+        GROUP BY i.order_item_id`
+	rows, err := tx.Query(SQL, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quantities := make(map[int32]int32)
+	for rows.Next() {
+		var orderItemID, quantity int32
+		if err := rows.Scan(&orderItemID, &quantity); err != nil {
+			return nil, err
+		}
+		quantities[orderItemID] = quantity
+	}
+
+	return quantities, rows.Err()
+}
+
+func (u *ReturnRepositoryImpl) UpdateReturn(payload *proto.OrderReturn, tx *sql.Tx) error {
+	SQL := `UPDATE order_returns
+        SET status = $1,
+            admin_note = NULLIF($2, ''),
+            refund_id = NULLIF($3, 0),
+            reviewed_by = NULLIF($4, ''),
+            updated_at = NOW()
+        WHERE id = $5`
+	if _, err := tx.Exec(SQL, payload.Status, payload.AdminNote, payload.RefundId, payload.ReviewedBy, payload.Id); err != nil {
+		return err
+	}
+	return nil
+}
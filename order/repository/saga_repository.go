@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrSagaNotAvailable = errors.New("saga not found or locked")
+
+type OrderSaga struct {
+	ID            int
+	OrderID       int
+	ReservationID string
+	PaymentID     int
+	Step          string
+	Status        string
+	Attempts      int
+	LastError     string
+}
+
+type SagaRepository interface {
+	CreateSaga(saga *OrderSaga, tx *sql.Tx) (int, error)
+	GetSagaForUpdate(ID int, tx *sql.Tx) (*OrderSaga, error)
+	GetSagaByOrderID(orderID int, db *sql.DB) (*OrderSaga, error)
+	GetSagaByOrderIDForUpdate(orderID int, tx *sql.Tx) (*OrderSaga, error)
+	GetDueSagaIDs(limit int, db *sql.DB) ([]int, error)
+	UpdateSaga(saga *OrderSaga, retryIn time.Duration, tx *sql.Tx) error
+}
+
+type SagaRepositoryImpl struct{}
+
+func NewSagaRepositoryImpl() *SagaRepositoryImpl {
+	return &SagaRepositoryImpl{}
+}
+
+func (u *SagaRepositoryImpl) CreateSaga(saga *OrderSaga, tx *sql.Tx) (int, error) {
+	SQL := "INSERT INTO order_sagas(order_id, reservation_id, step, status, last_error) VALUES ($1, NULLIF($2, '')::uuid, $3, $4, NULLIF($5, '')) RETURNING id"
+	var sagaID int
+	if err := tx.QueryRow(SQL, saga.OrderID, saga.ReservationID, saga.Step, saga.Status, saga.LastError).Scan(&sagaID); err != nil {
+		return 0, err
+	}
+	return sagaID, nil
+}
+
+// GetSagaForUpdate skips rows locked by another worker so a saga step never runs twice concurrently.
+func (u *SagaRepositoryImpl) GetSagaForUpdate(ID int, tx *sql.Tx) (*OrderSaga, error) {
+	SQL := `SELECT id, order_id, COALESCE(reservation_id::text, ''), COALESCE(payment_id, 0), step, status, attempts, COALESCE(last_error, '')
+        FROM order_sagas WHERE id = $1 FOR UPDATE SKIP LOCKED`
+	saga := &OrderSaga{}
+	if err := tx.QueryRow(SQL, ID).Scan(
+		&saga.ID,
+		&saga.OrderID,
+		&saga.ReservationID,
+		&saga.PaymentID,
+		&saga.Step,
+		&saga.Status,
+		&saga.Attempts,
+		&saga.LastError,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSagaNotAvailable
+		}
+		return nil, err
+	}
+	return saga, nil
+}
+
+func (u *SagaRepositoryImpl) GetSagaByOrderID(orderID int, db *sql.DB) (*OrderSaga, error) {
+	SQL := `SELECT id, order_id, COALESCE(reservation_id::text, ''), COALESCE(payment_id, 0), step, status, attempts, COALESCE(last_error, '')
+        FROM order_sagas WHERE order_id = $1`
+	saga := &OrderSaga{}
+	if err := db.QueryRow(SQL, orderID).Scan(
+		&saga.ID,
+		&saga.OrderID,
+		&saga.ReservationID,
+		&saga.PaymentID,
+		&saga.Step,
+		&saga.Status,
+		&saga.Attempts,
+		&saga.LastError,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSagaNotAvailable
+		}
+		return nil, err
+	}
+	return saga, nil
+}
+
+// GetSagaByOrderIDForUpdate waits for a running step to finish before returning the saga.
+func (u *SagaRepositoryImpl) GetSagaByOrderIDForUpdate(orderID int, tx *sql.Tx) (*OrderSaga, error) {
+	SQL := `SELECT id, order_id, COALESCE(reservation_id::text, ''), COALESCE(payment_id, 0), step, status, attempts, COALESCE(last_error, '')
+        FROM order_sagas WHERE order_id = $1 FOR UPDATE`
+	saga := &OrderSaga{}
+	if err := tx.QueryRow(SQL, orderID).Scan(
+		&saga.ID,
+		&saga.OrderID,
+		&saga.ReservationID,
+		&saga.PaymentID,
+		&saga.Step,
+		&saga.Status,
+		&saga.Attempts,
+		&saga.LastError,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSagaNotAvailable
+		}
+		return nil, err
+	}
+	return saga, nil
+}
+
+func (u *SagaRepositoryImpl) GetDueSagaIDs(limit int, db *sql.DB) ([]int, error) {
+	SQL := `SELECT id FROM order_sagas
+        WHERE status IN ('running', 'compensating') AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at ASC
+        LIMIT $1`
+	rows, err := db.Query(SQL, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sagaIDs []int
+	for rows.Next() {
+		var sagaID int
+		if err := rows.Scan(&sagaID); err != nil {
+			return nil, err
+		}
+		sagaIDs = append(sagaIDs, sagaID)
+	}
+
+	return sagaIDs, rows.Err()
+}
+
+func (u *SagaRepositoryImpl) UpdateSaga(saga *OrderSaga, retryIn time.Duration, tx *sql.Tx) error {
+	SQL := `UPDATE order_sagas
+        SET step = $1,
+            status = $2,
+            payment_id = NULLIF($3, 0),
+            attempts = $4,
+            last_error = NULLIF($5, ''),
+            next_attempt_at = NOW() + make_interval(secs => $6),
+            updated_at = NOW()
+        WHERE id = $7`
+	if _, err := tx.Exec(SQL, saga.Step, saga.Status, saga.PaymentID, saga.Attempts, saga.LastError, retryIn.Seconds(), saga.ID); err != nil {
+		return err
+	}
+	return nil
+}
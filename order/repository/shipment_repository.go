@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"order/proto"
+	"strings"
+)
+
+var ErrShipmentNotFound = errors.New("shipment not found")
+
+type ShipmentFilter struct {
+	OrderID int
+	UserID  int
+	Status  string
+	Limit   int
+}
+
+type ShipmentRepository interface {
+	CreateShipment(payload *proto.Shipment, tx *sql.Tx) (int, error)
+	CreateShipmentItem(shipmentID int, item *proto.ShipmentItem, tx *sql.Tx) error
+	CreateShipmentEvent(shipmentID int, event *proto.ShipmentEvent, tx *sql.Tx) error
+	GetShipment(shipmentID int, db *sql.DB) (*proto.Shipment, error)
+	GetShipmentForUpdate(shipmentID int, tx *sql.Tx) (*proto.Shipment, error)
+	ListShipments(filter *ShipmentFilter, db *sql.DB) ([]*proto.Shipment, error)
+	GetShipmentQuantities(orderID int, tx *sql.Tx) (map[string]map[int32]int32, error)
+	HasActiveShipments(orderID int, tx *sql.Tx) (bool, error)
+	UpdateShipment(payload *proto.Shipment, tx *sql.Tx) error
+}
+
+type ShipmentRepositoryImpl struct{}
+
+func NewShipmentRepositoryImpl() *ShipmentRepositoryImpl {
+	return &ShipmentRepositoryImpl{}
+}
+
+const selectShipmentSQL = `SELECT id, order_id, (SELECT user_id FROM orders WHERE orders.id = shipments.order_id),
+        carrier, COALESCE(service, ''), COALESCE(tracking_number, ''), status,
+        COALESCE(TO_CHAR(shipped_at, 'YYYY-MM-DD HH24:MI:SS'), ''), COALESCE(TO_CHAR(delivered_at, 'YYYY-MM-DD HH24:MI:SS'), ''),
+        created_at, updated_at
+        FROM shipments`
+
+func scanShipment(row interface{ Scan(...any) error }) (*proto.Shipment, error) {
+	shipment := &proto.Shipment{}
+	if err := row.Scan(
+		&shipment.Id,
+		&shipment.OrderId,
+		&shipment.UserId,
+		&shipment.Carrier,
+		&shipment.Service,
+		&shipment.TrackingNumber,
+		&shipment.Status,
+		&shipment.ShippedAt,
+		&shipment.DeliveredAt,
+		&shipment.CreatedAt,
+		&shipment.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return shipment, nil
+}
+
+// getShipmentDetails reads the items and tracking events of a shipment with either a *sql.DB or a
+// *sql.Tx query function.
+func getShipmentDetails(query func(string, ...any) (*sql.Rows, error), shipment *proto.Shipment) error {
+	rows, err := query("SELECT order_item_id, product_id, quantity FROM shipment_items WHERE shipment_id = $1 ORDER BY id ASC", shipment.Id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := &proto.ShipmentItem{}
+		if err := rows.Scan(&item.OrderItemId, &item.ProductId, &item.Quantity); err != nil {
+			return err
+		}
+		shipment.Items = append(shipment.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	SQL := `SELECT id, status, COALESCE(location, ''), COALESCE(description, ''), created_at
+        FROM shipment_events WHERE shipment_id = $1 ORDER BY id ASC`
+	events, err := query(SQL, shipment.Id)
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+
+	for events.Next() {
+		event := &proto.ShipmentEvent{}
+		if err := events.Scan(&event.Id, &event.Status, &event.Location, &event.Description, &event.CreatedAt); err != nil {
+			return err
+		}
+		shipment.Events = append(shipment.Events, event)
+	}
+
+	return events.Err()
+}
+
+func (u *ShipmentRepositoryImpl) CreateShipment(payload *proto.Shipment, tx *sql.Tx) (int, error) {
+	SQL := `INSERT INTO shipments(order_id, carrier, service, tracking_number, status)
+        VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5) RETURNING id`
+	var shipmentID int
+	if err := tx.QueryRow(SQL, payload.OrderId, payload.Carrier, payload.Service, payload.TrackingNumber, payload.Status).Scan(&shipmentID); err != nil {
+		return 0, err
+	}
+	return shipmentID, nil
+}
+
+func (u *ShipmentRepositoryImpl) CreateShipmentItem(shipmentID int, item *proto.ShipmentItem, tx *sql.Tx) error {
+	SQL := "INSERT INTO shipment_items(shipment_id, order_item_id, product_id, quantity) VALUES ($1, $2, $3, $4)"
+	if _, err := tx.Exec(SQL, shipmentID, item.OrderItemId, item.ProductId, item.Quantity); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *ShipmentRepositoryImpl) CreateShipmentEvent(shipmentID int, event *proto.ShipmentEvent, tx *sql.Tx) error {
+	SQL := "INSERT INTO shipment_events(shipment_id, status, location, description) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))"
+	if _, err := tx.Exec(SQL, shipmentID, event.Status, event.Location, event.Description); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *ShipmentRepositoryImpl) GetShipment(shipmentID int, db *sql.DB) (*proto.Shipment, error) {
+	shipment, err := scanShipment(db.QueryRow(selectShipmentSQL+" WHERE id = $1", shipmentID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrShipmentNotFound
+		}
+		return nil, err
+	}
+
+	if err := getShipmentDetails(db.Query, shipment); err != nil {
+		return nil, err
+	}
+	return shipment, nil
+}
+
+func (u *ShipmentRepositoryImpl) GetShipmentForUpdate(shipmentID int, tx *sql.Tx) (*proto.Shipment, error) {
+	shipment, err := scanShipment(tx.QueryRow(selectShipmentSQL+" WHERE id = $1 FOR UPDATE", shipmentID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrShipmentNotFound
+		}
+		return nil, err
+	}
+
+	if err := getShipmentDetails(tx.Query, shipment); err != nil {
+		return nil, err
+	}
+	return shipment, nil
+}
+
+func (u *ShipmentRepositoryImpl) ListShipments(filter *ShipmentFilter, db *sql.DB) ([]*proto.Shipment, error) {
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrderID != 0 {
+		addCondition("order_id = $%d", filter.OrderID)
+	}
+	if filter.UserID != 0 {
+		addCondition("order_id IN (SELECT id FROM orders WHERE user_id = $%d)", filter.UserID)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+
+	SQL := selectShipmentSQL
+	if len(conditions) > 0 {
+		SQL += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	SQL += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := db.Query(SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []*proto.Shipment
+	for rows.Next() {
+		shipment, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, shipment := range shipments {
+		if err := getShipmentDetails(db.Query, shipment); err != nil {
+			return nil, err
+		}
+	}
+
+	return shipments, nil
+}
+
+// GetShipmentQuantities sums the quantities per order item of the shipments of an order, grouped by the
+// status of the shipment.
+func (u *ShipmentRepositoryImpl) GetShipmentQuantities(orderID int, tx *sql.Tx) (map[string]map[int32]int32, error) {
+	SQL := `SELECT s.status, i.order_item_id, SUM(i.quantity)
+        FROM shipment_items i
+        JOIN shipments s ON s.id = i.shipment_id
+        WHERE s.order_id = $1
+        GROUP BY s.status, i.order_item_id`
+	rows, err := tx.Query(SQL, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quantities := make(map[string]map[int32]int32)
+	for rows.Next() {
+		var shipmentStatus string
+		var orderItemID, quantity int32
+		if err := rows.Scan(&shipmentStatus, &orderItemID, &quantity); err != nil {
+			return nil, err
+		}
+		if quantities[shipmentStatus] == nil {
+			quantities[shipmentStatus] = make(map[int32]int32)
+		}
+		quantities[shipmentStatus][orderItemID] = quantity
+	}
+
+	return quantities, rows.Err()
+}
+
+// HasActiveShipments reports whether the order has a shipment that was not cancelled.
+func (u *ShipmentRepositoryImpl) HasActiveShipments(orderID int, tx *sql.Tx) (bool, error) {
+	SQL := "SELECT EXISTS (SELECT 1 FROM shipments WHERE order_id = $1 AND status <> 'cancelled')"
+	var active bool
+	if err := tx.QueryRow(SQL, orderID).Scan(&active); err != nil {
+		return false, err
+	}
+
+	return active, nil
+}
+
+func (u *ShipmentRepositoryImpl) UpdateShipment(payload *proto.Shipment, tx *sql.Tx) error {
+	SQL := `UPDATE shipments
+        SET status = $1,
+            tracking_number = NULLIF($2, ''),
+            shipped_at = CASE WHEN $1::VARCHAR = 'shipped' AND shipped_at IS NULL THEN NOW() ELSE shipped_at END,
+            delivered_at = CASE WHEN $1::VARCHAR = 'delivered' AND delivered_at IS NULL THEN NOW() ELSE delivered_at END,
+            updated_at = NOW()
+        WHERE id = $3`
+	if _, err := tx.Exec(SQL, payload.Status, payload.TrackingNumber, payload.Id); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+)
+
+var ErrOrderShippingNotFound = errors.New("order shipping not found")
+
+// ShippingTableRate is one row of the table-rate carrier: the price of a service in a zone for parcels
+// from MinWeightGrams up to MaxWeightGrams, zero means no upper bound. Prices are in minor units.
+type ShippingTableRate struct {
+	ID             int
+	Zone           string
+	Service        string
+	MinWeightGrams int32
+	MaxWeightGrams int32
+	Price          int64
+	PricePerKg     int64
+	Currency       string
+	EstimatedDays  int32
+}
+
+type ShippingRepository interface {
+	GetZoneTableRates(zone string, weightGrams int32, db *sql.DB) ([]*ShippingTableRate, error)
+	CreateOrderShipping(orderID int, payload *proto.OrderShipping, tx *sql.Tx) error
+	UpdateOrderShippingWeight(orderID int, weightGrams int32, estimatedDays int32, tx *sql.Tx) error
+	GetOrderShipping(orderID int, db *sql.DB) (*proto.OrderShipping, error)
+}
+
+type ShippingRepositoryImpl struct{}
+
+func NewShippingRepositoryImpl() *ShippingRepositoryImpl {
+	return &ShippingRepositoryImpl{}
+}
+
+// GetZoneTableRates returns the active rates for the weight of the zone and of the zones that contain it,
+// the most specific zone first.
+func (u *ShippingRepositoryImpl) GetZoneTableRates(zone string, weightGrams int32, db *sql.DB) ([]*ShippingTableRate, error) {
+	SQL := `SELECT id, zone, service, min_weight_grams, COALESCE(max_weight_grams, 0), price, price_per_kg, currency,
+            estimated_days
+        FROM shipping_table_rates
+        WHERE active AND (UPPER(zone) = UPPER($1) OR UPPER($1) LIKE UPPER(zone) || '-%')
+        AND min_weight_grams <= $2 AND (max_weight_grams IS NULL OR max_weight_grams > $2)
+        ORDER BY LENGTH(zone) DESC, id ASC`
+	rows, err := db.Query(SQL, zone, weightGrams)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []*ShippingTableRate
+	for rows.Next() {
+		rate := &ShippingTableRate{}
+		if err := rows.Scan(
+			&rate.ID,
+			&rate.Zone,
+			&rate.Service,
+			&rate.MinWeightGrams,
+			&rate.MaxWeightGrams,
+			&rate.Price,
+			&rate.PricePerKg,
+			&rate.Currency,
+			&rate.EstimatedDays,
+		); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (u *ShippingRepositoryImpl) CreateOrderShipping(orderID int, payload *proto.OrderShipping, tx *sql.Tx) error {
+	SQL := `INSERT INTO order_shipping(order_id, recipient_name, phone, line1, line2, city, region, postal_code, country,
+            carrier, service, estimated_days, weight_grams)
+        VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, NULLIF($7, ''), $8, $9, $10, $11, $12, $13)`
+	address := payload.Address
+	if _, err := tx.Exec(SQL,
+		orderID,
+		address.RecipientName,
+		address.Phone,
+		address.Line1,
+		address.Line2,
+		address.City,
+		address.Region,
+		address.PostalCode,
+		address.Country,
+		payload.Carrier,
+		payload.Service,
+		payload.EstimatedDays,
+		payload.WeightGrams,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *ShippingRepositoryImpl) UpdateOrderShippingWeight(orderID int, weightGrams int32, estimatedDays int32, tx *sql.Tx) error {
+	SQL := "UPDATE order_shipping SET weight_grams = $1, estimated_days = $2, updated_at = NOW() WHERE order_id = $3"
+	if _, err := tx.Exec(SQL, weightGrams, estimatedDays, orderID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *ShippingRepositoryImpl) GetOrderShipping(orderID int, db *sql.DB) (*proto.OrderShipping, error) {
+	SQL := `SELECT recipient_name, COALESCE(phone, ''), line1, COALESCE(line2, ''), city, COALESCE(region, ''), postal_code,
+        country, carrier, service, estimated_days, weight_grams
+        FROM order_shipping WHERE order_id = $1`
+	shipping := &proto.OrderShipping{Address: &proto.ShippingAddress{}}
+	if err := db.QueryRow(SQL, orderID).Scan(
+		&shipping.Address.RecipientName,
+		&shipping.Address.Phone,
+		&shipping.Address.Line1,
+		&shipping.Address.Line2,
+		&shipping.Address.City,
+		&shipping.Address.Region,
+		&shipping.Address.PostalCode,
+		&shipping.Address.Country,
+		&shipping.Carrier,
+		&shipping.Service,
+		&shipping.EstimatedDays,
+		&shipping.WeightGrams,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOrderShippingNotFound
+		}
+		return nil, err
+	}
+	return shipping, nil
+}
@@ -0,0 +1,463 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"order/proto"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSubscriptionNotFound          = errors.New("subscription not found")
+	ErrSubscriptionCycleNotAvailable = errors.New("subscription cycle not found or locked")
+)
+
+// SubscriptionCycle is the state the subscription scheduler keeps of one cycle.
+type SubscriptionCycle struct {
+	ID             int
+	SubscriptionID int
+	OrderID        int
+	Status         string
+	Attempts       int
+	LastError      string
+}
+
+type SubscriptionFilter struct {
+	UserID int
+	Status string
+	Limit  int
+}
+
+type SubscriptionRepository interface {
+	CreateSubscription(payload *proto.CreateSubscriptionRequest, tx *sql.Tx) (int, error)
+	CreateSubscriptionItem(subscriptionID int, item *proto.SubscriptionItem, tx *sql.Tx) error
+	CreateSubscriptionShipping(subscriptionID int, payload *proto.CreateSubscriptionRequest, tx *sql.Tx) error
+	GetSubscription(subscriptionID int, db *sql.DB) (*proto.Subscription, error)
+	GetSubscriptionForUpdate(subscriptionID int, tx *sql.Tx) (*proto.Subscription, error)
+	ListSubscriptions(filter *SubscriptionFilter, db *sql.DB) ([]*proto.Subscription, error)
+	UpdateSubscriptionStatus(subscriptionID int, status string, tx *sql.Tx) error
+	SetSkipNext(subscriptionID int, skipNext bool, tx *sql.Tx) error
+	GetDueSubscriptionIDs(limit int, tx *sql.Tx) ([]int, error)
+	ScheduleCycle(subscriptionID int, tx *sql.Tx) error
+	GetDueCycleIDs(limit int, db *sql.DB) ([]int, error)
+	GetCycleForUpdate(cycleID int, tx *sql.Tx) (*SubscriptionCycle, error)
+	LockCycle(cycleID int, tx *sql.Tx) (*SubscriptionCycle, error)
+	UpdateCycle(cycle *SubscriptionCycle, retryIn time.Duration, tx *sql.Tx) error
+	CancelPendingCycles(subscriptionID int, tx *sql.Tx) ([]int, error)
+}
+
+type SubscriptionRepositoryImpl struct{}
+
+func NewSubscriptionRepositoryImpl() *SubscriptionRepositoryImpl {
+	return &SubscriptionRepositoryImpl{}
+}
+
+// subscriptionCycleLimit is how many of the latest cycles a subscription is returned with.
+const subscriptionCycleLimit = 12
+
+// subscriptionInterval is the interval between two cycles of a subscription as a SQL expression.
+const subscriptionInterval = "(interval_count || ' ' || interval_unit)::interval"
+
+const selectSubscriptionSQL = `SELECT id, user_id, status, interval_unit, interval_count,
+        TO_CHAR(next_run_at, 'YYYY-MM-DD HH24:MI:SS'), skip_next, COALESCE(currency, ''), COALESCE(region, ''),
+        max_amount, created_at, updated_at
+        FROM subscriptions`
+
+func scanSubscription(row interface{ Scan(...any) error }) (*proto.Subscription, error) {
+	subscription := &proto.Subscription{}
+	if err := row.Scan(
+		&subscription.Id,
+		&subscription.UserId,
+		&subscription.Status,
+		&subscription.Interval,
+		&subscription.IntervalCount,
+		&subscription.NextRunAt,
+		&subscription.SkipNext,
+		&subscription.Currency,
+		&subscription.Region,
+		&subscription.MaxAmount,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// getSubscriptionDetails reads the items, shipping address and latest cycles of a subscription with
+// either a *sql.DB or a *sql.Tx query function.
+func getSubscriptionDetails(query func(string, ...any) (*sql.Rows, error), subscription *proto.Subscription) error {
+	items, err := query("SELECT product_id, quantity FROM subscription_items WHERE subscription_id = $1 ORDER BY id ASC", subscription.Id)
+	if err != nil {
+		return err
+	}
+	defer items.Close()
+
+	for items.Next() {
+		item := &proto.SubscriptionItem{}
+		if err := items.Scan(&item.ProductId, &item.Quantity); err != nil {
+			return err
+		}
+		subscription.Items = append(subscription.Items, item)
+	}
+	if err := items.Err(); err != nil {
+		return err
+	}
+
+	SQL := `SELECT recipient_name, COALESCE(phone, ''), line1, COALESCE(line2, ''), city, COALESCE(region, ''), postal_code,
+        country, carrier, service
+        FROM subscription_shipping WHERE subscription_id = $1`
+	shipping, err := query(SQL, subscription.Id)
+	if err != nil {
+		return err
+	}
+	defer shipping.Close()
+
+	for shipping.Next() {
+		address := &proto.ShippingAddress{}
+		if err := shipping.Scan(
+			&address.RecipientName,
+			&address.Phone,
+			&address.Line1,
+			&address.Line2,
+			&address.City,
+			&address.Region,
+			&address.PostalCode,
+			&address.Country,
+			&subscription.ShippingCarrier,
+			&subscription.ShippingService,
+		); err != nil {
+			return err
+		}
+		subscription.ShippingAddress = address
+	}
+	if err := shipping.Err(); err != nil {
+		return err
+	}
+
+	SQL = `SELECT id, COALESCE(order_id, 0), status, attempts, COALESCE(last_error, ''),
+        TO_CHAR(scheduled_at, 'YYYY-MM-DD HH24:MI:SS'), TO_CHAR(next_attempt_at, 'YYYY-MM-DD HH24:MI:SS'), created_at, updated_at
+        FROM subscription_cycles WHERE subscription_id = $1
+        ORDER BY scheduled_at DESC
+        LIMIT $2`
+	cycles, err := query(SQL, subscription.Id, subscriptionCycleLimit)
+	if err != nil {
+		return err
+	}
+	defer cycles.Close()
+
+	for cycles.Next() {
+		cycle := &proto.SubscriptionCycle{}
+		if err := cycles.Scan(
+			&cycle.Id,
+			&cycle.OrderId,
+			&cycle.Status,
+			&cycle.Attempts,
+			&cycle.LastError,
+			&cycle.ScheduledAt,
+			&cycle.NextAttemptAt,
+			&cycle.CreatedAt,
+			&cycle.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		subscription.Cycles = append(subscription.Cycles, cycle)
+	}
+
+	return cycles.Err()
+}
+
+// CreateSubscription starts the subscription at payload.StartAt, a date, or right away without it.
+func (u *SubscriptionRepositoryImpl) CreateSubscription(payload *proto.CreateSubscriptionRequest, tx *sql.Tx) (int, error) {
+	SQL := `INSERT INTO subscriptions(user_id, interval_unit, interval_count, next_run_at, currency, region, max_amount)
+        VALUES ($1, $2, $3, COALESCE(NULLIF($4, '')::timestamp, NOW()), NULLIF($5, ''), NULLIF($6, ''), $7) RETURNING id`
+	var subscriptionID int
+	if err := tx.QueryRow(SQL, payload.UserId, payload.Interval, payload.IntervalCount, payload.StartAt, payload.Currency, payload.Region, payload.MaxAmount).Scan(&subscriptionID); err != nil {
+		return 0, err
+	}
+	return subscriptionID, nil
+}
+
+func (u *SubscriptionRepositoryImpl) CreateSubscriptionItem(subscriptionID int, item *proto.SubscriptionItem, tx *sql.Tx) error {
+	SQL := "INSERT INTO subscription_items(subscription_id, product_id, quantity) VALUES ($1, $2, $3)"
+	if _, err := tx.Exec(SQL, subscriptionID, item.ProductId, item.Quantity); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *SubscriptionRepositoryImpl) CreateSubscriptionShipping(subscriptionID int, payload *proto.CreateSubscriptionRequest, tx *sql.Tx) error {
+	SQL := `INSERT INTO subscription_shipping(subscription_id, recipient_name, phone, line1, line2, city, region, postal_code, country,
+            carrier, service)
+        VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, NULLIF($7, ''), $8, $9, $10, $11)`
+	address := payload.ShippingAddress
+	if _, err := tx.Exec(SQL,
+		subscriptionID,
+		address.RecipientName,
+		address.Phone,
+		address.Line1,
+		address.Line2,
+		address.City,
+		address.Region,
+		address.PostalCode,
+		address.Country,
+		payload.ShippingCarrier,
+		payload.ShippingService,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *SubscriptionRepositoryImpl) GetSubscription(subscriptionID int, db *sql.DB) (*proto.Subscription, error) {
+	subscription, err := scanSubscription(db.QueryRow(selectSubscriptionSQL+" WHERE id = $1", subscriptionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+
+	if err := getSubscriptionDetails(db.Query, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (u *SubscriptionRepositoryImpl) GetSubscriptionForUpdate(subscriptionID int, tx *sql.Tx) (*proto.Subscription, error) {
+	subscription, err := scanSubscription(tx.QueryRow(selectSubscriptionSQL+" WHERE id = $1 FOR UPDATE", subscriptionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+
+	if err := getSubscriptionDetails(tx.Query, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (u *SubscriptionRepositoryImpl) ListSubscriptions(filter *SubscriptionFilter, db *sql.DB) ([]*proto.Subscription, error) {
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+
+	SQL := selectSubscriptionSQL
+	if len(conditions) > 0 {
+		SQL += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	SQL += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := db.Query(SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*proto.Subscription
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, subscription := range subscriptions {
+		if err := getSubscriptionDetails(db.Query, subscription); err != nil {
+			return nil, err
+		}
+	}
+
+	return subscriptions, nil
+}
+
+// UpdateSubscriptionStatus changes the status of a subscription, a resumed subscription whose next run
+// has passed runs right away instead of once for every missed cycle.
+func (u *SubscriptionRepositoryImpl) UpdateSubscriptionStatus(subscriptionID int, status string, tx *sql.Tx) error {
+	SQL := `UPDATE subscriptions
+        SET status = $1,
+            next_run_at = CASE WHEN $1::VARCHAR = 'active' THEN GREATEST(next_run_at, NOW()) ELSE next_run_at END,
+            updated_at = NOW()
+        WHERE id = $2`
+	if _, err := tx.Exec(SQL, status, subscriptionID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *SubscriptionRepositoryImpl) SetSkipNext(subscriptionID int, skipNext bool, tx *sql.Tx) error {
+	SQL := "UPDATE subscriptions SET skip_next = $1, updated_at = NOW() WHERE id = $2"
+	if _, err := tx.Exec(SQL, skipNext, subscriptionID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetDueSubscriptionIDs locks the active subscriptions whose next run has come, several schedulers
+// never start the same cycle twice.
+func (u *SubscriptionRepositoryImpl) GetDueSubscriptionIDs(limit int, tx *sql.Tx) ([]int, error) {
+	SQL := `SELECT id FROM subscriptions
+        WHERE status = 'active' AND next_run_at <= NOW()
+        ORDER BY next_run_at ASC
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED`
+	rows, err := tx.Query(SQL, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptionIDs []int
+	for rows.Next() {
+		var subscriptionID int
+		if err := rows.Scan(&subscriptionID); err != nil {
+			return nil, err
+		}
+		subscriptionIDs = append(subscriptionIDs, subscriptionID)
+	}
+
+	return subscriptionIDs, rows.Err()
+}
+
+// ScheduleCycle adds the cycle of the next run of a subscription, skipped when the customer asked to
+// skip it, and moves the next run one interval on. A next run that would still be in the past, e.g.
+// after the service was down, is counted from now so missed cycles are not ordered all at once.
+func (u *SubscriptionRepositoryImpl) ScheduleCycle(subscriptionID int, tx *sql.Tx) error {
+	SQL := `INSERT INTO subscription_cycles(subscription_id, scheduled_at, status)
+        SELECT id, next_run_at, CASE WHEN skip_next THEN 'skipped' ELSE 'pending' END
+        FROM subscriptions WHERE id = $1
+        ON CONFLICT (subscription_id, scheduled_at) DO NOTHING`
+	if _, err := tx.Exec(SQL, subscriptionID); err != nil {
+		return err
+	}
+
+	SQL = `UPDATE subscriptions
+        SET next_run_at = CASE WHEN next_run_at + ` + subscriptionInterval + ` > NOW()
+                THEN next_run_at + ` + subscriptionInterval + `
+                ELSE NOW() + ` + subscriptionInterval + ` END,
+            skip_next = FALSE,
+            updated_at = NOW()
+        WHERE id = $1`
+	if _, err := tx.Exec(SQL, subscriptionID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *SubscriptionRepositoryImpl) GetDueCycleIDs(limit int, db *sql.DB) ([]int, error) {
+	SQL := `SELECT id FROM subscription_cycles
+        WHERE status = 'pending' AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at ASC
+        LIMIT $1`
+	rows, err := db.Query(SQL, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cycleIDs []int
+	for rows.Next() {
+		var cycleID int
+		if err := rows.Scan(&cycleID); err != nil {
+			return nil, err
+		}
+		cycleIDs = append(cycleIDs, cycleID)
+	}
+
+	return cycleIDs, rows.Err()
+}
+
+const selectCycleSQL = `SELECT id, subscription_id, COALESCE(order_id, 0), status, attempts, COALESCE(last_error, '')
+        FROM subscription_cycles WHERE id = $1`
+
+// GetCycleForUpdate skips rows locked by another worker so a cycle is never attempted twice concurrently.
+func (u *SubscriptionRepositoryImpl) GetCycleForUpdate(cycleID int, tx *sql.Tx) (*SubscriptionCycle, error) {
+	return scanCycle(tx.QueryRow(selectCycleSQL+" FOR UPDATE SKIP LOCKED", cycleID))
+}
+
+// LockCycle waits for the lock of a cycle, unlike GetCycleForUpdate, to store the outcome of an attempt.
+func (u *SubscriptionRepositoryImpl) LockCycle(cycleID int, tx *sql.Tx) (*SubscriptionCycle, error) {
+	return scanCycle(tx.QueryRow(selectCycleSQL+" FOR UPDATE", cycleID))
+}
+
+func scanCycle(row interface{ Scan(...any) error }) (*SubscriptionCycle, error) {
+	cycle := &SubscriptionCycle{}
+	if err := row.Scan(
+		&cycle.ID,
+		&cycle.SubscriptionID,
+		&cycle.OrderID,
+		&cycle.Status,
+		&cycle.Attempts,
+		&cycle.LastError,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSubscriptionCycleNotAvailable
+		}
+		return nil, err
+	}
+	return cycle, nil
+}
+
+func (u *SubscriptionRepositoryImpl) UpdateCycle(cycle *SubscriptionCycle, retryIn time.Duration, tx *sql.Tx) error {
+	SQL := `UPDATE subscription_cycles
+        SET order_id = NULLIF($1, 0),
+            status = $2,
+            attempts = $3,
+            last_error = NULLIF($4, ''),
+            next_attempt_at = NOW() + make_interval(secs => $5),
+            updated_at = NOW()
+        WHERE id = $6`
+	if _, err := tx.Exec(SQL, cycle.OrderID, cycle.Status, cycle.Attempts, cycle.LastError, retryIn.Seconds(), cycle.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CancelPendingCycles cancels the pending cycles of a subscription that have no order yet. The other
+// pending cycles are made due so the scheduler settles them, their orders are returned.
+func (u *SubscriptionRepositoryImpl) CancelPendingCycles(subscriptionID int, tx *sql.Tx) ([]int, error) {
+	SQL := `UPDATE subscription_cycles SET status = 'cancelled', updated_at = NOW()
+        WHERE subscription_id = $1 AND status = 'pending' AND order_id IS NULL`
+	if _, err := tx.Exec(SQL, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	SQL = `UPDATE subscription_cycles SET next_attempt_at = NOW(), updated_at = NOW()
+        WHERE subscription_id = $1 AND status = 'pending'
+        RETURNING order_id`
+	rows, err := tx.Query(SQL, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orderIDs []int
+	for rows.Next() {
+		var orderID int
+		if err := rows.Scan(&orderID); err != nil {
+			return nil, err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+
+	return orderIDs, rows.Err()
+}
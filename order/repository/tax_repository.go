@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"order/proto"
+	"strings"
+)
+
+var ErrTaxRuleNotFound = errors.New("tax rule not found")
+
+type TaxRepository interface {
+	CreateTaxRule(payload *proto.TaxRule, db *sql.DB) (int, error)
+	GetTaxRule(taxRuleID int, db *sql.DB) (*proto.TaxRule, error)
+	ListTaxRules(includeInactive bool, region string, db *sql.DB) ([]*proto.TaxRule, error)
+	DeactivateTaxRule(taxRuleID int, db *sql.DB) error
+	GetRegionTaxRules(region string, tx *sql.Tx) ([]*proto.TaxRule, error)
+	CreateOrderItemTax(payload *proto.OrderTax, tx *sql.Tx) error
+	CreateOrderTax(payload *proto.OrderTax, tx *sql.Tx) error
+	DeleteOrderTaxes(orderID int, tx *sql.Tx) error
+	GetOrderTaxes(orderID int, db *sql.DB) ([]*proto.OrderTax, error)
+	GetOrderItemTaxes(orderID int, db *sql.DB) ([]*proto.OrderTax, error)
+}
+
+type TaxRepositoryImpl struct{}
+
+func NewTaxRepositoryImpl() *TaxRepositoryImpl {
+	return &TaxRepositoryImpl{}
+}
+
+const selectTaxRuleSQL = "SELECT id, name, region, tax_class, rate, active, created_at, updated_at FROM tax_rules"
+
+func scanTaxRule(row interface{ Scan(...any) error }) (*proto.TaxRule, error) {
+	taxRule := &proto.TaxRule{}
+	if err := row.Scan(
+		&taxRule.Id,
+		&taxRule.Name,
+		&taxRule.Region,
+		&taxRule.TaxClass,
+		&taxRule.Rate,
+		&taxRule.Active,
+		&taxRule.CreatedAt,
+		&taxRule.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return taxRule, nil
+}
+
+func scanTaxRules(rows *sql.Rows) ([]*proto.TaxRule, error) {
+	defer rows.Close()
+
+	var taxRules []*proto.TaxRule
+	for rows.Next() {
+		taxRule, err := scanTaxRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		taxRules = append(taxRules, taxRule)
+	}
+
+	return taxRules, rows.Err()
+}
+
+func (u *TaxRepositoryImpl) CreateTaxRule(payload *proto.TaxRule, db *sql.DB) (int, error) {
+	SQL := "INSERT INTO tax_rules(name, region, tax_class, rate) VALUES ($1, $2, $3, $4) RETURNING id"
+	var taxRuleID int
+	if err := db.QueryRow(SQL, payload.Name, payload.Region, payload.TaxClass, payload.Rate).Scan(&taxRuleID); err != nil {
+		return 0, err
+	}
+	return taxRuleID, nil
+}
+
+func (u *TaxRepositoryImpl) GetTaxRule(taxRuleID int, db *sql.DB) (*proto.TaxRule, error) {
+	taxRule, err := scanTaxRule(db.QueryRow(selectTaxRuleSQL+" WHERE id = $1", taxRuleID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaxRuleNotFound
+		}
+		return nil, err
+	}
+	return taxRule, nil
+}
+
+func (u *TaxRepositoryImpl) ListTaxRules(includeInactive bool, region string, db *sql.DB) ([]*proto.TaxRule, error) {
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if !includeInactive {
+		conditions = append(conditions, "active")
+	}
+	if region != "" {
+		addCondition("region = $%d", region)
+	}
+
+	SQL := selectTaxRuleSQL
+	if len(conditions) > 0 {
+		SQL += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	rows, err := db.Query(SQL+" ORDER BY region ASC, tax_class ASC, id ASC", args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanTaxRules(rows)
+}
+
+func (u *TaxRepositoryImpl) DeactivateTaxRule(taxRuleID int, db *sql.DB) error {
+	SQL := "UPDATE tax_rules SET active = FALSE, updated_at = NOW() WHERE id = $1"
+	result, err := db.Exec(SQL, taxRuleID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTaxRuleNotFound
+	}
+	return nil
+}
+
+// GetRegionTaxRules returns the active rules of a region, of the regions that contain it and the rules
+// without region.
+func (u *TaxRepositoryImpl) GetRegionTaxRules(region string, tx *sql.Tx) ([]*proto.TaxRule, error) {
+	SQL := selectTaxRuleSQL + `
+        WHERE active AND (region = '' OR UPPER(region) = UPPER($1) OR UPPER($1) LIKE UPPER(region) || '-%')
+        ORDER BY id ASC`
+	rows, err := tx.Query(SQL, region)
+	if err != nil {
+		return nil, err
+	}
+	return scanTaxRules(rows)
+}
+
+func (u *TaxRepositoryImpl) CreateOrderItemTax(payload *proto.OrderTax, tx *sql.Tx) error {
+	SQL := `INSERT INTO order_item_taxes(order_id, order_item_id, tax_rule_id, name, rate, taxable_amount, amount)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	if _, err := tx.Exec(SQL, payload.OrderId, payload.OrderItemId, payload.TaxRuleId, payload.Name, payload.Rate, payload.TaxableAmount, payload.Amount); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *TaxRepositoryImpl) CreateOrderTax(payload *proto.OrderTax, tx *sql.Tx) error {
+	SQL := `INSERT INTO order_taxes(order_id, tax_rule_id, name, rate, taxable_amount, amount)
+        VALUES ($1, $2, $3, $4, $5, $6)`
+	if _, err := tx.Exec(SQL, payload.OrderId, payload.TaxRuleId, payload.Name, payload.Rate, payload.TaxableAmount, payload.Amount); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *TaxRepositoryImpl) DeleteOrderTaxes(orderID int, tx *sql.Tx) error {
+	if _, err := tx.Exec("DELETE FROM order_item_taxes WHERE order_id = $1", orderID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM order_taxes WHERE order_id = $1", orderID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *TaxRepositoryImpl) GetOrderTaxes(orderID int, db *sql.DB) ([]*proto.OrderTax, error) {
+	SQL := `SELECT id, order_id, 0, COALESCE(tax_rule_id, 0), name, rate, taxable_amount, amount
+        FROM order_taxes WHERE order_id = $1 ORDER BY id ASC`
+	return getOrderTaxes(db, SQL, orderID)
+}
+
+func (u *TaxRepositoryImpl) GetOrderItemTaxes(orderID int, db *sql.DB) ([]*proto.OrderTax, error) {
+	SQL := `SELECT id, order_id, order_item_id, COALESCE(tax_rule_id, 0), name, rate, taxable_amount, amount
+        FROM order_item_taxes WHERE order_id = $1 ORDER BY id ASC`
+	return getOrderTaxes(db, SQL, orderID)
+}
+
+func getOrderTaxes(db *sql.DB, SQL string, orderID int) ([]*proto.OrderTax, error) {
+	rows, err := db.Query(SQL, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taxes []*proto.OrderTax
+	for rows.Next() {
+		tax := &proto.OrderTax{}
+		if err := rows.Scan(
+			&tax.Id,
+			&tax.OrderId,
+			&tax.OrderItemId,
+			&tax.TaxRuleId,
+			&tax.Name,
+			&tax.Rate,
+			&tax.TaxableAmount,
+			&tax.Amount,
+		); err != nil {
+			return nil, err
+		}
+		taxes = append(taxes, tax)
+	}
+
+	return taxes, rows.Err()
+}
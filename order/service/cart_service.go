@@ -0,0 +1,265 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+	"order/repository"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// checkoutKeyPrefix keeps checkout idempotency keys apart from the keys clients send to CreateOrder.
+const checkoutKeyPrefix = "checkout:"
+
+// CartService keeps a persistent cart per user. Only product IDs and quantities are stored, the
+// name, price and stock of each line are read from the product service every time the cart is shown.
+type CartService struct {
+	DB              *sql.DB
+	cartRepo        repository.CartRepository
+	productRepo     repository.ProductRepository
+	idempotencyRepo repository.IdempotencyRepository
+	exchangeRates   *ExchangeRateService
+	orderService    *OrderService
+}
+
+func NewCartService(DB *sql.DB, cartRepo repository.CartRepository, productRepo repository.ProductRepository, idempotencyRepo repository.IdempotencyRepository, exchangeRates *ExchangeRateService, orderService *OrderService) *CartService {
+	return &CartService{
+		DB:              DB,
+		cartRepo:        cartRepo,
+		productRepo:     productRepo,
+		idempotencyRepo: idempotencyRepo,
+		exchangeRates:   exchangeRates,
+		orderService:    orderService,
+	}
+}
+
+// GetCart shows the cart in the requested currency at the current rate, or else in the currency of
+// its products. Checkout converts again at the rate of that time.
+func (u *CartService) GetCart(payload *proto.GetCartRequest) (*proto.Cart, error) {
+	currency, err := normalizeCurrency(payload.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := u.getCart(int(payload.UserId))
+	if err != nil || currency == "" || cart.Currency == "" || cart.Currency == currency {
+		return cart, err
+	}
+
+	exchangeRate, err := u.exchangeRates.Rate(cart.Currency, currency)
+	if err != nil {
+		return nil, err
+	}
+	cart.Currency, cart.TotalPrice = currency, 0
+	for _, v := range cart.Items {
+		if v.Currency != exchangeRate.BaseCurrency {
+			continue
+		}
+		v.Price = convertAmount(v.Price, exchangeRate.Rate, exchangeRate.BaseCurrency, exchangeRate.QuoteCurrency)
+		v.Subtotal = int64(v.Quantity) * v.Price
+		v.Currency = currency
+		cart.TotalPrice += v.Subtotal
+	}
+	return cart, nil
+}
+
+func (u *CartService) AddCartItem(payload *proto.CartItemRequest) (*proto.Cart, error) {
+	if payload.Quantity <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity must be greater than zero")
+	}
+	if _, err := u.productRepo.GetProduct(&proto.GetProductRequest{Id: payload.ProductId}); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Adding %d of product %d to the cart of user %d", payload.Quantity, payload.ProductId, payload.UserId)
+	if err := u.cartRepo.AddCartItem(int(payload.UserId), int(payload.ProductId), int(payload.Quantity), u.DB); err != nil {
+		return nil, err
+	}
+
+	return u.getCart(int(payload.UserId))
+}
+
+func (u *CartService) UpdateCartItem(payload *proto.CartItemRequest) (*proto.Cart, error) {
+	if payload.Quantity <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity must be greater than zero")
+	}
+
+	if err := u.cartRepo.UpdateCartItemQuantity(int(payload.UserId), int(payload.ProductId), int(payload.Quantity), u.DB); err != nil {
+		if errors.Is(err, repository.ErrCartItemNotFound) {
+			return nil, status.Errorf(codes.NotFound, "product %d is not in the cart", payload.ProductId)
+		}
+		return nil, err
+	}
+
+	return u.getCart(int(payload.UserId))
+}
+
+func (u *CartService) RemoveCartItem(payload *proto.RemoveCartItemRequest) (*proto.Cart, error) {
+	if err := u.cartRepo.DeleteCartItem(int(payload.UserId), int(payload.ProductId), u.DB); err != nil {
+		if errors.Is(err, repository.ErrCartItemNotFound) {
+			return nil, status.Errorf(codes.NotFound, "product %d is not in the cart", payload.ProductId)
+		}
+		return nil, err
+	}
+
+	return u.getCart(int(payload.UserId))
+}
+
+func (u *CartService) ClearCart(payload *proto.GetCartRequest) (*proto.Cart, error) {
+	if err := u.cartRepo.ClearCart(int(payload.UserId), u.DB); err != nil {
+		return nil, err
+	}
+
+	return &proto.Cart{UserId: payload.UserId}, nil
+}
+
+// Checkout turns the cart into an order through CreateOrder and removes the ordered lines from the
+// cart. The cart rows stay locked until then, so a second checkout of the same cart waits and finds
+// it empty instead of ordering the same items twice.
+func (u *CartService) Checkout(payload *proto.CheckoutRequest) (*proto.OrderResponse, error) {
+	var idempotencyKey string
+	if payload.IdempotencyKey != "" {
+		idempotencyKey = checkoutKeyPrefix + payload.IdempotencyKey
+
+		// The cart is empty after a successful checkout, so a retry is answered from the key alone
+		response, found, err := u.replayCheckout(idempotencyKey, payload.UserId)
+		if found || err != nil {
+			return response, err
+		}
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	items, err := u.cartRepo.GetCartItemsForUpdate(int(payload.UserId), tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, status.Error(codes.FailedPrecondition, "cart is empty")
+	}
+
+	if err := u.fillCartItems(items); err != nil {
+		return nil, err
+	}
+	orderItems := make([]*proto.OrderItemRequest, 0, len(items))
+	productIDs := make([]int32, 0, len(items))
+	for _, v := range items {
+		if !v.Available {
+			return nil, status.Errorf(codes.FailedPrecondition, "only %d of product %d is in stock", v.Stock, v.ProductId)
+		}
+		orderItems = append(orderItems, &proto.OrderItemRequest{
+			ProductId: v.ProductId,
+			Quantity:  v.Quantity,
+		})
+		productIDs = append(productIDs, v.ProductId)
+	}
+
+	logrus.Infof("Checking out the cart of user %d", payload.UserId)
+	response, err := u.orderService.CreateOrder(&proto.CreateOrderRequest{
+		UserId:          payload.UserId,
+		Items:           orderItems,
+		IdempotencyKey:  idempotencyKey,
+		CouponCode:      payload.CouponCode,
+		Region:          payload.Region,
+		ShippingAddress: payload.ShippingAddress,
+		ShippingCarrier: payload.ShippingCarrier,
+		ShippingService: payload.ShippingService,
+		Currency:        payload.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.cartRepo.DeleteCartItems(int(payload.UserId), productIDs, tx); err != nil {
+		logrus.Errorf("Failed to empty the cart of user %d after order %d: %v", payload.UserId, response.Order.Id, err)
+		return response, nil
+	}
+	if err := tx.Commit(); err != nil {
+		logrus.Errorf("Failed to empty the cart of user %d after order %d: %v", payload.UserId, response.Order.Id, err)
+	}
+
+	return response, nil
+}
+
+// replayCheckout returns the order of a checkout that already used the key, found is false for a new key.
+func (u *CartService) replayCheckout(key string, userID int32) (*proto.OrderResponse, bool, error) {
+	record, err := u.idempotencyRepo.GetIdempotencyKey(userID, key, u.DB)
+	if err != nil {
+		if errors.Is(err, repository.ErrIdempotencyKeyNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	response := &proto.OrderResponse{}
+	if err := protobuf.Unmarshal(record.Response, response); err != nil {
+		return nil, true, err
+	}
+	if response.Order.GetUserId() != userID {
+		return nil, true, status.Error(codes.AlreadyExists, "idempotency key was already used with a different request")
+	}
+
+	logrus.Infof("Replaying checkout order %d for idempotency key %s", response.Order.Id, key)
+	return response, true, nil
+}
+
+func (u *CartService) getCart(userID int) (*proto.Cart, error) {
+	items, err := u.cartRepo.GetCartItems(userID, u.DB)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.fillCartItems(items); err != nil {
+		return nil, err
+	}
+
+	cart := &proto.Cart{
+		UserId:    int32(userID),
+		Items:     items,
+		Available: len(items) > 0,
+	}
+	// An order has one currency, products priced in another one cannot be checked out together
+	for _, v := range items {
+		if cart.Currency == "" {
+			cart.Currency = v.Currency
+		}
+		if v.Currency != cart.Currency {
+			v.Available = false
+		} else {
+			cart.TotalPrice += v.Subtotal
+		}
+		cart.Available = cart.Available && v.Available
+	}
+
+	return cart, nil
+}
+
+// fillCartItems adds the current name, price and stock of each product. A product that was deleted
+// since it was put in the cart stays in the cart as unavailable until the user removes it.
+func (u *CartService) fillCartItems(items []*proto.CartItem) error {
+	for _, v := range items {
+		product, err := u.productRepo.GetProduct(&proto.GetProductRequest{Id: v.ProductId})
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				v.Available = false
+				continue
+			}
+			return err
+		}
+
+		v.ProductName = product.Name
+		v.Price = product.Price
+		v.Currency = product.Currency
+		v.Stock = product.Stock
+		v.Available = product.Stock >= v.Quantity
+		v.Subtotal = int64(v.Quantity) * product.Price
+	}
+	return nil
+}
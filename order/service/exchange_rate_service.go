@@ -0,0 +1,174 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"order/proto"
+	"order/repository"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Values stored in exchange_rates.source.
+const (
+	ExchangeRateSourceAdmin = "admin"
+	ExchangeRateSourceFile  = "file"
+)
+
+// ExchangeRateService keeps the rates that convert product prices into the currency a customer pays in.
+// Rates are set by an admin or loaded from a file when the service starts, a stored pair also converts
+// the other way with its inverse.
+type ExchangeRateService struct {
+	DB               *sql.DB
+	exchangeRateRepo repository.ExchangeRateRepository
+}
+
+func NewExchangeRateService(DB *sql.DB, exchangeRateRepo repository.ExchangeRateRepository) *ExchangeRateService {
+	return &ExchangeRateService{
+		DB:               DB,
+		exchangeRateRepo: exchangeRateRepo,
+	}
+}
+
+func (u *ExchangeRateService) SetExchangeRate(payload *proto.ExchangeRate) (*proto.ExchangeRate, error) {
+	var err error
+	if payload.BaseCurrency, err = normalizeCurrency(payload.BaseCurrency); err != nil {
+		return nil, err
+	}
+	if payload.QuoteCurrency, err = normalizeCurrency(payload.QuoteCurrency); err != nil {
+		return nil, err
+	}
+	if payload.BaseCurrency == "" || payload.QuoteCurrency == "" {
+		return nil, status.Error(codes.InvalidArgument, "base and quote currency are required")
+	}
+	if payload.BaseCurrency == payload.QuoteCurrency {
+		return nil, status.Error(codes.InvalidArgument, "base and quote currency must be different")
+	}
+	if payload.Rate <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "rate must be greater than zero")
+	}
+	if payload.Source == "" {
+		payload.Source = ExchangeRateSourceAdmin
+	}
+
+	logrus.Infof("Setting exchange rate %s/%s to %v", payload.BaseCurrency, payload.QuoteCurrency, payload.Rate)
+	if err := u.exchangeRateRepo.UpsertExchangeRate(payload, u.DB); err != nil {
+		return nil, err
+	}
+
+	return u.exchangeRateRepo.GetExchangeRate(payload.BaseCurrency, payload.QuoteCurrency, u.DB)
+}
+
+func (u *ExchangeRateService) GetExchangeRate(payload *proto.GetExchangeRateRequest) (*proto.ExchangeRate, error) {
+	baseCurrency, err := normalizeCurrency(payload.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	quoteCurrency, err := normalizeCurrency(payload.QuoteCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if baseCurrency == "" || quoteCurrency == "" {
+		return nil, status.Error(codes.InvalidArgument, "base and quote currency are required")
+	}
+
+	exchangeRate, err := u.Rate(baseCurrency, quoteCurrency)
+	if status.Code(err) == codes.FailedPrecondition {
+		return nil, status.Error(codes.NotFound, status.Convert(err).Message())
+	}
+	return exchangeRate, err
+}
+
+func (u *ExchangeRateService) ListExchangeRates(payload *proto.ListExchangeRatesRequest) ([]*proto.ExchangeRate, error) {
+	baseCurrency, err := normalizeCurrency(payload.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	return u.exchangeRateRepo.ListExchangeRates(baseCurrency, u.DB)
+}
+
+// ConvertAmounts converts amounts at the current rate with the minor units of both currencies, so
+// clients can show prices in their currency the way orders convert them.
+func (u *ExchangeRateService) ConvertAmounts(payload *proto.ConvertAmountsRequest) (*proto.ConvertAmountsResponse, error) {
+	exchangeRate, err := u.GetExchangeRate(&proto.GetExchangeRateRequest{
+		BaseCurrency:  payload.BaseCurrency,
+		QuoteCurrency: payload.QuoteCurrency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make([]int64, 0, len(payload.Amounts))
+	for _, amount := range payload.Amounts {
+		amounts = append(amounts, convertAmount(amount, exchangeRate.Rate, exchangeRate.BaseCurrency, exchangeRate.QuoteCurrency))
+	}
+	return &proto.ConvertAmountsResponse{
+		ExchangeRate: exchangeRate,
+		Amounts:      amounts,
+	}, nil
+}
+
+// Rate returns the rate that converts baseCurrency into quoteCurrency: 1 for the same currency, else the
+// stored pair or the inverse of the opposite pair.
+func (u *ExchangeRateService) Rate(baseCurrency string, quoteCurrency string) (*proto.ExchangeRate, error) {
+	if baseCurrency == quoteCurrency {
+		return &proto.ExchangeRate{BaseCurrency: baseCurrency, QuoteCurrency: quoteCurrency, Rate: 1}, nil
+	}
+
+	exchangeRate, err := u.exchangeRateRepo.GetExchangeRate(baseCurrency, quoteCurrency, u.DB)
+	if err == nil || !errors.Is(err, repository.ErrExchangeRateNotFound) {
+		return exchangeRate, err
+	}
+
+	inverse, err := u.exchangeRateRepo.GetExchangeRate(quoteCurrency, baseCurrency, u.DB)
+	if err != nil {
+		if errors.Is(err, repository.ErrExchangeRateNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "no exchange rate from %s to %s", baseCurrency, quoteCurrency)
+		}
+		return nil, err
+	}
+	return &proto.ExchangeRate{
+		BaseCurrency:  baseCurrency,
+		QuoteCurrency: quoteCurrency,
+		Rate:          1 / inverse.Rate,
+		Source:        inverse.Source,
+		UpdatedAt:     inverse.UpdatedAt,
+	}, nil
+}
+
+// Convert converts an amount at the current rate.
+func (u *ExchangeRateService) Convert(amount int64, baseCurrency string, quoteCurrency string) (int64, error) {
+	exchangeRate, err := u.Rate(baseCurrency, quoteCurrency)
+	if err != nil {
+		return 0, err
+	}
+	return convertAmount(amount, exchangeRate.Rate, baseCurrency, quoteCurrency), nil
+}
+
+// LoadFile stores the rates of a JSON file, a list of objects with base_currency, quote_currency and
+// rate. Rates missing from the file keep their current value.
+func (u *ExchangeRateService) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var exchangeRates []*proto.ExchangeRate
+	if err := json.Unmarshal(data, &exchangeRates); err != nil {
+		return fmt.Errorf("invalid exchange rates file %s: %w", path, err)
+	}
+	for _, v := range exchangeRates {
+		v.Source = ExchangeRateSourceFile
+		if _, err := u.SetExchangeRate(v); err != nil {
+			return fmt.Errorf("exchange rate %s/%s: %w", v.BaseCurrency, v.QuoteCurrency, err)
+		}
+	}
+
+	logrus.Infof("Loaded %d exchange rates from %s", len(exchangeRates), path)
+	return nil
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+	"order/repository"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Status values stored in shipments.status.
+const (
+	ShipmentStatusPending   = "pending"
+	ShipmentStatusPicked    = "picked"
+	ShipmentStatusPacked    = "packed"
+	ShipmentStatusShipped   = "shipped"
+	ShipmentStatusInTransit = "in_transit"
+	ShipmentStatusDelivered = "delivered"
+	ShipmentStatusCancelled = "cancelled"
+)
+
+const shipmentListLimit = 100
+
+// shipmentStatusTransitions lists every status a shipment may move to from its current status, in_transit
+// may follow itself to add tracking events on the way.
+var shipmentStatusTransitions = map[string][]string{
+	ShipmentStatusPending:   {ShipmentStatusPicked, ShipmentStatusCancelled},
+	ShipmentStatusPicked:    {ShipmentStatusPacked, ShipmentStatusCancelled},
+	ShipmentStatusPacked:    {ShipmentStatusShipped, ShipmentStatusCancelled},
+	ShipmentStatusShipped:   {ShipmentStatusInTransit, ShipmentStatusDelivered},
+	ShipmentStatusInTransit: {ShipmentStatusInTransit, ShipmentStatusDelivered},
+	ShipmentStatusDelivered: {},
+	ShipmentStatusCancelled: {},
+}
+
+// shippedShipmentStatuses are the statuses of shipments that left the warehouse.
+var shippedShipmentStatuses = []string{ShipmentStatusShipped, ShipmentStatusInTransit, ShipmentStatusDelivered}
+
+// FulfillmentService picks, packs and ships paid orders. An order may be split over several shipments,
+// it becomes fulfilled once every item was shipped and completed once every item was delivered.
+type FulfillmentService struct {
+	DB            *sql.DB
+	orderRepo     repository.OrderRepository
+	orderItemRepo repository.OrderItemsRepository
+	shipmentRepo  repository.ShipmentRepository
+	shippingRepo  repository.ShippingRepository
+	historyRepo   repository.OrderHistoryRepository
+}
+
+func NewFulfillmentService(DB *sql.DB, orderRepo repository.OrderRepository, orderItemRepo repository.OrderItemsRepository, shipmentRepo repository.ShipmentRepository, shippingRepo repository.ShippingRepository, historyRepo repository.OrderHistoryRepository) *FulfillmentService {
+	return &FulfillmentService{
+		DB:            DB,
+		orderRepo:     orderRepo,
+		orderItemRepo: orderItemRepo,
+		shipmentRepo:  shipmentRepo,
+		shippingRepo:  shippingRepo,
+		historyRepo:   historyRepo,
+	}
+}
+
+// CreateShipment puts items of a paid order in a new shipment, without items it takes everything that is
+// not in a shipment yet. The carrier and service default to the ones chosen at checkout.
+func (u *FulfillmentService) CreateShipment(payload *proto.CreateShipmentRequest) (*proto.Shipment, error) {
+	// Merge duplicate order items, keeping the order they were requested in
+	var orderItemIDs []int32
+	quantities := make(map[int32]int32)
+	for _, v := range payload.Items {
+		if v.Quantity <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "quantity must be greater than zero")
+		}
+		if _, ok := quantities[v.OrderItemId]; !ok {
+			orderItemIDs = append(orderItemIDs, v.OrderItemId)
+		}
+		quantities[v.OrderItemId] += v.Quantity
+	}
+
+	order, err := u.orderRepo.GetOrderByID(&proto.GetOrderRequest{OrderId: payload.OrderId}, u.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	shipment := &proto.Shipment{
+		OrderId:        order.Id,
+		Carrier:        payload.Carrier,
+		Service:        payload.Service,
+		TrackingNumber: payload.TrackingNumber,
+		Status:         ShipmentStatusPending,
+	}
+	if shipment.Carrier == "" {
+		shipping, err := u.shippingRepo.GetOrderShipping(int(order.Id), u.DB)
+		if err != nil {
+			if errors.Is(err, repository.ErrOrderShippingNotFound) {
+				return nil, status.Errorf(codes.InvalidArgument, "order %d has no shipping method, carrier is required", order.Id)
+			}
+			return nil, err
+		}
+		shipment.Carrier, shipment.Service = shipping.Carrier, shipping.Service
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the order serializes shipments of the same order
+	orderStatus, err := u.orderRepo.GetOrderStatusForUpdate(int(order.Id), tx)
+	if err != nil {
+		return nil, err
+	}
+	if orderStatus != OrderStatusPaid {
+		return nil, status.Errorf(codes.FailedPrecondition, "order %d is %s and cannot be shipped", order.Id, orderStatus)
+	}
+
+	orderItems, err := u.orderItemRepo.GetOrderItemsForUpdate(int(order.Id), tx)
+	if err != nil {
+		return nil, err
+	}
+	shipmentQuantities, err := u.shipmentRepo.GetShipmentQuantities(int(order.Id), tx)
+	if err != nil {
+		return nil, err
+	}
+	// What cancelled shipments held can be shipped again
+	left := make(map[int32]int32, len(orderItems))
+	for _, v := range orderItems {
+		left[v.Id] = v.Quantity
+		for shipmentStatus, itemQuantities := range shipmentQuantities {
+			if shipmentStatus != ShipmentStatusCancelled {
+				left[v.Id] -= itemQuantities[v.Id]
+			}
+		}
+	}
+
+	if len(payload.Items) == 0 {
+		for _, v := range orderItems {
+			if left[v.Id] > 0 {
+				orderItemIDs = append(orderItemIDs, v.Id)
+				quantities[v.Id] = left[v.Id]
+			}
+		}
+		if len(orderItemIDs) == 0 {
+			return nil, status.Errorf(codes.FailedPrecondition, "every item of order %d is already in a shipment", order.Id)
+		}
+	}
+
+	orderItemsByID := make(map[int32]*proto.OrderItem, len(orderItems))
+	for _, v := range orderItems {
+		orderItemsByID[v.Id] = v
+	}
+	for _, orderItemID := range orderItemIDs {
+		orderItem, ok := orderItemsByID[orderItemID]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "order item %d does not belong to order %d", orderItemID, order.Id)
+		}
+		if quantities[orderItemID] > left[orderItemID] {
+			return nil, status.Errorf(codes.FailedPrecondition, "only %d of order item %d can still be shipped", left[orderItemID], orderItemID)
+		}
+		shipment.Items = append(shipment.Items, &proto.ShipmentItem{
+			OrderItemId: orderItem.Id,
+			ProductId:   orderItem.ProductId,
+			Quantity:    quantities[orderItemID],
+		})
+	}
+
+	logrus.Infof("Creating %s shipment for order %d by %s", shipment.Carrier, order.Id, payload.Actor)
+	shipmentID, err := u.shipmentRepo.CreateShipment(shipment, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range shipment.Items {
+		if err := u.shipmentRepo.CreateShipmentItem(shipmentID, v, tx); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.shipmentRepo.CreateShipmentEvent(shipmentID, &proto.ShipmentEvent{
+		Status:      ShipmentStatusPending,
+		Description: "shipment created",
+	}, tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return u.shipmentRepo.GetShipment(shipmentID, u.DB)
+}
+
+// UpdateShipmentStatus moves a shipment along and records a tracking event for it. The order follows
+// its shipments: fulfilled once every item was shipped, completed once every item was delivered.
+func (u *FulfillmentService) UpdateShipmentStatus(payload *proto.UpdateShipmentStatusRequest) (*proto.Shipment, error) {
+	if _, ok := shipmentStatusTransitions[payload.Status]; !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown shipment status %s", payload.Status)
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	shipment, err := u.shipmentRepo.GetShipmentForUpdate(int(payload.ShipmentId), tx)
+	if err != nil {
+		if errors.Is(err, repository.ErrShipmentNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	orderStatus, err := u.orderRepo.GetOrderStatusForUpdate(int(shipment.OrderId), tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Repeating the current status returns the shipment unchanged, except for in_transit updates
+	if shipment.Status == payload.Status && payload.Status != ShipmentStatusInTransit {
+		return shipment, nil
+	}
+	if !slices.Contains(shipmentStatusTransitions[shipment.Status], payload.Status) {
+		return nil, status.Errorf(codes.FailedPrecondition, "shipment %d cannot change status from %s to %s", shipment.Id, shipment.Status, payload.Status)
+	}
+	if payload.Status != ShipmentStatusCancelled && orderStatus != OrderStatusPaid && orderStatus != OrderStatusFulfilled {
+		return nil, status.Errorf(codes.FailedPrecondition, "order %d is %s", shipment.OrderId, orderStatus)
+	}
+
+	if payload.TrackingNumber != "" {
+		shipment.TrackingNumber = payload.TrackingNumber
+	}
+	if payload.Status == ShipmentStatusShipped && shipment.TrackingNumber == "" {
+		return nil, status.Error(codes.InvalidArgument, "tracking number is required to ship")
+	}
+
+	logrus.Infof("Shipment %d %s by %s", shipment.Id, payload.Status, payload.Actor)
+	shipment.Status = payload.Status
+	if err := u.shipmentRepo.UpdateShipment(shipment, tx); err != nil {
+		return nil, err
+	}
+	if err := u.shipmentRepo.CreateShipmentEvent(int(shipment.Id), &proto.ShipmentEvent{
+		Status:      payload.Status,
+		Location:    payload.Location,
+		Description: payload.Description,
+	}, tx); err != nil {
+		return nil, err
+	}
+
+	if err := u.advanceOrder(int(shipment.OrderId), orderStatus, payload.Actor, tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return u.shipmentRepo.GetShipment(int(shipment.Id), u.DB)
+}
+
+// advanceOrder moves a paid order to fulfilled once every item was shipped and on to completed once every
+// item was delivered, each step is recorded in the order status history.
+func (u *FulfillmentService) advanceOrder(orderID int, orderStatus string, actor string, tx *sql.Tx) error {
+	orderItems, err := u.orderItemRepo.GetOrderItemsForUpdate(orderID, tx)
+	if err != nil {
+		return err
+	}
+	quantities, err := u.shipmentRepo.GetShipmentQuantities(orderID, tx)
+	if err != nil {
+		return err
+	}
+
+	shipped, delivered := true, true
+	for _, v := range orderItems {
+		var shippedQuantity int32
+		for _, shipmentStatus := range shippedShipmentStatuses {
+			shippedQuantity += quantities[shipmentStatus][v.Id]
+		}
+		shipped = shipped && shippedQuantity >= v.Quantity
+		delivered = delivered && quantities[ShipmentStatusDelivered][v.Id] >= v.Quantity
+	}
+
+	if actor == "" {
+		actor = actorFulfillment
+	}
+	if shipped && orderStatus == OrderStatusPaid {
+		if err := transitionOrderStatus(u.orderRepo, u.historyRepo, &proto.OrderStatusChange{
+			OrderId:   int32(orderID),
+			NewStatus: OrderStatusFulfilled,
+			Actor:     actor,
+			Reason:    "every item was shipped",
+		}, tx); err != nil {
+			return err
+		}
+		orderStatus = OrderStatusFulfilled
+	}
+	if delivered && orderStatus == OrderStatusFulfilled {
+		return transitionOrderStatus(u.orderRepo, u.historyRepo, &proto.OrderStatusChange{
+			OrderId:   int32(orderID),
+			NewStatus: OrderStatusCompleted,
+			Actor:     actor,
+			Reason:    "every item was delivered",
+		}, tx)
+	}
+	return nil
+}
+
+func (u *FulfillmentService) GetShipment(payload *proto.GetShipmentRequest) (*proto.Shipment, error) {
+	shipment, err := u.shipmentRepo.GetShipment(int(payload.ShipmentId), u.DB)
+	if err != nil {
+		if errors.Is(err, repository.ErrShipmentNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+	if payload.UserId != 0 && shipment.UserId != payload.UserId {
+		return nil, status.Error(codes.NotFound, repository.ErrShipmentNotFound.Error())
+	}
+
+	return shipment, nil
+}
+
+func (u *FulfillmentService) ListShipments(payload *proto.ListShipmentsRequest) ([]*proto.Shipment, error) {
+	if _, ok := shipmentStatusTransitions[payload.Status]; payload.Status != "" && !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown shipment status %s", payload.Status)
+	}
+
+	return u.shipmentRepo.ListShipments(&repository.ShipmentFilter{
+		OrderID: int(payload.OrderId),
+		UserID:  int(payload.UserId),
+		Status:  payload.Status,
+		Limit:   shipmentListLimit,
+	}, u.DB)
+}
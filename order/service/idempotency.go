@@ -0,0 +1,64 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"order/proto"
+	"order/repository"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// reservedKeyPrefixes start the idempotency keys the service makes up for checkouts and subscription
+// cycles. Clients cannot send them, or they could take the key of a cycle before it is ordered.
+var reservedKeyPrefixes = []string{checkoutKeyPrefix, subscriptionKeyPrefix}
+
+// ReservedIdempotencyKey reports whether key starts with a prefix the service keeps for its own keys.
+func ReservedIdempotencyKey(key string) bool {
+	for _, prefix := range reservedKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// createOrderHash fingerprints a CreateOrder request so a reused key with another payload is detected.
+func createOrderHash(payload *proto.CreateOrderRequest) (string, error) {
+	request := protobuf.Clone(payload).(*proto.CreateOrderRequest)
+	request.IdempotencyKey = ""
+
+	data, err := protobuf.MarshalOptions{Deterministic: true}.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// replayCreateOrder returns the stored response of a key the user already used, found is false for a new key.
+func (u *OrderService) replayCreateOrder(userID int32, key string, requestHash string) (*proto.OrderResponse, bool, error) {
+	record, err := u.idempotencyRepo.GetIdempotencyKey(userID, key, u.DB)
+	if err != nil {
+		if errors.Is(err, repository.ErrIdempotencyKeyNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, true, status.Error(codes.AlreadyExists, "idempotency key was already used with a different request")
+	}
+
+	response := &proto.OrderResponse{}
+	if err := protobuf.Unmarshal(record.Response, response); err != nil {
+		return nil, true, err
+	}
+	return response, true, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Amounts are int64 minor units of the order currency, 1/100 of the currency unit for most currencies
+// and 10^-currencyExponent of it in general. Computations that need a fraction of an amount round it
+// once to the nearest minor unit.
+
+// defaultCurrency is the currency of products created without one, as set in the products table.
+const defaultCurrency = "IDR"
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not 1/100 of the currency unit.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// currencyExponent returns the number of decimals of the minor unit of a currency.
+func currencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// percentOf returns percent of amount, rounded to a minor unit.
+func percentOf(amount int64, percent float64) int64 {
+	return int64(math.Round(float64(amount) * percent / 100))
+}
+
+// scaleAmount returns amount * numerator / denominator rounded to a minor unit, used to share an amount
+// in proportion to a part of a total.
+func scaleAmount(amount int64, numerator int64, denominator int64) int64 {
+	if denominator == 0 {
+		return 0
+	}
+	return int64(math.Round(float64(amount) * float64(numerator) / float64(denominator)))
+}
+
+// convertAmount converts an amount of baseCurrency to quoteCurrency with an exchange rate per currency
+// unit, rounded to a minor unit of quoteCurrency.
+func convertAmount(amount int64, rate float64, baseCurrency string, quoteCurrency string) int64 {
+	shift := math.Pow10(currencyExponent(quoteCurrency) - currencyExponent(baseCurrency))
+	return int64(math.Round(float64(amount) * rate * shift))
+}
+
+// formatAmount writes an amount with its currency for messages, e.g. 1500.50 IDR or 1500 JPY.
+func formatAmount(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	exponent := currencyExponent(currency)
+	if exponent == 0 {
+		return fmt.Sprintf("%s%d %s", sign, amount, currency)
+	}
+	unit := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/unit, exponent, amount%unit, currency)
+}
+
+// envCurrency reads a currency code from the environment, fallback when it is not set.
+func envCurrency(name string, fallback string) string {
+	if currency := strings.ToUpper(strings.TrimSpace(os.Getenv(name))); len(currency) == 3 {
+		return currency
+	}
+	return fallback
+}
+
+// normalizeCurrency upper cases a currency code, an empty code stays empty.
+func normalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency != "" && len(currency) != 3 {
+		return "", status.Error(codes.InvalidArgument, "currency must be a three letter code")
+	}
+	return currency, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"order/proto"
+	"order/transport/kafka"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultOrderPaymentTTL = 30 * time.Minute
+	orderExpirySweepPeriod = time.Minute
+	orderExpirySweepBatch  = 50
+)
+
+// ExpirePendingOrders periodically cancels orders that were not paid within ORDER_PAYMENT_TTL_SECONDS,
+// their payment is marked expired and their stock released by the saga.
+func (u *OrderService) ExpirePendingOrders(ctx context.Context) {
+	logrus.Info("Starting order expiry worker")
+	ticker := time.NewTicker(orderExpirySweepPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Order expiry worker stopping...")
+			return
+		case <-ticker.C:
+			if err := u.expireBatch(); err != nil {
+				logrus.Errorf("failed to expire orders: %v", err)
+			}
+		}
+	}
+}
+
+func (u *OrderService) expireBatch() error {
+	orderIDs, err := u.orderRepo.GetExpiredPendingOrderIDs(orderPaymentTTL().Seconds(), orderExpirySweepBatch, u.DB)
+	if err != nil {
+		return err
+	}
+
+	for _, orderID := range orderIDs {
+		order, err := u.orderRepo.GetOrderByID(&proto.GetOrderRequest{OrderId: int32(orderID)}, u.DB)
+		if err != nil {
+			logrus.Errorf("failed to get expired order %d: %v", orderID, err)
+			continue
+		}
+
+		logrus.Infof("Expiring unpaid order %d", orderID)
+		if err := u.cancel(order, actorOrderExpiry, "payment was not received in time", SagaStepExpirePayment, kafka.EventOrderExpired, orderExpiredTopic()); err != nil {
+			// The order was paid or failed after it was selected
+			if status.Code(err) == codes.FailedPrecondition {
+				logrus.Infof("Order %d is no longer pending: %v", orderID, err)
+				continue
+			}
+			logrus.Errorf("failed to expire order %d: %v", orderID, err)
+		}
+	}
+
+	return nil
+}
+
+func orderPaymentTTL() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("ORDER_PAYMENT_TTL_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return defaultOrderPaymentTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func orderExpiredTopic() string {
+	if topic := os.Getenv("KAFKA_ORDER_EXPIRED_TOPIC"); topic != "" {
+		return topic
+	}
+	return "order.expired"
+}
@@ -1,46 +1,212 @@
-package service
-
-// import (
-// 	"database/sql"
-// 	"order/helper"
-// 	"order/proto"
-// 	"order/repository"
-// )
-
-// type OrderItemService struct {
-// 	DB            *sql.DB
-// 	orderItemRepo repository.OrderItemsRepository
-// }
-
-// func NewOrderItemService(DB *sql.DB, orderItemRepo repository.OrderItemsRepository) *OrderItemService {
-// 	return &OrderItemService{
-// 		DB:            DB,
-// 		orderItemRepo: orderItemRepo,
-// 	}
-// }
-
-// func (u *OrderItemService) Create(payload *proto.OrderItemRequest) error {
-// 	tx, err := u.DB.Begin()
-// 	if err != nil {
-// 		return err
-// 	}
-// 	defer helper.CommitOrRollback(tx)
-
-// 	if err := u.orderItemRepo.CreateOrderItems(payload, tx); err != nil {
-// 		return err
-// 	}
-// 	return nil
-// }
-
-// func (u *OrderItemService) Delete(payload *proto.GetOrderItemRequest) error {
-// 	tx, err := u.DB.Begin()
-// 	if err != nil {
-// 		return err
-// 	}
-// 	defer helper.CommitOrRollback(tx)
-
-// 	if err := u.orderItemRepo.DeleteOrderItems(payload, tx); err != nil {
-// 		return err
-// 	}
-// 	return nil
-// }
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+	"order/repository"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// orderItemEdit picks the line an edit applies to and its new quantity, a line without ID is new
+// and a zero quantity removes the line.
+type orderItemEdit func(orderID int, tx *sql.Tx) (*proto.OrderItem, int32, error)
+
+func (u *OrderService) AddOrderItem(payload *proto.AddOrderItemRequest) (*proto.OrderResponse, error) {
+	if payload.Quantity <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity must be greater than zero")
+	}
+
+	return u.editOrderItems(payload.OrderId, payload.UserId, func(orderID int, tx *sql.Tx) (*proto.OrderItem, int32, error) {
+		orderItem, err := u.orderItemRepo.GetOrderItemByProductID(orderID, int(payload.ProductId), tx)
+		if errors.Is(err, repository.ErrOrderItemNotFound) {
+			return &proto.OrderItem{OrderId: int32(orderID), ProductId: payload.ProductId}, payload.Quantity, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return orderItem, orderItem.Quantity + payload.Quantity, nil
+	})
+}
+
+func (u *OrderService) UpdateOrderItemQuantity(payload *proto.UpdateOrderItemQuantityRequest) (*proto.OrderResponse, error) {
+	if payload.Quantity <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity must be greater than zero")
+	}
+
+	return u.editOrderItems(payload.OrderId, payload.UserId, func(orderID int, tx *sql.Tx) (*proto.OrderItem, int32, error) {
+		orderItem, err := u.getOrderItemOfOrder(orderID, payload.OrderItemId, tx)
+		if err != nil {
+			return nil, 0, err
+		}
+		return orderItem, payload.Quantity, nil
+	})
+}
+
+func (u *OrderService) RemoveOrderItem(payload *proto.RemoveOrderItemRequest) (*proto.OrderResponse, error) {
+	return u.editOrderItems(payload.OrderId, payload.UserId, func(orderID int, tx *sql.Tx) (*proto.OrderItem, int32, error) {
+		orderItem, err := u.getOrderItemOfOrder(orderID, payload.OrderItemId, tx)
+		if err != nil {
+			return nil, 0, err
+		}
+		return orderItem, 0, nil
+	})
+}
+
+func (u *OrderService) getOrderItemOfOrder(orderID int, orderItemID int32, tx *sql.Tx) (*proto.OrderItem, error) {
+	orderItem, err := u.orderItemRepo.GetOrderItem(int(orderItemID), tx)
+	if err != nil && !errors.Is(err, repository.ErrOrderItemNotFound) {
+		return nil, err
+	}
+	if err != nil || orderItem.OrderId != int32(orderID) {
+		return nil, status.Errorf(codes.NotFound, "order item %d not found in order %d", orderItemID, orderID)
+	}
+	return orderItem, nil
+}
+
+// editOrderItems applies an edit to a pending order: the stock reservation follows the new quantity,
+// the promotions, taxes, shipping and total_price are recomputed and the pending payment gets the new amount. The order row stays locked
+// until the end so the saga cannot create or capture the payment halfway through; when a later step
+// fails the reservation and the payment are set back to what they were.
+func (u *OrderService) editOrderItems(orderID int32, userID int32, edit orderItemEdit) (*proto.OrderResponse, error) {
+	order, err := u.orderRepo.GetOrderByID(&proto.GetOrderRequest{OrderId: orderID}, u.DB)
+	if err != nil {
+		return nil, err
+	}
+	if userID != 0 && order.UserId != userID {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	orderStatus, err := u.orderRepo.GetOrderStatusForUpdate(int(order.Id), tx)
+	if err != nil {
+		return nil, err
+	}
+	if orderStatus != OrderStatusPending {
+		return nil, status.Errorf(codes.FailedPrecondition, "order %d is %s, only pending orders can be edited", order.Id, orderStatus)
+	}
+
+	reservationID, err := u.orderRepo.GetOrderReservationID(int(order.Id), tx)
+	if err != nil {
+		return nil, err
+	}
+	if reservationID == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "order %d has no stock reservation", order.Id)
+	}
+
+	orderItem, quantity, err := edit(int(order.Id), tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if quantity == 0 {
+		if _, count, err := u.orderItemRepo.SumOrderItems(int(order.Id), tx); err != nil {
+			return nil, err
+		} else if count <= 1 {
+			return nil, status.Error(codes.FailedPrecondition, "an order needs at least one item, cancel the order instead")
+		}
+	}
+
+	logrus.Infof("Setting product %d of order %d to quantity %d", orderItem.ProductId, order.Id, quantity)
+	reserved, err := u.productRepo.AdjustReservation(&proto.AdjustReservationRequest{
+		ReservationId: reservationID,
+		ProductId:     orderItem.ProductId,
+		Quantity:      quantity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	committed := false
+	paymentUpdated := false
+	defer func() {
+		if committed {
+			return
+		}
+		if _, err := u.productRepo.AdjustReservation(&proto.AdjustReservationRequest{
+			ReservationId: reservationID,
+			ProductId:     orderItem.ProductId,
+			Quantity:      orderItem.Quantity,
+		}); err != nil {
+			logrus.Errorf("Failed to restore reservation %s for product %d: %v", reservationID, orderItem.ProductId, err)
+		}
+		if paymentUpdated {
+			if _, err := u.paymentRepo.UpdatePaymentAmount(&proto.UpdatePaymentAmountRequest{
+				OrderId:    order.Id,
+				TotalPrice: order.TotalPrice,
+			}); err != nil {
+				logrus.Errorf("Failed to restore payment amount of order %d: %v", order.Id, err)
+			}
+		}
+	}()
+
+	switch {
+	case quantity == 0:
+		err = u.orderItemRepo.DeleteOrderItems(&proto.GetOrderItemRequest{OrderItemId: orderItem.Id}, tx)
+	case orderItem.Id != 0:
+		err = u.orderItemRepo.UpdateOrderItemQuantity(int(orderItem.Id), int(quantity), tx)
+	case reserved.Currency != order.BaseCurrency:
+		err = status.Errorf(codes.FailedPrecondition, "product %d is priced in %s, order %d in %s", orderItem.ProductId, reserved.Currency, order.Id, order.BaseCurrency)
+	default:
+		// New lines snapshot the current product price and name like CreateOrder does, converted at the
+		// rate the order was placed with
+		err = u.orderItemRepo.CreateOrderItems(&proto.OrderItem{
+			OrderId:     order.Id,
+			ProductId:   orderItem.ProductId,
+			Quantity:    quantity,
+			Price:       convertAmount(reserved.Price, order.ExchangeRate, order.BaseCurrency, order.Currency),
+			ProductName: reserved.Name,
+			Category:    reserved.Category,
+			TaxClass:    reserved.TaxClass,
+			WeightGrams: reserved.WeightGrams,
+		}, tx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Promotions are priced again as of the time the order was placed, shipping at the current rates
+	orderItems, err := u.orderItemRepo.GetOrderItemsForUpdate(int(order.Id), tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.shipping.RequoteOrder(order, orderItems, tx); err != nil {
+		return nil, err
+	}
+	pricing, err := u.priceOrder(order, orderItems, order.CreatedAt, false, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a payment yet, the saga creates it later from the new total
+	if _, err := u.paymentRepo.UpdatePaymentAmount(&proto.UpdatePaymentAmountRequest{
+		OrderId:    order.Id,
+		TotalPrice: pricing.Total,
+	}); err != nil && status.Code(err) != codes.NotFound {
+		return nil, err
+	} else if err == nil {
+		paymentUpdated = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	order, err = u.GetOrderByID(&proto.GetOrderRequest{OrderId: order.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.OrderResponse{
+		Order: order,
+	}, nil
+}
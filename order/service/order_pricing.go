@@ -0,0 +1,43 @@
+package service
+
+import (
+	"database/sql"
+	"order/proto"
+)
+
+// orderPricing is the breakdown of what an order costs.
+type orderPricing struct {
+	Subtotal      int64
+	DiscountTotal int64
+	TaxTotal      int64
+	ShippingCost  int64
+	Total         int64
+	Discounts     []*proto.OrderDiscount
+	Taxes         []*proto.OrderTax
+}
+
+// priceOrder applies the promotions and then the taxes to the items of an order, adds the shipping cost
+// of the order and stores the totals. The promotions are the ones valid at the given time, see
+// PromotionService.ApplyPromotions. Shipping is neither discounted nor taxed.
+func (u *OrderService) priceOrder(order *proto.Order, items []*proto.OrderItem, at string, strict bool, tx *sql.Tx) (*orderPricing, error) {
+	pricing, err := u.promotions.ApplyPromotions(order, items, at, strict, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	pricing.Taxes, err = u.taxes.ApplyTaxes(order, items, pricing, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range pricing.Taxes {
+		pricing.TaxTotal += v.Amount
+	}
+	pricing.ShippingCost = order.ShippingCost
+	pricing.Total = pricing.Subtotal - pricing.DiscountTotal + pricing.TaxTotal + pricing.ShippingCost
+
+	if err := u.orderRepo.UpdateOrderTotals(int(order.Id), pricing.Subtotal, pricing.DiscountTotal, pricing.TaxTotal, pricing.ShippingCost, pricing.Total, tx); err != nil {
+		return nil, err
+	}
+
+	return pricing, nil
+}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"order/proto"
+	"order/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	SagaStepReserveStock   = "reserve_stock"
+	SagaStepCreatePayment  = "create_payment"
+	SagaStepCapturePayment = "capture_payment"
+	SagaStepConfirmOrder   = "confirm_order"
+	SagaStepVoidPayment    = "void_payment"
+	SagaStepExpirePayment  = "expire_payment"
+	SagaStepReleaseStock   = "release_stock"
+	SagaStepFailOrder      = "fail_order"
+
+	SagaStatusRunning      = "running"
+	SagaStatusCompensating = "compensating"
+	SagaStatusCompleted    = "completed"
+	SagaStatusCompensated  = "compensated"
+)
+
+const (
+	sagaMaxAttempts  = 5
+	sagaBatchSize    = 20
+	sagaPollPeriod   = 5 * time.Second
+	sagaCapturePoll  = 30 * time.Second
+	sagaBaseBackoff  = 2 * time.Second
+	sagaMaxBackoff   = 5 * time.Minute
+	sagaStepsPerCall = 10
+)
+
+// errSagaWaiting means the current step depends on something outside the saga, e.g. the customer paying.
+var errSagaWaiting = errors.New("saga is waiting")
+
+// OrderSaga drives an order through reserve stock -> create payment -> capture -> confirm,
+// and through release stock/void payment/fail order when one of those steps cannot succeed.
+// Every step is persisted in order_sagas so a restarted service picks up where it stopped.
+type OrderSaga struct {
+	DB            *sql.DB
+	sagaRepo      repository.SagaRepository
+	orderRepo     repository.OrderRepository
+	productRepo   repository.ProductRepository
+	paymentRepo   repository.PaymentRepository
+	historyRepo   repository.OrderHistoryRepository
+	promotionRepo repository.PromotionRepository
+}
+
+func NewOrderSaga(DB *sql.DB, sagaRepo repository.SagaRepository, orderRepo repository.OrderRepository, productRepo repository.ProductRepository, paymentRepo repository.PaymentRepository, historyRepo repository.OrderHistoryRepository, promotionRepo repository.PromotionRepository) *OrderSaga {
+	return &OrderSaga{
+		DB:            DB,
+		sagaRepo:      sagaRepo,
+		orderRepo:     orderRepo,
+		productRepo:   productRepo,
+		paymentRepo:   paymentRepo,
+		historyRepo:   historyRepo,
+		promotionRepo: promotionRepo,
+	}
+}
+
+// Begin persists a new saga in the same transaction that creates the order.
+func (u *OrderSaga) Begin(orderID int, reservationID string, tx *sql.Tx) (int, error) {
+	return u.sagaRepo.CreateSaga(&repository.OrderSaga{
+		OrderID:       orderID,
+		ReservationID: reservationID,
+		Step:          SagaStepReserveStock,
+		Status:        SagaStatusRunning,
+	}, tx)
+}
+
+// Cancel switches the saga of an order to compensation, starting at compensateFrom (void or expire
+// the payment) and then releasing the stock. Orders created before sagas existed get a compensating saga of their own.
+func (u *OrderSaga) Cancel(orderID int, reason string, compensateFrom string, tx *sql.Tx) (int, error) {
+	saga, err := u.sagaRepo.GetSagaByOrderIDForUpdate(orderID, tx)
+	if err != nil {
+		if !errors.Is(err, repository.ErrSagaNotAvailable) {
+			return 0, err
+		}
+
+		reservationID, err := u.orderRepo.GetOrderReservationID(orderID, tx)
+		if err != nil {
+			return 0, err
+		}
+		return u.sagaRepo.CreateSaga(&repository.OrderSaga{
+			OrderID:       orderID,
+			ReservationID: reservationID,
+			Step:          compensateFrom,
+			Status:        SagaStatusCompensating,
+			LastError:     reason,
+		}, tx)
+	}
+
+	if saga.Status == SagaStatusCompensating || saga.Status == SagaStatusCompensated {
+		return saga.ID, nil
+	}
+
+	u.startCompensation(saga, reason)
+	saga.Step = compensateFrom
+	if err := u.sagaRepo.UpdateSaga(saga, 0, tx); err != nil {
+		return 0, err
+	}
+	return saga.ID, nil
+}
+
+// ActiveSagaID returns the saga of an order while it is still running or compensating.
+func (u *OrderSaga) ActiveSagaID(orderID int) (int, bool, error) {
+	saga, err := u.sagaRepo.GetSagaByOrderID(orderID, u.DB)
+	if err != nil {
+		if errors.Is(err, repository.ErrSagaNotAvailable) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	active := saga.Status == SagaStatusRunning || saga.Status == SagaStatusCompensating
+	return saga.ID, active, nil
+}
+
+// Run resumes every saga that is due, including the ones interrupted by a crash.
+func (u *OrderSaga) Run(ctx context.Context) {
+	logrus.Info("Starting order saga worker")
+	ticker := time.NewTicker(sagaPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Order saga worker stopping...")
+			return
+		case <-ticker.C:
+			sagaIDs, err := u.sagaRepo.GetDueSagaIDs(sagaBatchSize, u.DB)
+			if err != nil {
+				logrus.Errorf("failed to get due sagas: %v", err)
+				continue
+			}
+			for _, sagaID := range sagaIDs {
+				if err := u.Process(sagaID); err != nil {
+					logrus.Errorf("failed to process saga %d: %v", sagaID, err)
+				}
+			}
+		}
+	}
+}
+
+// Process executes saga steps until the saga has to wait, retry later or is finished.
+func (u *OrderSaga) Process(sagaID int) error {
+	for i := 0; i < sagaStepsPerCall; i++ {
+		done, err := u.step(sagaID)
+		if err != nil || done {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *OrderSaga) step(sagaID int) (bool, error) {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return true, err
+	}
+	defer tx.Rollback()
+
+	saga, err := u.sagaRepo.GetSagaForUpdate(sagaID, tx)
+	if err != nil {
+		if errors.Is(err, repository.ErrSagaNotAvailable) {
+			return true, nil
+		}
+		return true, err
+	}
+	if saga.Status == SagaStatusCompleted || saga.Status == SagaStatusCompensated {
+		return true, nil
+	}
+
+	var retryIn time.Duration
+	done := false
+
+	logrus.Infof("Saga %d for order %d executing step %s", saga.ID, saga.OrderID, saga.Step)
+	err = u.execute(saga, tx)
+	switch {
+	case errors.Is(err, errSagaWaiting):
+		retryIn = sagaCapturePoll
+		done = true
+	case err != nil:
+		logrus.Errorf("Saga %d step %s failed: %v", saga.ID, saga.Step, err)
+		saga.Attempts++
+		saga.LastError = err.Error()
+		if saga.Status == SagaStatusRunning && saga.Attempts >= sagaMaxAttempts {
+			u.startCompensation(saga, err.Error())
+		} else {
+			retryIn = sagaBackoff(saga.Attempts)
+			done = true
+		}
+	default:
+		saga.Attempts = 0
+	}
+
+	if saga.Status == SagaStatusCompleted || saga.Status == SagaStatusCompensated {
+		done = true
+	}
+
+	if err := u.sagaRepo.UpdateSaga(saga, retryIn, tx); err != nil {
+		return true, err
+	}
+	if err := tx.Commit(); err != nil {
+		return true, err
+	}
+
+	return done, nil
+}
+
+func (u *OrderSaga) execute(saga *repository.OrderSaga, tx *sql.Tx) error {
+	switch saga.Step {
+	case SagaStepReserveStock:
+		if _, err := u.productRepo.CommitReservation(&proto.ReservationRequest{
+			ReservationId: saga.ReservationID,
+			OrderId:       int32(saga.OrderID),
+		}); err != nil {
+			return err
+		}
+		saga.Step = SagaStepCreatePayment
+
+	case SagaStepCreatePayment:
+		// Waits for an item edit in progress so the payment is created with the final total
+		if _, err := u.orderRepo.GetOrderStatusForUpdate(saga.OrderID, tx); err != nil {
+			return err
+		}
+		order, err := u.orderRepo.GetOrderByID(&proto.GetOrderRequest{OrderId: int32(saga.OrderID)}, u.DB)
+		if err != nil {
+			return err
+		}
+		payment, err := u.paymentRepo.PayOrder(&proto.CreatePaymentRequest{
+			UserId:       order.UserId,
+			OrderId:      order.Id,
+			TotalPrice:   order.TotalPrice,
+			Currency:     order.Currency,
+			BaseCurrency: order.BaseCurrency,
+			ExchangeRate: order.ExchangeRate,
+		})
+		if err != nil {
+			return err
+		}
+		saga.PaymentID = int(payment.Id)
+		saga.Step = SagaStepCapturePayment
+
+	case SagaStepCapturePayment:
+		payment, err := u.paymentRepo.GetPayment(&proto.GetPaymentRequest{PaymentId: int32(saga.PaymentID)})
+		if err != nil {
+			return err
+		}
+		switch payment.Status {
+		case "paid":
+			saga.Step = SagaStepConfirmOrder
+		case "pending":
+			return errSagaWaiting
+		default:
+			u.startCompensation(saga, "payment is "+payment.Status)
+		}
+
+	case SagaStepConfirmOrder:
+		if err := transitionOrderStatus(u.orderRepo, u.historyRepo, &proto.OrderStatusChange{
+			OrderId:   int32(saga.OrderID),
+			NewStatus: OrderStatusPaid,
+			Actor:     actorOrderSaga,
+			Reason:    "payment captured",
+		}, tx); err != nil {
+			return err
+		}
+		saga.Status = SagaStatusCompleted
+
+	case SagaStepVoidPayment:
+		request := &proto.GetPaymentRequest{PaymentId: int32(saga.PaymentID), OrderId: int32(saga.OrderID)}
+		if _, err := u.paymentRepo.VoidPayment(request); err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		saga.Step = SagaStepReleaseStock
+
+	case SagaStepExpirePayment:
+		request := &proto.GetPaymentRequest{PaymentId: int32(saga.PaymentID), OrderId: int32(saga.OrderID)}
+		if _, err := u.paymentRepo.ExpirePayment(request); err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		saga.Step = SagaStepReleaseStock
+
+	case SagaStepReleaseStock:
+		if saga.ReservationID == "" {
+			logrus.Warnf("Saga %d for order %d has no reservation, stock is not restored", saga.ID, saga.OrderID)
+		} else if _, err := u.productRepo.ReleaseReservation(&proto.ReservationRequest{ReservationId: saga.ReservationID}); err != nil {
+			return err
+		}
+		saga.Step = SagaStepFailOrder
+
+	case SagaStepFailOrder:
+		// A cancelled order already has its final status
+		current, err := u.orderRepo.GetOrderStatusForUpdate(saga.OrderID, tx)
+		if err != nil {
+			return err
+		}
+		if current != OrderStatusCancelled {
+			if err := transitionOrderStatus(u.orderRepo, u.historyRepo, &proto.OrderStatusChange{
+				OrderId:   int32(saga.OrderID),
+				NewStatus: OrderStatusFailed,
+				Actor:     actorOrderSaga,
+				Reason:    saga.LastError,
+			}, tx); err != nil {
+				return err
+			}
+		}
+		// The promotion uses of an order that will not be paid go back to the promotions
+		if err := u.promotionRepo.ReleaseOrderRedemptions(saga.OrderID, tx); err != nil {
+			return err
+		}
+		saga.Status = SagaStatusCompensated
+
+	default:
+		return errors.New("unknown saga step " + saga.Step)
+	}
+
+	return nil
+}
+
+func (u *OrderSaga) startCompensation(saga *repository.OrderSaga, reason string) {
+	logrus.Warnf("Saga %d for order %d compensating: %s", saga.ID, saga.OrderID, reason)
+	saga.Status = SagaStatusCompensating
+	saga.Step = SagaStepVoidPayment
+	saga.Attempts = 0
+	saga.LastError = reason
+}
+
+func sagaBackoff(attempts int) time.Duration {
+	backoff := sagaBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= sagaMaxBackoff {
+			return sagaMaxBackoff
+		}
+	}
+	return backoff
+}
@@ -1,153 +1,657 @@
-package service
-
-import (
-	"database/sql"
-	"errors"
-	"order/helper"
-	"order/proto"
-	"order/repository"
-	"order/transport/kafka"
-	"os"
-	"time"
-
-	"github.com/sirupsen/logrus"
-)
-
-type OrderService struct {
-	DB            *sql.DB
-	orderRepo     repository.OrderRepository
-	orderItemRepo repository.OrderItemsRepository
-	productRepo   repository.ProductRepository
-}
-
-func NewOrderItemService(DB *sql.DB, orderRepo repository.OrderRepository, orderItemRepo repository.OrderItemsRepository, productRepo repository.ProductRepository) *OrderService {
-	return &OrderService{
-		DB:            DB,
-		orderRepo:     orderRepo,
-		orderItemRepo: orderItemRepo,
-		productRepo:   productRepo,
-	}
-}
-
-func (u *OrderService) CreateOrder(payload *proto.CreateOrderRequest) (*proto.OrderResponse, error) {
-	var totalPrices float64
-
-	addr := []string{os.Getenv("KAFKA_BROKER_URL")}
-	topic := os.Getenv("KAFKA_ORDER_TOPIC")
-
-	var products []*proto.Product
-
-	logrus.Info("Calculating total price")
-	for _, v := range payload.Items {
-		product, err := u.productRepo.GetProduct(&proto.GetProductRequest{Id: v.ProductId})
-		if err != nil {
-			return nil, err
-		}
-		products = append(products, product)
-
-		if product.Stock < v.Quantity {
-			return nil, errors.New("stock is not enough")
-		}
-		totalPrice := float64(v.Quantity) * product.Price
-		totalPrices += totalPrice
-	}
-	payload.TotalPrice = totalPrices
-
-	tx, err := u.DB.Begin()
-	if err != nil {
-		return nil, err
-	}
-
-	rollback := true
-	defer func() {
-		if rollback {
-			if rErr := tx.Rollback(); rErr != nil {
-				logrus.Errorf("Rollback error: %v", rErr)
-			}
-		}
-	}()
-
-	logrus.Info("Create order")
-	orderID, err := u.orderRepo.CreateOrder(payload, payload.TotalPrice, tx)
-	if err != nil {
-		return nil, err
-	}
-
-	logrus.Info("Saving order items")
-	for _, v := range payload.Items {
-		orderItemPayload := &proto.OrderItemRequest{
-			OrderId:   int32(orderID),
-			ProductId: v.ProductId,
-			Quantity:  v.Quantity,
-		}
-		err := u.orderItemRepo.CreateOrderItems(orderItemPayload, tx)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	logrus.Info("Updating products")
-	for i, v := range payload.Items {
-		if _, err := u.productRepo.UpdateProduct(&proto.Product{
-			Id:          products[i].Id,
-			Name:        products[i].Name,
-			Description: products[i].Description,
-			Price:       products[i].Price,
-			Stock:       products[i].Stock - v.Quantity,
-			CreatedAt:   products[i].CreatedAt,
-			UpdatedAt:   products[i].UpdatedAt,
-		}); err != nil {
-			return nil, err
-		}
-	}
-
-	// Commit transaction sebelum publish Kafka
-	logrus.Info("Committing transaction")
-	if err := tx.Commit(); err != nil {
-		return nil, err
-	}
-	rollback = false
-
-	logrus.Info("Sending message to kafka")
-	partition, offset, err := kafka.SendMessage(addr, topic, &proto.Order{
-		Id:         int32(orderID),
-		UserId:     payload.UserId,
-		Status:     "Pending",
-		TotalPrice: payload.TotalPrice,
-	})
-	if err != nil {
-		logrus.Errorf("Failed to send message to Kafka: %v", err)
-	} else {
-		logrus.Infof("Message sent to topic: %s partition: %d, offset: %d", topic, partition, offset)
-	}
-
-	return &proto.OrderResponse{
-		Order: &proto.Order{
-			Id:         int32(orderID),
-			TotalPrice: payload.TotalPrice,
-			CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
-			UpdatedAt:  time.Now().Format("2006-01-02 15:04:05"),
-		},
-	}, nil
-}
-
-func (u *OrderService) GetOrderByID(payload *proto.GetOrderRequest) (*proto.Order, error) {
-	orderResponse, err := u.orderRepo.GetOrderByID(payload, u.DB)
-	if err != nil {
-		return nil, err
-	}
-	return orderResponse, nil
-}
-
-func (u *OrderService) UpdateOrderStatus(status string, orderID int) error {
-	tx, err := u.DB.Begin()
-	if err != nil {
-		return err
-	}
-	defer helper.CommitOrRollback(tx)
-
-	if err := u.orderRepo.UpdateOrderStatus(status, orderID, tx); err != nil {
-		return err
-	}
-	return nil
-}
+package service
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"order/proto"
+	"order/repository"
+	"order/transport/kafka"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+type OrderService struct {
+	DB              *sql.DB
+	orderRepo       repository.OrderRepository
+	orderItemRepo   repository.OrderItemsRepository
+	productRepo     repository.ProductRepository
+	paymentRepo     repository.PaymentRepository
+	outboxRepo      repository.OutboxRepository
+	idempotencyRepo repository.IdempotencyRepository
+	historyRepo     repository.OrderHistoryRepository
+	shipmentRepo    repository.ShipmentRepository
+	promotions      *PromotionService
+	taxes           *TaxService
+	shipping        *ShippingService
+	exchangeRates   *ExchangeRateService
+	saga            *OrderSaga
+}
+
+func NewOrderItemService(DB *sql.DB, orderRepo repository.OrderRepository, orderItemRepo repository.OrderItemsRepository, productRepo repository.ProductRepository, paymentRepo repository.PaymentRepository, outboxRepo repository.OutboxRepository, idempotencyRepo repository.IdempotencyRepository, historyRepo repository.OrderHistoryRepository, shipmentRepo repository.ShipmentRepository, promotions *PromotionService, taxes *TaxService, shipping *ShippingService, exchangeRates *ExchangeRateService, saga *OrderSaga) *OrderService {
+	return &OrderService{
+		DB:              DB,
+		orderRepo:       orderRepo,
+		orderItemRepo:   orderItemRepo,
+		productRepo:     productRepo,
+		paymentRepo:     paymentRepo,
+		outboxRepo:      outboxRepo,
+		idempotencyRepo: idempotencyRepo,
+		historyRepo:     historyRepo,
+		shipmentRepo:    shipmentRepo,
+		promotions:      promotions,
+		taxes:           taxes,
+		shipping:        shipping,
+		exchangeRates:   exchangeRates,
+		saga:            saga,
+	}
+}
+
+func (u *OrderService) CreateOrder(payload *proto.CreateOrderRequest) (*proto.OrderResponse, error) {
+	var totalPrices int64
+
+	topic := os.Getenv("KAFKA_ORDER_TOPIC")
+	payload.CouponCode = strings.TrimSpace(payload.CouponCode)
+	payload.Region = strings.ToUpper(strings.TrimSpace(payload.Region))
+
+	var err error
+	if payload.Currency, err = normalizeCurrency(payload.Currency); err != nil {
+		return nil, err
+	}
+
+	if payload.ShippingAddress != nil {
+		if err := normalizeShippingAddress(payload.ShippingAddress); err != nil {
+			return nil, err
+		}
+		if payload.ShippingCarrier == "" || payload.ShippingService == "" {
+			return nil, status.Error(codes.InvalidArgument, "shipping carrier and service are required with a shipping address")
+		}
+		// Goods are taxed where they are delivered
+		if payload.Region == "" {
+			payload.Region = shippingZone(payload.ShippingAddress)
+		}
+	} else if payload.ShippingCarrier != "" || payload.ShippingService != "" {
+		return nil, status.Error(codes.InvalidArgument, "shipping address is required")
+	}
+
+	// A retried request with the same key gets the order that was created the first time
+	var requestHash string
+	if payload.IdempotencyKey != "" {
+		if len(payload.IdempotencyKey) > maxIdempotencyKeyLength {
+			return nil, status.Error(codes.InvalidArgument, "idempotency key is too long")
+		}
+
+		hash, err := createOrderHash(payload)
+		if err != nil {
+			return nil, err
+		}
+		requestHash = hash
+
+		response, found, err := u.replayCreateOrder(payload.UserId, payload.IdempotencyKey, requestHash)
+		if found || err != nil {
+			if err == nil {
+				logrus.Infof("Replaying order %d for idempotency key %s", response.Order.Id, payload.IdempotencyKey)
+			}
+			return response, err
+		}
+	}
+
+	logrus.Info("Reserving stock")
+	reservationItems := make([]*proto.ReservationItem, 0, len(payload.Items))
+	for _, v := range payload.Items {
+		reservationItems = append(reservationItems, &proto.ReservationItem{
+			ProductId: v.ProductId,
+			Quantity:  v.Quantity,
+		})
+	}
+	reservation, err := u.productRepo.ReserveStock(&proto.ReserveStockRequest{Items: reservationItems})
+	if err != nil {
+		return nil, err
+	}
+
+	rollback := true
+	defer func() {
+		if rollback {
+			logrus.Infof("Releasing reservation %s", reservation.ReservationId)
+			if _, err := u.productRepo.ReleaseReservation(&proto.ReservationRequest{ReservationId: reservation.ReservationId}); err != nil {
+				logrus.Errorf("Release reservation error: %v", err)
+			}
+		}
+	}()
+
+	// Products are priced in their base currency, an order paid in another currency converts the prices
+	// at the current rate and keeps that rate
+	var baseCurrency string
+	for _, v := range reservation.Items {
+		if baseCurrency == "" {
+			baseCurrency = v.Currency
+		}
+		if v.Currency != baseCurrency {
+			return nil, status.Error(codes.InvalidArgument, "products priced in different currencies cannot be ordered together")
+		}
+	}
+	if payload.Currency == "" {
+		payload.Currency = baseCurrency
+	}
+	exchangeRate, err := u.exchangeRates.Rate(baseCurrency, payload.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Info("Calculating total price")
+	for _, v := range reservation.Items {
+		v.Price = convertAmount(v.Price, exchangeRate.Rate, baseCurrency, payload.Currency)
+		totalPrices += int64(v.Quantity) * v.Price
+	}
+	payload.TotalPrice = totalPrices
+
+	// The rate is quoted again for the reserved items, whatever the client was shown before
+	var shipping *proto.OrderShipping
+	var shippingCost int64
+	if payload.ShippingAddress != nil {
+		var weightGrams int32
+		for _, v := range reservation.Items {
+			weightGrams += v.Quantity * v.WeightGrams
+		}
+		quote, err := u.shipping.Quote(payload.ShippingAddress, weightGrams, payload.Currency, payload.ShippingCarrier, payload.ShippingService)
+		if err != nil {
+			return nil, err
+		}
+		shipping = &proto.OrderShipping{
+			Address:       payload.ShippingAddress,
+			Carrier:       quote.Carrier,
+			Service:       quote.Service,
+			EstimatedDays: quote.EstimatedDays,
+			WeightGrams:   weightGrams,
+		}
+		shippingCost = quote.Cost
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if rollback {
+			if rErr := tx.Rollback(); rErr != nil {
+				logrus.Errorf("Rollback error: %v", rErr)
+			}
+		}
+	}()
+
+	logrus.Info("Create order")
+	orderID, err := u.orderRepo.CreateOrder(payload, payload.TotalPrice, exchangeRate, OrderStatusPending, reservation.ReservationId, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.historyRepo.CreateStatusChange(&proto.OrderStatusChange{
+		OrderId:   int32(orderID),
+		NewStatus: OrderStatusPending,
+		Actor:     userActor(payload.UserId),
+		Reason:    "order created",
+	}, tx); err != nil {
+		return nil, err
+	}
+
+	if shipping != nil {
+		if err := u.shipping.CreateOrderShipping(orderID, shipping, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Price and name come from the reservation so the order keeps them even if the product changes later
+	logrus.Info("Saving order items")
+	orderItems := make([]*proto.OrderItem, 0, len(reservation.Items))
+	for _, v := range reservation.Items {
+		orderItem := &proto.OrderItem{
+			OrderId:     int32(orderID),
+			ProductId:   v.ProductId,
+			Quantity:    v.Quantity,
+			Price:       v.Price,
+			ProductName: v.Name,
+			Category:    v.Category,
+			TaxClass:    v.TaxClass,
+			WeightGrams: v.WeightGrams,
+		}
+		if err := u.orderItemRepo.CreateOrderItems(orderItem, tx); err != nil {
+			return nil, err
+		}
+		orderItems = append(orderItems, orderItem)
+	}
+
+	logrus.Info("Applying promotions, taxes and shipping")
+	pricing, err := u.priceOrder(&proto.Order{
+		Id:           int32(orderID),
+		UserId:       payload.UserId,
+		CouponCode:   payload.CouponCode,
+		Region:       payload.Region,
+		ShippingCost: shippingCost,
+		Currency:     payload.Currency,
+	}, orderItems, "", true, tx)
+	if err != nil {
+		return nil, err
+	}
+	payload.TotalPrice = pricing.Total
+
+	// The event is committed with the order; OutboxRelay publishes it to Kafka
+	logrus.Info("Saving order created event to outbox")
+	event, err := kafka.MarshalEvent(kafka.EventOrderCreated, kafka.OrderEventVersion, orderID, &proto.Order{
+		Id:           int32(orderID),
+		UserId:       payload.UserId,
+		Status:       OrderStatusPending,
+		TotalPrice:   payload.TotalPrice,
+		Currency:     payload.Currency,
+		BaseCurrency: baseCurrency,
+		ExchangeRate: exchangeRate.Rate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.outboxRepo.CreateEvent(&repository.OutboxEvent{
+		AggregateID: orderID,
+		Topic:       topic,
+		Payload:     event,
+	}, tx); err != nil {
+		return nil, err
+	}
+
+	logrus.Info("Starting order saga")
+	sagaID, err := u.saga.Begin(orderID, reservation.ReservationId, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	orderResponse := &proto.OrderResponse{
+		Order: &proto.Order{
+			Id:            int32(orderID),
+			UserId:        payload.UserId,
+			Status:        OrderStatusPending,
+			Subtotal:      pricing.Subtotal,
+			DiscountTotal: pricing.DiscountTotal,
+			TaxTotal:      pricing.TaxTotal,
+			ShippingCost:  pricing.ShippingCost,
+			TotalPrice:    pricing.Total,
+			Currency:      payload.Currency,
+			BaseCurrency:  baseCurrency,
+			ExchangeRate:  exchangeRate.Rate,
+			CouponCode:    payload.CouponCode,
+			Region:        payload.Region,
+			CreatedAt:     time.Now().Format("2006-01-02 15:04:05"),
+			UpdatedAt:     time.Now().Format("2006-01-02 15:04:05"),
+			OrderItems:    orderItems,
+			Discounts:     pricing.Discounts,
+			Taxes:         pricing.Taxes,
+			Shipping:      shipping,
+		},
+	}
+
+	if payload.IdempotencyKey != "" {
+		response, err := protobuf.Marshal(orderResponse)
+		if err != nil {
+			return nil, err
+		}
+		created, err := u.idempotencyRepo.CreateIdempotencyKey(&repository.IdempotencyRecord{
+			UserID:      payload.UserId,
+			Key:         payload.IdempotencyKey,
+			RequestHash: requestHash,
+			Response:    response,
+		}, tx)
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			// A concurrent request with the same key won, this order is rolled back
+			logrus.Infof("Idempotency key %s was used concurrently, discarding order %d", payload.IdempotencyKey, orderID)
+			response, found, err := u.replayCreateOrder(payload.UserId, payload.IdempotencyKey, requestHash)
+			if err == nil && !found {
+				err = status.Error(codes.Aborted, "idempotency key is being used by another request")
+			}
+			return response, err
+		}
+	}
+
+	logrus.Info("Committing transaction")
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	rollback = false
+
+	go func() {
+		if err := u.saga.Process(sagaID); err != nil {
+			logrus.Errorf("Failed to process saga %d for order %d: %v", sagaID, orderID, err)
+		}
+	}()
+
+	return orderResponse, nil
+}
+
+func (u *OrderService) GetOrderByID(payload *proto.GetOrderRequest) (*proto.Order, error) {
+	orderResponse, err := u.orderRepo.GetOrderByID(payload, u.DB)
+	if err != nil {
+		return nil, err
+	}
+	if payload.UserId != 0 && orderResponse.UserId != payload.UserId {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+
+	orderItems, err := u.orderItemRepo.GetOrderItems(int(orderResponse.Id), u.DB)
+	if err != nil {
+		return nil, err
+	}
+	orderResponse.OrderItems = orderItems
+
+	discounts, err := u.promotions.GetOrderDiscounts(int(orderResponse.Id))
+	if err != nil {
+		return nil, err
+	}
+	orderResponse.Discounts = discounts
+
+	if err := u.taxes.AttachTaxes(orderResponse); err != nil {
+		return nil, err
+	}
+
+	if err := u.shipping.AttachShipping(orderResponse); err != nil {
+		return nil, err
+	}
+
+	return orderResponse, nil
+}
+
+func (u *OrderService) ListOrders(payload *proto.ListOrdersRequest) (*proto.ListOrdersResponse, error) {
+	filter := &repository.OrderListFilter{
+		UserID:   int(payload.UserId),
+		MinTotal: payload.MinTotal,
+		MaxTotal: payload.MaxTotal,
+		Limit:    int(payload.Limit),
+	}
+
+	if payload.Status != proto.OrderStatus_ORDER_STATUS_UNSPECIFIED {
+		name, err := orderStatusName(payload.Status)
+		if err != nil {
+			return nil, err
+		}
+		filter.Status = name
+	}
+
+	switch payload.SortBy {
+	case "", "created_at":
+		filter.SortBy = "created_at"
+	case "total_price":
+		filter.SortBy = "total_price"
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid sort_by %q", payload.SortBy)
+	}
+
+	switch strings.ToLower(payload.SortOrder) {
+	case "", "desc":
+		filter.Descending = true
+	case "asc":
+		filter.Descending = false
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid sort_order %q", payload.SortOrder)
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseListDate(payload.CreatedFrom, false); err != nil {
+		return nil, err
+	}
+	if filter.CreatedTo, err = parseListDate(payload.CreatedTo, true); err != nil {
+		return nil, err
+	}
+
+	if payload.Cursor != "" {
+		cursor, err := decodeOrderCursor(payload.Cursor)
+		if err != nil || cursor.SortBy != filter.SortBy || cursor.Descending != filter.Descending {
+			return nil, status.Error(codes.InvalidArgument, "invalid cursor")
+		}
+		filter.AfterValue = cursor.Value
+		filter.AfterID = cursor.ID
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	pageSize := filter.Limit
+	filter.Limit++
+	orders, err := u.orderRepo.ListOrders(filter, u.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &proto.ListOrdersResponse{}
+	if len(orders) > pageSize {
+		orders = orders[:pageSize]
+		last := orders[len(orders)-1]
+		value := last.CreatedAt
+		if filter.SortBy == "total_price" {
+			value = strconv.FormatInt(last.TotalPrice, 10)
+		}
+		response.NextCursor = encodeOrderCursor(&orderCursor{
+			SortBy:     filter.SortBy,
+			Descending: filter.Descending,
+			Value:      value,
+			ID:         int(last.Id),
+		})
+	}
+	response.Orders = orders
+
+	return response, nil
+}
+
+// CancelOrder marks an order that is not shipped yet as cancelled, the saga then voids or
+// refunds its payment and releases its stock.
+func (u *OrderService) CancelOrder(payload *proto.CancelOrderRequest) (*proto.Order, error) {
+	order, err := u.orderRepo.GetOrderByID(&proto.GetOrderRequest{OrderId: payload.OrderId}, u.DB)
+	if err != nil {
+		return nil, err
+	}
+	if payload.UserId != 0 && order.UserId != payload.UserId {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+
+	reason := payload.Reason
+	if reason == "" {
+		reason = "cancelled by customer"
+	}
+
+	logrus.Infof("Cancelling order %d", order.Id)
+	actor := actorSystem
+	if payload.UserId != 0 {
+		actor = userActor(payload.UserId)
+	}
+	if err := u.cancel(order, actor, reason, SagaStepVoidPayment, kafka.EventOrderCancelled, orderCancelledTopic()); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// cancel moves the order to cancelled together with its saga and an eventType event for topic,
+// the saga compensates from compensateFrom once the transaction is committed.
+func (u *OrderService) cancel(order *proto.Order, actor string, reason string, compensateFrom string, eventType string, topic string) error {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sagaID, err := u.saga.Cancel(int(order.Id), reason, compensateFrom, tx)
+	if err != nil {
+		return err
+	}
+
+	if err := transitionOrderStatus(u.orderRepo, u.historyRepo, &proto.OrderStatusChange{
+		OrderId:   order.Id,
+		NewStatus: OrderStatusCancelled,
+		Actor:     actor,
+		Reason:    reason,
+	}, tx); err != nil {
+		return err
+	}
+	// The transition locked the order, so no shipment can be created until this transaction ends
+	shipped, err := u.shipmentRepo.HasActiveShipments(int(order.Id), tx)
+	if err != nil {
+		return err
+	}
+	if shipped {
+		return status.Errorf(codes.FailedPrecondition, "order %d has shipments and cannot be cancelled", order.Id)
+	}
+	if err := u.promotions.ReleaseRedemptions(int(order.Id), tx); err != nil {
+		return err
+	}
+	order.Status = OrderStatusCancelled
+
+	event, err := kafka.MarshalEvent(eventType, kafka.OrderEventVersion, int(order.Id), order)
+	if err != nil {
+		return err
+	}
+	if err := u.outboxRepo.CreateEvent(&repository.OutboxEvent{
+		AggregateID: int(order.Id),
+		Topic:       topic,
+		Payload:     event,
+	}, tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := u.saga.Process(sagaID); err != nil {
+			logrus.Errorf("Failed to process saga %d for order %d: %v", sagaID, order.Id, err)
+		}
+	}()
+
+	return nil
+}
+
+func (u *OrderService) UpdateOrderStatus(payload *proto.UpdateOrderStatusRequest) error {
+	to, err := orderStatusName(payload.Status)
+	if err != nil {
+		return err
+	}
+	orderID := int(payload.OrderId)
+
+	// Orders with an active saga only become paid or failed through the saga, which checks the payment itself
+	sagaID, active, err := u.saga.ActiveSagaID(orderID)
+	if err != nil {
+		return err
+	}
+	if active {
+		if to != OrderStatusPaid && to != OrderStatusFailed {
+			return status.Errorf(codes.FailedPrecondition, "order %d is still being processed", orderID)
+		}
+		logrus.Infof("Order %d is handled by saga %d, resuming saga", orderID, sagaID)
+		return u.saga.Process(sagaID)
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	actor := payload.Actor
+	if actor == "" {
+		actor = actorSystem
+	}
+	if err := transitionOrderStatus(u.orderRepo, u.historyRepo, &proto.OrderStatusChange{
+		OrderId:   payload.OrderId,
+		NewStatus: to,
+		Actor:     actor,
+		Reason:    payload.Reason,
+	}, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetOrderHistory returns every status change of an order, oldest first.
+func (u *OrderService) GetOrderHistory(payload *proto.GetOrderHistoryRequest) ([]*proto.OrderStatusChange, error) {
+	order, err := u.orderRepo.GetOrderByID(&proto.GetOrderRequest{OrderId: payload.OrderId}, u.DB)
+	if err != nil {
+		return nil, err
+	}
+	if payload.UserId != 0 && order.UserId != payload.UserId {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+
+	return u.historyRepo.GetOrderHistory(int(order.Id), u.DB)
+}
+
+func orderCancelledTopic() string {
+	if topic := os.Getenv("KAFKA_ORDER_CANCELLED_TOPIC"); topic != "" {
+		return topic
+	}
+	return "order.cancelled"
+}
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type orderCursor struct {
+	SortBy     string `json:"sort_by"`
+	Descending bool   `json:"desc"`
+	Value      string `json:"value"`
+	ID         int    `json:"id"`
+}
+
+func encodeOrderCursor(cursor *orderCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeOrderCursor(value string) (*orderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &orderCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID <= 0 {
+		return nil, errors.New("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// parseListDate accepts a date or an RFC3339 timestamp, a date used as upper bound includes the whole day.
+func parseListDate(value string, endOfDay bool) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			date = date.AddDate(0, 0, 1)
+		}
+		return date.Format("2006-01-02 15:04:05"), nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "invalid date %q", value)
+	}
+	return timestamp.In(time.FixedZone("WIB", 7*60*60)).Format("2006-01-02 15:04:05"), nil
+}
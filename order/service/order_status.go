@@ -0,0 +1,90 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"order/proto"
+	"order/repository"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Actors recorded in the order status history for changes the order service makes on its own.
+const (
+	actorSystem      = "system"
+	actorOrderSaga   = "order-saga"
+	actorOrderExpiry = "order-expiry"
+	actorFulfillment = "fulfillment"
+)
+
+// Status values stored in orders.status and returned in proto.Order.
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusFulfilled = "fulfilled"
+	OrderStatusCompleted = "completed"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusFailed    = "failed"
+)
+
+var orderStatusNames = map[proto.OrderStatus]string{
+	proto.OrderStatus_ORDER_STATUS_PENDING:   OrderStatusPending,
+	proto.OrderStatus_ORDER_STATUS_PAID:      OrderStatusPaid,
+	proto.OrderStatus_ORDER_STATUS_FULFILLED: OrderStatusFulfilled,
+	proto.OrderStatus_ORDER_STATUS_COMPLETED: OrderStatusCompleted,
+	proto.OrderStatus_ORDER_STATUS_CANCELLED: OrderStatusCancelled,
+	proto.OrderStatus_ORDER_STATUS_FAILED:    OrderStatusFailed,
+}
+
+// orderStatusTransitions lists every status an order may move to from its current status.
+var orderStatusTransitions = map[string][]string{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled, OrderStatusFailed},
+	OrderStatusPaid:      {OrderStatusFulfilled, OrderStatusCancelled},
+	OrderStatusFulfilled: {OrderStatusCompleted},
+	OrderStatusCompleted: {},
+	OrderStatusCancelled: {},
+	OrderStatusFailed:    {},
+}
+
+func orderStatusName(orderStatus proto.OrderStatus) (string, error) {
+	name, ok := orderStatusNames[orderStatus]
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "unknown order status %s", orderStatus)
+	}
+	return name, nil
+}
+
+func canTransition(from string, to string) bool {
+	for _, next := range orderStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionOrderStatus locks the order and moves it to change.NewStatus if the transition table allows it,
+// the change is recorded in the order status history with its actor and reason.
+func transitionOrderStatus(orderRepo repository.OrderRepository, historyRepo repository.OrderHistoryRepository, change *proto.OrderStatusChange, tx *sql.Tx) error {
+	orderID := int(change.OrderId)
+	from, err := orderRepo.GetOrderStatusForUpdate(orderID, tx)
+	if err != nil {
+		return err
+	}
+
+	if !canTransition(from, change.NewStatus) {
+		return status.Error(codes.FailedPrecondition, fmt.Sprintf("order %d cannot change status from %s to %s", orderID, from, change.NewStatus))
+	}
+
+	if err := orderRepo.UpdateOrderStatus(change.NewStatus, orderID, tx); err != nil {
+		return err
+	}
+
+	change.OldStatus = from
+	return historyRepo.CreateStatusChange(change, tx)
+}
+
+func userActor(userID int32) string {
+	return fmt.Sprintf("user:%d", userID)
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"order/proto"
+	"order/repository"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	orderWatchPollPeriod = time.Second
+	orderWatchBatchSize  = 500
+	orderWatchBuffer     = 16
+	// orderWatchLookback IDs are read again on every poll, so a change whose transaction commits after
+	// a change with a higher ID is still passed on
+	orderWatchLookback = 100
+)
+
+// OrderWatcher tails order_status_history and passes new status changes to the clients watching the
+// order. Reading the table sees the changes of every instance of the service, the saga and the
+// expiry worker alike.
+type OrderWatcher struct {
+	DB          *sql.DB
+	orderRepo   repository.OrderRepository
+	historyRepo repository.OrderHistoryRepository
+
+	mu       sync.Mutex
+	watchers map[int32]map[chan *proto.OrderStatusChange]bool
+	started  bool
+	lastID   int
+	seen     map[int32]bool
+}
+
+func NewOrderWatcher(DB *sql.DB, orderRepo repository.OrderRepository, historyRepo repository.OrderHistoryRepository) *OrderWatcher {
+	return &OrderWatcher{
+		DB:          DB,
+		orderRepo:   orderRepo,
+		historyRepo: historyRepo,
+		watchers:    make(map[int32]map[chan *proto.OrderStatusChange]bool),
+		seen:        make(map[int32]bool),
+	}
+}
+
+func (u *OrderWatcher) Run(ctx context.Context) {
+	logrus.Info("Starting order watcher")
+	ticker := time.NewTicker(orderWatchPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Order watcher stopping...")
+			return
+		case <-ticker.C:
+			if err := u.poll(); err != nil {
+				logrus.Errorf("failed to read order status changes: %v", err)
+			}
+		}
+	}
+}
+
+// WatchOrder sends the status history of the order and then every new change until the order reaches
+// a final status or the client goes away. A client that does not keep up gets ResourceExhausted and can
+// watch again, the history it gets then covers what it missed.
+func (u *OrderWatcher) WatchOrder(ctx context.Context, payload *proto.WatchOrderRequest, send func(*proto.OrderStatusChange) error) error {
+	order, err := u.orderRepo.GetOrderByID(&proto.GetOrderRequest{OrderId: payload.OrderId}, u.DB)
+	if err != nil {
+		return err
+	}
+	if payload.UserId != 0 && order.UserId != payload.UserId {
+		return status.Error(codes.NotFound, "order not found")
+	}
+
+	// Subscribing before reading the history, a change is then either in the history or received
+	changes, unsubscribe := u.subscribe(order.Id)
+	defer unsubscribe()
+
+	history, err := u.historyRepo.GetOrderHistory(int(order.Id), u.DB)
+	if err != nil {
+		return err
+	}
+
+	// Orders placed before the history was kept start with their current status
+	if len(history) == 0 {
+		history = append(history, &proto.OrderStatusChange{
+			OrderId:   order.Id,
+			NewStatus: order.Status,
+			CreatedAt: order.UpdatedAt,
+		})
+	}
+
+	orderStatus := order.Status
+	sent := make(map[int32]bool)
+	for _, change := range history {
+		if err := send(change); err != nil {
+			return err
+		}
+		sent[change.Id] = true
+		orderStatus = change.NewStatus
+	}
+
+	for len(orderStatusTransitions[orderStatus]) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case change, ok := <-changes:
+			if !ok {
+				return status.Errorf(codes.ResourceExhausted, "too many status changes of order %d were not read", order.Id)
+			}
+			if sent[change.Id] {
+				continue
+			}
+			if err := send(change); err != nil {
+				return err
+			}
+			sent[change.Id] = true
+			orderStatus = change.NewStatus
+		}
+	}
+	return nil
+}
+
+func (u *OrderWatcher) subscribe(orderID int32) (<-chan *proto.OrderStatusChange, func()) {
+	changes := make(chan *proto.OrderStatusChange, orderWatchBuffer)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.watchers[orderID] == nil {
+		u.watchers[orderID] = make(map[chan *proto.OrderStatusChange]bool)
+	}
+	u.watchers[orderID][changes] = true
+
+	return changes, func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		u.unsubscribe(orderID, changes)
+	}
+}
+
+// unsubscribe must be called with the lock held.
+func (u *OrderWatcher) unsubscribe(orderID int32, changes chan *proto.OrderStatusChange) {
+	if !u.watchers[orderID][changes] {
+		return
+	}
+	delete(u.watchers[orderID], changes)
+	if len(u.watchers[orderID]) == 0 {
+		delete(u.watchers, orderID)
+	}
+	close(changes)
+}
+
+func (u *OrderWatcher) poll() error {
+	// Tailing starts at the last change when the service starts, clients get older ones as history
+	if !u.started {
+		lastID, err := u.historyRepo.GetLastStatusChangeID(u.DB)
+		if err != nil {
+			return err
+		}
+		u.lastID, u.started = lastID, true
+	}
+
+	changes, err := u.historyRepo.GetStatusChangesAfter(max(u.lastID-orderWatchLookback, 0), orderWatchBatchSize, u.DB)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, change := range changes {
+		if u.seen[change.Id] {
+			continue
+		}
+		u.seen[change.Id] = true
+		u.lastID = max(u.lastID, int(change.Id))
+
+		for watcher := range u.watchers[change.OrderId] {
+			select {
+			case watcher <- change:
+			default:
+				// A client that does not keep up is dropped rather than holding back the others
+				u.unsubscribe(change.OrderId, watcher)
+			}
+		}
+	}
+
+	for id := range u.seen {
+		if int(id) <= u.lastID-orderWatchLookback {
+			delete(u.seen, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"errors"
+	"order/proto"
+	"order/repository"
+	"order/transport/kafka"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const actorPaymentService = "payment-service"
+
+// HandlePaymentEvent moves the order of a payment.succeeded event to paid and of a payment.failed event
+// to failed. Events of orders that already moved on, e.g. redelivered events, are skipped; any other
+// error is returned so the event is handled again.
+func (u *OrderService) HandlePaymentEvent(envelope *proto.EventEnvelope) error {
+	var to proto.OrderStatus
+	switch envelope.Type {
+	case kafka.EventPaymentSucceeded:
+		to = proto.OrderStatus_ORDER_STATUS_PAID
+	case kafka.EventPaymentFailed:
+		to = proto.OrderStatus_ORDER_STATUS_FAILED
+	default:
+		logrus.Warnf("Skipping event %s with unknown type %s", envelope.EventId, envelope.Type)
+		return nil
+	}
+	if envelope.Version != 1 {
+		logrus.Warnf("Skipping %s event %s with unknown version %d", envelope.Type, envelope.EventId, envelope.Version)
+		return nil
+	}
+
+	result := &proto.PaymentResult{}
+	if err := envelope.Payload.UnmarshalTo(result); err != nil {
+		logrus.Errorf("Skipping %s event %s with invalid payload: %v", envelope.Type, envelope.EventId, err)
+		return nil
+	}
+	if result.Payment == nil {
+		logrus.Errorf("Skipping %s event %s without payment", envelope.Type, envelope.EventId)
+		return nil
+	}
+
+	logrus.Infof("Received %s event for order %d", envelope.Type, result.Payment.OrderId)
+	err := u.UpdateOrderStatus(&proto.UpdateOrderStatusRequest{
+		OrderId: result.Payment.OrderId,
+		Status:  to,
+		Actor:   actorPaymentService,
+		Reason:  result.Reason,
+	})
+	if errors.Is(err, repository.ErrOrderNotFound) || status.Code(err) == codes.NotFound || status.Code(err) == codes.FailedPrecondition {
+		logrus.Warnf("Skipping %s event %s: %v", envelope.Type, envelope.EventId, err)
+		return nil
+	}
+	return err
+}
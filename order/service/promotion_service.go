@@ -0,0 +1,276 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+	"order/repository"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Values stored in promotions.type.
+const (
+	PromotionTypePercentage         = "percentage"
+	PromotionTypeFixedAmount        = "fixed_amount"
+	PromotionTypeFreeItem           = "free_item"
+	PromotionTypeBuyXGetY           = "buy_x_get_y"
+	PromotionTypeCategoryPercentage = "category_percentage"
+)
+
+// promotionStages orders the promotion types: item discounts first, then order discounts on what is left.
+var promotionStages = map[string]int{
+	PromotionTypeFreeItem:           0,
+	PromotionTypeBuyXGetY:           0,
+	PromotionTypeCategoryPercentage: 0,
+	PromotionTypePercentage:         1,
+	PromotionTypeFixedAmount:        2,
+}
+
+// PromotionService manages coupons and automatic promotions and applies them to orders. A promotion
+// with a code is a coupon, one without code applies to every order that qualifies.
+type PromotionService struct {
+	DB            *sql.DB
+	promotionRepo repository.PromotionRepository
+}
+
+func NewPromotionService(DB *sql.DB, promotionRepo repository.PromotionRepository) *PromotionService {
+	return &PromotionService{
+		DB:            DB,
+		promotionRepo: promotionRepo,
+	}
+}
+
+func (u *PromotionService) CreatePromotion(payload *proto.Promotion) (*proto.Promotion, error) {
+	payload.Code = strings.TrimSpace(payload.Code)
+	var err error
+	if payload.Currency, err = normalizeCurrency(payload.Currency); err != nil {
+		return nil, err
+	}
+	if payload.Currency == "" {
+		payload.Currency = defaultCurrency
+	}
+	if payload.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "promotion name is required")
+	}
+	if payload.UsageLimit < 0 || payload.PerUserLimit < 0 || payload.MinOrderAmount < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limits and minimum order amount cannot be negative")
+	}
+
+	switch payload.Type {
+	case PromotionTypePercentage:
+		if payload.Value <= 0 || payload.Value > 100 {
+			return nil, status.Error(codes.InvalidArgument, "percentage must be between 0 and 100")
+		}
+	case PromotionTypeFixedAmount:
+		if payload.Amount <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "amount must be greater than zero")
+		}
+	case PromotionTypeFreeItem:
+		if payload.ProductId <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "free item promotion needs a product")
+		}
+	case PromotionTypeBuyXGetY:
+		if payload.BuyQuantity <= 0 || payload.GetQuantity <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "buy and get quantities must be greater than zero")
+		}
+	case PromotionTypeCategoryPercentage:
+		if payload.Category == "" {
+			return nil, status.Error(codes.InvalidArgument, "category promotion needs a category")
+		}
+		if payload.Value <= 0 || payload.Value > 100 {
+			return nil, status.Error(codes.InvalidArgument, "percentage must be between 0 and 100")
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown promotion type %s", payload.Type)
+	}
+
+	if payload.StartsAt, err = parseListDate(payload.StartsAt, false); err != nil {
+		return nil, err
+	}
+	if payload.EndsAt, err = parseListDate(payload.EndsAt, true); err != nil {
+		return nil, err
+	}
+	if payload.StartsAt != "" && payload.EndsAt != "" && payload.EndsAt <= payload.StartsAt {
+		return nil, status.Error(codes.InvalidArgument, "promotion ends before it starts")
+	}
+
+	logrus.Infof("Creating %s promotion %s", payload.Type, payload.Name)
+	promotionID, err := u.promotionRepo.CreatePromotion(payload, u.DB)
+	if err != nil {
+		if errors.Is(err, repository.ErrPromotionCodeExists) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, err
+	}
+
+	return u.promotionRepo.GetPromotion(promotionID, u.DB)
+}
+
+func (u *PromotionService) ListPromotions(payload *proto.ListPromotionsRequest) ([]*proto.Promotion, error) {
+	return u.promotionRepo.ListPromotions(payload.IncludeInactive, u.DB)
+}
+
+func (u *PromotionService) DeactivatePromotion(payload *proto.GetPromotionRequest) (*proto.Promotion, error) {
+	if err := u.promotionRepo.DeactivatePromotion(int(payload.PromotionId), u.DB); err != nil {
+		if errors.Is(err, repository.ErrPromotionNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	return u.promotionRepo.GetPromotion(int(payload.PromotionId), u.DB)
+}
+
+func (u *PromotionService) GetOrderDiscounts(orderID int) ([]*proto.OrderDiscount, error) {
+	return u.promotionRepo.GetOrderDiscounts(orderID, u.DB)
+}
+
+// ReleaseRedemptions gives back the promotion uses of an order that will not be paid.
+func (u *PromotionService) ReleaseRedemptions(orderID int, tx *sql.Tx) error {
+	return u.promotionRepo.ReleaseOrderRedemptions(orderID, tx)
+}
+
+// ApplyPromotions prices the items of an order and stores the discounts it gets, replacing the ones it
+// had before. Promotions are valid at the given time, an empty time means now. A coupon that does
+// not apply is an error when strict, otherwise the order just loses the coupon discount.
+func (u *PromotionService) ApplyPromotions(order *proto.Order, items []*proto.OrderItem, at string, strict bool, tx *sql.Tx) (*orderPricing, error) {
+	if err := u.promotionRepo.ReleaseOrderRedemptions(int(order.Id), tx); err != nil {
+		return nil, err
+	}
+	if err := u.promotionRepo.DeleteOrderDiscounts(int(order.Id), tx); err != nil {
+		return nil, err
+	}
+
+	pricing := &orderPricing{}
+	for _, v := range items {
+		pricing.Subtotal += int64(v.Quantity) * v.Price
+	}
+
+	promotions, err := u.promotionRepo.GetApplicablePromotions(order.CouponCode, at, tx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(promotions, func(i, j int) bool {
+		return promotionStages[promotions[i].Type] < promotionStages[promotions[j].Type]
+	})
+
+	// rejectCoupon fails a strict request when the coupon cannot be used, automatic promotions are skipped
+	couponFound := false
+	rejectCoupon := func(promotion *proto.Promotion, reason string) error {
+		if promotion.Code == "" {
+			return nil
+		}
+		if strict {
+			return status.Errorf(codes.FailedPrecondition, "coupon %s %s", order.CouponCode, reason)
+		}
+		logrus.Infof("Dropping coupon %s from order %d: %s", order.CouponCode, order.Id, reason)
+		return nil
+	}
+
+	remaining := pricing.Subtotal
+	for _, promotion := range promotions {
+		if promotion.Code != "" {
+			couponFound = true
+		}
+		// Amounts of a promotion are in its own currency
+		if promotion.Currency != order.Currency && (promotion.Type == PromotionTypeFixedAmount || promotion.MinOrderAmount > 0) {
+			if err := rejectCoupon(promotion, "is only valid for orders in "+promotion.Currency); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if pricing.Subtotal < promotion.MinOrderAmount {
+			if err := rejectCoupon(promotion, "needs an order of at least "+formatAmount(promotion.MinOrderAmount, promotion.Currency)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		discount := promotionDiscount(promotion, items, remaining)
+		if discount.Amount <= 0 {
+			if err := rejectCoupon(promotion, "does not apply to this order"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		redeemed, err := u.promotionRepo.RedeemPromotion(promotion, int(order.Id), int(order.UserId), tx)
+		if err != nil {
+			return nil, err
+		}
+		if !redeemed {
+			if err := rejectCoupon(promotion, "has reached its usage limit"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		discount.OrderId = order.Id
+		if err := u.promotionRepo.CreateOrderDiscount(discount, tx); err != nil {
+			return nil, err
+		}
+		pricing.Discounts = append(pricing.Discounts, discount)
+		pricing.DiscountTotal += discount.Amount
+		remaining -= discount.Amount
+	}
+
+	if order.CouponCode != "" && !couponFound {
+		if strict {
+			return nil, status.Errorf(codes.FailedPrecondition, "coupon %s is not valid", order.CouponCode)
+		}
+		logrus.Infof("Dropping coupon %s from order %d: no longer valid", order.CouponCode, order.Id)
+	}
+
+	pricing.Total = pricing.Subtotal - pricing.DiscountTotal
+	return pricing, nil
+}
+
+// promotionDiscount computes what a promotion takes off the order, never more than what is left to pay.
+func promotionDiscount(promotion *proto.Promotion, items []*proto.OrderItem, remaining int64) *proto.OrderDiscount {
+	discount := &proto.OrderDiscount{
+		PromotionId: promotion.Id,
+		Code:        promotion.Code,
+		Type:        promotion.Type,
+		Description: promotion.Name,
+	}
+
+	var amount int64
+	switch promotion.Type {
+	case PromotionTypePercentage:
+		amount = percentOf(remaining, promotion.Value)
+	case PromotionTypeFixedAmount:
+		amount = promotion.Amount
+	case PromotionTypeFreeItem:
+		discount.ProductId = promotion.ProductId
+		freeQuantity := max(promotion.GetQuantity, 1)
+		for _, v := range items {
+			if v.ProductId == promotion.ProductId {
+				amount += int64(min(freeQuantity, v.Quantity)) * v.Price
+			}
+		}
+	case PromotionTypeBuyXGetY:
+		// Without product the deal applies to every line on its own
+		discount.ProductId = promotion.ProductId
+		for _, v := range items {
+			if promotion.ProductId != 0 && v.ProductId != promotion.ProductId {
+				continue
+			}
+			freeQuantity := v.Quantity / (promotion.BuyQuantity + promotion.GetQuantity) * promotion.GetQuantity
+			amount += int64(freeQuantity) * v.Price
+		}
+	case PromotionTypeCategoryPercentage:
+		for _, v := range items {
+			if strings.EqualFold(v.Category, promotion.Category) {
+				amount += percentOf(int64(v.Quantity)*v.Price, promotion.Value)
+			}
+		}
+	}
+
+	discount.Amount = min(amount, remaining)
+	return discount
+}
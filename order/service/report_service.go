@@ -0,0 +1,165 @@
+package service
+
+import (
+	"database/sql"
+	"order/proto"
+	"order/repository"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultReportDays = 30
+	maxReportDays     = 3 * 366
+)
+
+// ReportService answers the sales reports of the admins. Only orders that were paid count as sales,
+// amounts are reported per currency because orders of different currencies cannot be summed.
+type ReportService struct {
+	DB         *sql.DB
+	reportRepo repository.ReportRepository
+}
+
+func NewReportService(DB *sql.DB, reportRepo repository.ReportRepository) *ReportService {
+	return &ReportService{
+		DB:         DB,
+		reportRepo: reportRepo,
+	}
+}
+
+// GetRevenueReport sums the revenue per day, week or month, a week starts on Monday.
+func (u *ReportService) GetRevenueReport(payload *proto.ReportRequest) (*proto.RevenueReport, error) {
+	filter, from, to, err := reportFilter(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(payload.GroupBy) {
+	case "", "day":
+		filter.GroupBy = "day"
+	case "week":
+		filter.GroupBy = "week"
+	case "month":
+		filter.GroupBy = "month"
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid group_by %q", payload.GroupBy)
+	}
+
+	periods, err := u.reportRepo.GetRevenue(filter, u.DB)
+	if err != nil {
+		return nil, err
+	}
+	for _, period := range periods {
+		period.AverageOrderValue = period.Revenue / int64(period.Orders)
+	}
+
+	return &proto.RevenueReport{
+		From:    from,
+		To:      to,
+		GroupBy: filter.GroupBy,
+		Periods: periods,
+	}, nil
+}
+
+// GetTopProducts ranks the products by the quantity or the revenue of their order items.
+func (u *ReportService) GetTopProducts(payload *proto.ReportRequest) (*proto.TopProductsReport, error) {
+	filter, from, to, err := reportFilter(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(payload.SortBy) {
+	case "", "revenue":
+		filter.SortBy = "revenue"
+	case "quantity":
+		filter.SortBy = "quantity"
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid sort_by %q", payload.SortBy)
+	}
+
+	filter.Limit = int(payload.Limit)
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	products, err := u.reportRepo.GetTopProducts(filter, u.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.TopProductsReport{
+		From:     from,
+		To:       to,
+		SortBy:   filter.SortBy,
+		Products: products,
+	}, nil
+}
+
+// GetOrderValueReport returns the number of orders, the revenue and the average order value.
+func (u *ReportService) GetOrderValueReport(payload *proto.ReportRequest) (*proto.OrderValueReport, error) {
+	filter, from, to, err := reportFilter(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := u.reportRepo.GetOrderValue(filter, u.DB)
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		value.AverageOrderValue = value.Revenue / int64(value.Orders)
+	}
+
+	return &proto.OrderValueReport{
+		From:       from,
+		To:         to,
+		Currencies: values,
+	}, nil
+}
+
+// reportFilter reads the date range and currency of a report, it also returns the dates of the range
+// that was used so a report without dates says what it covers.
+func reportFilter(payload *proto.ReportRequest) (*repository.ReportFilter, string, string, error) {
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if payload.To != "" {
+		date, err := time.Parse("2006-01-02", payload.To)
+		if err != nil {
+			return nil, "", "", status.Errorf(codes.InvalidArgument, "invalid date %q", payload.To)
+		}
+		to = date
+	}
+
+	from := to.AddDate(0, 0, 1-defaultReportDays)
+	if payload.From != "" {
+		date, err := time.Parse("2006-01-02", payload.From)
+		if err != nil {
+			return nil, "", "", status.Errorf(codes.InvalidArgument, "invalid date %q", payload.From)
+		}
+		from = date
+	}
+
+	if from.After(to) {
+		return nil, "", "", status.Error(codes.InvalidArgument, "from must not be after to")
+	}
+	if to.Sub(from) > maxReportDays*24*time.Hour {
+		return nil, "", "", status.Errorf(codes.InvalidArgument, "a report covers at most %d days", maxReportDays)
+	}
+
+	currency, err := normalizeCurrency(payload.Currency)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return &repository.ReportFilter{
+		From:     from.Format("2006-01-02 15:04:05"),
+		To:       to.AddDate(0, 0, 1).Format("2006-01-02 15:04:05"),
+		Currency: currency,
+	}, from.Format("2006-01-02"), to.Format("2006-01-02"), nil
+}
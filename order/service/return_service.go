@@ -0,0 +1,293 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"order/proto"
+	"order/repository"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Status values stored in order_returns.status.
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusRefunded  = "refunded"
+)
+
+const returnListLimit = 100
+
+// returnableOrderStatuses are the statuses of orders that were delivered and can no longer be cancelled,
+// so a returned unit is never restocked and refunded a second time by a cancellation.
+var returnableOrderStatuses = map[string]bool{
+	OrderStatusFulfilled: true,
+	OrderStatusCompleted: true,
+}
+
+// ReturnService handles the RMA workflow: a customer requests a return of some order items,
+// an admin approves or rejects it and an approved return is restocked and partially refunded.
+type ReturnService struct {
+	DB            *sql.DB
+	orderRepo     repository.OrderRepository
+	orderItemRepo repository.OrderItemsRepository
+	returnRepo    repository.ReturnRepository
+	productRepo   repository.ProductRepository
+	paymentRepo   repository.PaymentRepository
+}
+
+func NewReturnService(DB *sql.DB, orderRepo repository.OrderRepository, orderItemRepo repository.OrderItemsRepository, returnRepo repository.ReturnRepository, productRepo repository.ProductRepository, paymentRepo repository.PaymentRepository) *ReturnService {
+	return &ReturnService{
+		DB:            DB,
+		orderRepo:     orderRepo,
+		orderItemRepo: orderItemRepo,
+		returnRepo:    returnRepo,
+		productRepo:   productRepo,
+		paymentRepo:   paymentRepo,
+	}
+}
+
+func (u *ReturnService) CreateReturn(payload *proto.CreateReturnRequest) (*proto.OrderReturn, error) {
+	if len(payload.Items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "return has no items")
+	}
+
+	// Merge duplicate order items, keeping the order they were requested in
+	var orderItemIDs []int32
+	quantities := make(map[int32]int32)
+	for _, v := range payload.Items {
+		if v.Quantity <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "quantity must be greater than zero")
+		}
+		if _, ok := quantities[v.OrderItemId]; !ok {
+			orderItemIDs = append(orderItemIDs, v.OrderItemId)
+		}
+		quantities[v.OrderItemId] += v.Quantity
+	}
+
+	order, err := u.orderRepo.GetOrderByID(&proto.GetOrderRequest{OrderId: payload.OrderId}, u.DB)
+	if err != nil {
+		return nil, err
+	}
+	if payload.UserId != 0 && order.UserId != payload.UserId {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the order serializes returns of the same order
+	orderStatus, err := u.orderRepo.GetOrderStatusForUpdate(int(order.Id), tx)
+	if err != nil {
+		return nil, err
+	}
+	if !returnableOrderStatuses[orderStatus] {
+		return nil, status.Errorf(codes.FailedPrecondition, "order %d is %s and cannot be returned", order.Id, orderStatus)
+	}
+
+	orderItems, err := u.orderItemRepo.GetOrderItems(int(order.Id), u.DB)
+	if err != nil {
+		return nil, err
+	}
+	returned, err := u.returnRepo.GetReturnedQuantities(int(order.Id), tx)
+	if err != nil {
+		return nil, err
+	}
+
+	orderReturn := &proto.OrderReturn{
+		OrderId: order.Id,
+		UserId:  order.UserId,
+		Status:  ReturnStatusRequested,
+		Reason:  payload.Reason,
+	}
+	orderItemsByID := make(map[int32]*proto.OrderItem, len(orderItems))
+	for _, v := range orderItems {
+		orderItemsByID[v.Id] = v
+	}
+
+	for _, orderItemID := range orderItemIDs {
+		orderItem, ok := orderItemsByID[orderItemID]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "order item %d does not belong to order %d", orderItemID, order.Id)
+		}
+
+		quantity := quantities[orderItemID]
+		if left := orderItem.Quantity - returned[orderItem.Id]; quantity > left {
+			return nil, status.Errorf(codes.FailedPrecondition, "only %d of order item %d can still be returned", left, orderItem.Id)
+		}
+		orderReturn.Items = append(orderReturn.Items, &proto.ReturnItem{
+			OrderItemId: orderItem.Id,
+			ProductId:   orderItem.ProductId,
+			Quantity:    quantity,
+			Price:       orderItem.Price,
+		})
+		orderReturn.RefundAmount += int64(quantity) * orderItem.Price
+	}
+	// Discounts and taxes of the order are shared by its items in proportion to their price, shipping is not refunded
+	if itemsTotal := order.TotalPrice - order.ShippingCost; order.Subtotal > 0 && itemsTotal != order.Subtotal {
+		orderReturn.RefundAmount = scaleAmount(orderReturn.RefundAmount, itemsTotal, order.Subtotal)
+	}
+	orderReturn.Currency = order.Currency
+
+	logrus.Infof("Creating return for order %d", order.Id)
+	returnID, err := u.returnRepo.CreateReturn(orderReturn, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range orderReturn.Items {
+		if err := u.returnRepo.CreateReturnItem(returnID, v, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return u.returnRepo.GetReturn(returnID, u.DB)
+}
+
+// ApproveReturn approves a requested return, then restocks its items and refunds them. Both calls are
+// idempotent by reference, so approving a return that is stuck in approved retries what is left.
+func (u *ReturnService) ApproveReturn(payload *proto.ReviewReturnRequest) (*proto.OrderReturn, error) {
+	orderReturn, err := u.review(payload, ReturnStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+	if orderReturn.Status != ReturnStatusApproved {
+		return orderReturn, nil
+	}
+
+	reference := fmt.Sprintf("return:%d", orderReturn.Id)
+
+	logrus.Infof("Restocking return %d", orderReturn.Id)
+	restockItems := make([]*proto.ReservationItem, 0, len(orderReturn.Items))
+	for _, v := range orderReturn.Items {
+		restockItems = append(restockItems, &proto.ReservationItem{
+			ProductId: v.ProductId,
+			Quantity:  v.Quantity,
+		})
+	}
+	if _, err := u.productRepo.RestockProducts(&proto.RestockRequest{
+		Reference: reference,
+		Items:     restockItems,
+	}); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Refunding %s for return %d", formatAmount(orderReturn.RefundAmount, orderReturn.Currency), orderReturn.Id)
+	refund, err := u.paymentRepo.RefundPayment(&proto.RefundPaymentRequest{
+		OrderId:   orderReturn.OrderId,
+		Amount:    orderReturn.RefundAmount,
+		Reason:    fmt.Sprintf("return %d", orderReturn.Id),
+		Reference: reference,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	orderReturn, err = u.returnRepo.GetReturnForUpdate(int(orderReturn.Id), tx)
+	if err != nil {
+		return nil, err
+	}
+	if orderReturn.Status == ReturnStatusApproved {
+		orderReturn.Status = ReturnStatusRefunded
+		orderReturn.RefundId = refund.Id
+		if err := u.returnRepo.UpdateReturn(orderReturn, tx); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return u.returnRepo.GetReturn(int(orderReturn.Id), u.DB)
+}
+
+func (u *ReturnService) RejectReturn(payload *proto.ReviewReturnRequest) (*proto.OrderReturn, error) {
+	return u.review(payload, ReturnStatusRejected)
+}
+
+// review moves a requested return to the decision of the admin. Reviewing a return again with the
+// same decision returns it unchanged, a different decision is rejected.
+func (u *ReturnService) review(payload *proto.ReviewReturnRequest, decision string) (*proto.OrderReturn, error) {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	orderReturn, err := u.returnRepo.GetReturnForUpdate(int(payload.ReturnId), tx)
+	if err != nil {
+		if errors.Is(err, repository.ErrReturnNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	switch {
+	case orderReturn.Status == ReturnStatusRequested:
+	case orderReturn.Status == decision,
+		decision == ReturnStatusApproved && orderReturn.Status == ReturnStatusRefunded:
+		return orderReturn, nil
+	default:
+		return nil, status.Errorf(codes.FailedPrecondition, "return %d is already %s", orderReturn.Id, orderReturn.Status)
+	}
+
+	logrus.Infof("Return %d %s by %s", orderReturn.Id, decision, payload.Actor)
+	orderReturn.Status = decision
+	orderReturn.AdminNote = payload.AdminNote
+	orderReturn.ReviewedBy = payload.Actor
+	if err := u.returnRepo.UpdateReturn(orderReturn, tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return orderReturn, nil
+}
+
+func (u *ReturnService) GetReturn(payload *proto.GetReturnRequest) (*proto.OrderReturn, error) {
+	orderReturn, err := u.returnRepo.GetReturn(int(payload.ReturnId), u.DB)
+	if err != nil {
+		if errors.Is(err, repository.ErrReturnNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+	if payload.UserId != 0 && orderReturn.UserId != payload.UserId {
+		return nil, status.Error(codes.NotFound, repository.ErrReturnNotFound.Error())
+	}
+
+	return orderReturn, nil
+}
+
+func (u *ReturnService) ListReturns(payload *proto.ListReturnsRequest) ([]*proto.OrderReturn, error) {
+	switch payload.Status {
+	case "", ReturnStatusRequested, ReturnStatusApproved, ReturnStatusRejected, ReturnStatusRefunded:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown return status %s", payload.Status)
+	}
+
+	return u.returnRepo.ListReturns(&repository.ReturnFilter{
+		OrderID: int(payload.OrderId),
+		UserID:  int(payload.UserId),
+		Status:  payload.Status,
+		Limit:   returnListLimit,
+	}, u.DB)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"database/sql"
+	"order/proto"
+	"order/repository"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	ShippingCarrierTableRate    = "table_rate"
+	ShippingCarrierLocalCourier = "local_courier"
+)
+
+// TableRateProvider prices parcels from shipping_table_rates. For each service the rate of the most
+// specific zone wins, so a region can override the rates of its country.
+type TableRateProvider struct {
+	DB           *sql.DB
+	shippingRepo repository.ShippingRepository
+}
+
+func NewTableRateProvider(DB *sql.DB, shippingRepo repository.ShippingRepository) *TableRateProvider {
+	return &TableRateProvider{
+		DB:           DB,
+		shippingRepo: shippingRepo,
+	}
+}
+
+func (u *TableRateProvider) Carrier() string {
+	return ShippingCarrierTableRate
+}
+
+func (u *TableRateProvider) Quote(request *ShippingRateRequest) ([]*proto.ShippingQuote, error) {
+	rates, err := u.shippingRepo.GetZoneTableRates(request.Zone, request.WeightGrams, u.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	var quotes []*proto.ShippingQuote
+	quoted := make(map[string]bool)
+	for _, rate := range rates {
+		if quoted[rate.Service] {
+			continue
+		}
+		quoted[rate.Service] = true
+
+		quotes = append(quotes, &proto.ShippingQuote{
+			Carrier:       u.Carrier(),
+			Service:       rate.Service,
+			Description:   rate.Service + " to " + rate.Zone,
+			Cost:          rate.Price + rate.PricePerKg*startedKilograms(request.WeightGrams),
+			Currency:      rate.Currency,
+			EstimatedDays: rate.EstimatedDays,
+		})
+	}
+	return quotes, nil
+}
+
+// LocalCourierProvider stands in for the API of a local courier until the real integration exists.
+// It delivers within one country, set by LOCAL_COURIER_COUNTRY, at a base rate plus a rate per
+// kilogram read from LOCAL_COURIER_BASE_RATE and LOCAL_COURIER_RATE_PER_KG in minor units of
+// LOCAL_COURIER_CURRENCY.
+type LocalCourierProvider struct {
+	country   string
+	currency  string
+	baseRate  int64
+	ratePerKg int64
+}
+
+func NewLocalCourierProvider() *LocalCourierProvider {
+	country := strings.ToUpper(os.Getenv("LOCAL_COURIER_COUNTRY"))
+	if country == "" {
+		country = "ID"
+	}
+	return &LocalCourierProvider{
+		country:   country,
+		currency:  envCurrency("LOCAL_COURIER_CURRENCY", defaultCurrency),
+		baseRate:  envAmount("LOCAL_COURIER_BASE_RATE", 900000),
+		ratePerKg: envAmount("LOCAL_COURIER_RATE_PER_KG", 400000),
+	}
+}
+
+func (u *LocalCourierProvider) Carrier() string {
+	return ShippingCarrierLocalCourier
+}
+
+func (u *LocalCourierProvider) Quote(request *ShippingRateRequest) ([]*proto.ShippingQuote, error) {
+	if request.Address.Country != u.country {
+		return nil, nil
+	}
+
+	cost := u.baseRate + u.ratePerKg*startedKilograms(request.WeightGrams)
+	return []*proto.ShippingQuote{
+		{
+			Carrier:       u.Carrier(),
+			Service:       "regular",
+			Description:   "Local courier regular delivery",
+			Cost:          cost,
+			Currency:      u.currency,
+			EstimatedDays: 3,
+		},
+		{
+			Carrier:       u.Carrier(),
+			Service:       "same_day",
+			Description:   "Local courier same day delivery",
+			Cost:          cost * 2,
+			Currency:      u.currency,
+			EstimatedDays: 0,
+		},
+	}, nil
+}
+
+// startedKilograms counts every started kilogram of a parcel, a parcel without weight counts as one.
+func startedKilograms(weightGrams int32) int64 {
+	return max((int64(weightGrams)+999)/1000, 1)
+}
+
+func envAmount(name string, fallback int64) int64 {
+	amount, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil || amount < 0 {
+		return fallback
+	}
+	return amount
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+	"order/repository"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ShippingRateRequest describes a parcel to price. Zone is the country of the address, followed by
+// its region when it has one, e.g. ID or ID-JK.
+type ShippingRateRequest struct {
+	Address     *proto.ShippingAddress
+	Zone        string
+	WeightGrams int32
+}
+
+// ShippingRateProvider prices parcels for one carrier in the currency of its rates. Quote returns no
+// quotes when the carrier does not deliver to the address.
+type ShippingRateProvider interface {
+	Carrier() string
+	Quote(request *ShippingRateRequest) ([]*proto.ShippingQuote, error)
+}
+
+// ShippingService quotes the shipping of orders with every provider and keeps the address and the
+// chosen rate of an order. The cost is always quoted again here, a client never sets it. Quotes are
+// converted into the currency of the order at the current rate.
+type ShippingService struct {
+	DB            *sql.DB
+	shippingRepo  repository.ShippingRepository
+	productRepo   repository.ProductRepository
+	exchangeRates *ExchangeRateService
+	providers     []ShippingRateProvider
+}
+
+func NewShippingService(DB *sql.DB, shippingRepo repository.ShippingRepository, productRepo repository.ProductRepository, exchangeRates *ExchangeRateService, providers ...ShippingRateProvider) *ShippingService {
+	return &ShippingService{
+		DB:            DB,
+		shippingRepo:  shippingRepo,
+		productRepo:   productRepo,
+		exchangeRates: exchangeRates,
+		providers:     providers,
+	}
+}
+
+// GetShippingQuotes returns the rates of every carrier for the items sent to the address, in the
+// requested currency or else in the currency of each carrier.
+func (u *ShippingService) GetShippingQuotes(payload *proto.GetShippingQuotesRequest) (*proto.GetShippingQuotesResponse, error) {
+	if err := normalizeShippingAddress(payload.Address); err != nil {
+		return nil, err
+	}
+	currency, err := normalizeCurrency(payload.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload.Items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "items are required")
+	}
+
+	var weightGrams int32
+	for _, v := range payload.Items {
+		if v.Quantity <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "quantity must be greater than zero")
+		}
+		product, err := u.productRepo.GetProduct(&proto.GetProductRequest{Id: v.ProductId})
+		if err != nil {
+			return nil, err
+		}
+		weightGrams += v.Quantity * product.WeightGrams
+	}
+
+	return &proto.GetShippingQuotesResponse{
+		Quotes:      u.quotes(payload.Address, weightGrams, currency),
+		WeightGrams: weightGrams,
+	}, nil
+}
+
+// Quote prices the parcel with the chosen carrier and service in the currency of the order.
+func (u *ShippingService) Quote(address *proto.ShippingAddress, weightGrams int32, currency string, carrier string, service string) (*proto.ShippingQuote, error) {
+	for _, v := range u.quotes(address, weightGrams, currency) {
+		if strings.EqualFold(v.Carrier, carrier) && strings.EqualFold(v.Service, service) && v.Currency == currency {
+			return v, nil
+		}
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "shipping service %s of %s is not available for this address", service, carrier)
+}
+
+// quotes asks every provider, a provider that fails is left out so the others can still be chosen. With
+// a currency, quotes that cannot be converted into it are left out too.
+func (u *ShippingService) quotes(address *proto.ShippingAddress, weightGrams int32, currency string) []*proto.ShippingQuote {
+	request := &ShippingRateRequest{
+		Address:     address,
+		Zone:        shippingZone(address),
+		WeightGrams: weightGrams,
+	}
+
+	var quotes []*proto.ShippingQuote
+	for _, provider := range u.providers {
+		providerQuotes, err := provider.Quote(request)
+		if err != nil {
+			logrus.Errorf("Failed to get shipping quotes from %s: %v", provider.Carrier(), err)
+			continue
+		}
+		for _, quote := range providerQuotes {
+			if currency != "" && quote.Currency != currency {
+				cost, err := u.exchangeRates.Convert(quote.Cost, quote.Currency, currency)
+				if err != nil {
+					logrus.Errorf("Failed to convert %s %s quote: %v", quote.Carrier, quote.Service, err)
+					continue
+				}
+				quote.Cost, quote.Currency = cost, currency
+			}
+			quotes = append(quotes, quote)
+		}
+	}
+	return quotes
+}
+
+func (u *ShippingService) CreateOrderShipping(orderID int, shipping *proto.OrderShipping, tx *sql.Tx) error {
+	return u.shippingRepo.CreateOrderShipping(orderID, shipping, tx)
+}
+
+// RequoteOrder prices the shipping of an order again for the weight of its items and sets the new
+// cost on the order. When the rate is no longer available the order keeps the cost it had.
+func (u *ShippingService) RequoteOrder(order *proto.Order, items []*proto.OrderItem, tx *sql.Tx) error {
+	shipping, err := u.shippingRepo.GetOrderShipping(int(order.Id), u.DB)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderShippingNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var weightGrams int32
+	for _, v := range items {
+		weightGrams += v.Quantity * v.WeightGrams
+	}
+
+	quote, err := u.Quote(shipping.Address, weightGrams, order.Currency, shipping.Carrier, shipping.Service)
+	if err != nil {
+		logrus.Infof("Keeping shipping cost of order %d: %v", order.Id, err)
+		return nil
+	}
+	order.ShippingCost = quote.Cost
+
+	return u.shippingRepo.UpdateOrderShippingWeight(int(order.Id), weightGrams, quote.EstimatedDays, tx)
+}
+
+// AttachShipping adds the shipping address and rate to an order that has them.
+func (u *ShippingService) AttachShipping(order *proto.Order) error {
+	shipping, err := u.shippingRepo.GetOrderShipping(int(order.Id), u.DB)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderShippingNotFound) {
+			return nil
+		}
+		return err
+	}
+	order.Shipping = shipping
+	return nil
+}
+
+// normalizeShippingAddress checks the required fields and upper cases the country and region codes.
+func normalizeShippingAddress(address *proto.ShippingAddress) error {
+	if address == nil {
+		return status.Error(codes.InvalidArgument, "shipping address is required")
+	}
+
+	address.RecipientName = strings.TrimSpace(address.RecipientName)
+	address.Line1 = strings.TrimSpace(address.Line1)
+	address.City = strings.TrimSpace(address.City)
+	address.PostalCode = strings.TrimSpace(address.PostalCode)
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	address.Region = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(address.Region)), address.Country+"-")
+
+	switch {
+	case address.RecipientName == "":
+		return status.Error(codes.InvalidArgument, "recipient name is required")
+	case address.Line1 == "":
+		return status.Error(codes.InvalidArgument, "address line is required")
+	case address.City == "":
+		return status.Error(codes.InvalidArgument, "city is required")
+	case address.PostalCode == "":
+		return status.Error(codes.InvalidArgument, "postal code is required")
+	case len(address.Country) != 2:
+		return status.Error(codes.InvalidArgument, "country must be a two letter code")
+	}
+	return nil
+}
+
+func shippingZone(address *proto.ShippingAddress) string {
+	if address.Region == "" {
+		return address.Country
+	}
+	return address.Country + "-" + address.Region
+}
@@ -0,0 +1,578 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"order/proto"
+	"order/repository"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Status values of subscriptions and their cycles.
+const (
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusPaused    = "paused"
+	SubscriptionStatusCancelled = "cancelled"
+
+	CycleStatusPending   = "pending"
+	CycleStatusPaid      = "paid"
+	CycleStatusSkipped   = "skipped"
+	CycleStatusFailed    = "failed"
+	CycleStatusCancelled = "cancelled"
+)
+
+const (
+	subscriptionPollPeriod  = time.Minute
+	subscriptionBatchSize   = 50
+	subscriptionPaymentPoll = 30 * time.Second
+	subscriptionCycleLease  = 5 * time.Minute
+	maxSubscriptionInterval = 12
+)
+
+// subscriptionKeyPrefix keeps the idempotency keys of subscription orders and payments apart from the
+// keys clients send.
+const subscriptionKeyPrefix = "subscription:"
+
+// subscriptionRetryDelays is the dunning schedule: how long a cycle waits after each failed attempt to
+// order and pay. Once it is used up the cycle fails and the subscription is paused.
+var subscriptionRetryDelays = []time.Duration{time.Hour, 6 * time.Hour, 24 * time.Hour, 72 * time.Hour}
+
+// SubscriptionService manages the subscriptions of customers and runs their cycles: every cycle orders
+// the items through CreateOrder, like a checkout, and pays the order automatically.
+type SubscriptionService struct {
+	DB               *sql.DB
+	subscriptionRepo repository.SubscriptionRepository
+	orderRepo        repository.OrderRepository
+	productRepo      repository.ProductRepository
+	paymentRepo      repository.PaymentRepository
+	orderService     *OrderService
+}
+
+func NewSubscriptionService(DB *sql.DB, subscriptionRepo repository.SubscriptionRepository, orderRepo repository.OrderRepository, productRepo repository.ProductRepository, paymentRepo repository.PaymentRepository, orderService *OrderService) *SubscriptionService {
+	return &SubscriptionService{
+		DB:               DB,
+		subscriptionRepo: subscriptionRepo,
+		orderRepo:        orderRepo,
+		productRepo:      productRepo,
+		paymentRepo:      paymentRepo,
+		orderService:     orderService,
+	}
+}
+
+func (u *SubscriptionService) CreateSubscription(payload *proto.CreateSubscriptionRequest) (*proto.Subscription, error) {
+	if len(payload.Items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "subscription items are required")
+	}
+	for _, item := range payload.Items {
+		if item.Quantity <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "quantity must be greater than zero")
+		}
+		if _, err := u.productRepo.GetProduct(&proto.GetProductRequest{Id: item.ProductId}); err != nil {
+			return nil, err
+		}
+	}
+
+	payload.Interval = strings.ToLower(strings.TrimSpace(payload.Interval))
+	switch payload.Interval {
+	case "day", "week", "month":
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid interval %q", payload.Interval)
+	}
+	if payload.IntervalCount == 0 {
+		payload.IntervalCount = 1
+	}
+	if payload.IntervalCount < 0 || payload.IntervalCount > maxSubscriptionInterval {
+		return nil, status.Errorf(codes.InvalidArgument, "interval_count must be between 1 and %d", maxSubscriptionInterval)
+	}
+
+	if payload.StartAt != "" {
+		startAt, err := time.Parse("2006-01-02", payload.StartAt)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid date %q", payload.StartAt)
+		}
+		payload.StartAt = startAt.Format("2006-01-02 15:04:05")
+	}
+
+	var err error
+	if payload.Currency, err = normalizeCurrency(payload.Currency); err != nil {
+		return nil, err
+	}
+	if payload.MaxAmount < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max_amount cannot be negative")
+	}
+	if payload.MaxAmount > 0 && payload.Currency == "" {
+		return nil, status.Error(codes.InvalidArgument, "currency is required with max_amount")
+	}
+	payload.Region = strings.ToUpper(strings.TrimSpace(payload.Region))
+
+	if payload.ShippingAddress != nil {
+		if err := normalizeShippingAddress(payload.ShippingAddress); err != nil {
+			return nil, err
+		}
+		if payload.ShippingCarrier == "" || payload.ShippingService == "" {
+			return nil, status.Error(codes.InvalidArgument, "shipping carrier and service are required with a shipping address")
+		}
+	} else if payload.ShippingCarrier != "" || payload.ShippingService != "" {
+		return nil, status.Error(codes.InvalidArgument, "shipping address is required")
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	logrus.Infof("Creating %d %s subscription of user %d", payload.IntervalCount, payload.Interval, payload.UserId)
+	subscriptionID, err := u.subscriptionRepo.CreateSubscription(payload, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range payload.Items {
+		if err := u.subscriptionRepo.CreateSubscriptionItem(subscriptionID, item, tx); err != nil {
+			return nil, err
+		}
+	}
+	if payload.ShippingAddress != nil {
+		if err := u.subscriptionRepo.CreateSubscriptionShipping(subscriptionID, payload, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return u.subscriptionRepo.GetSubscription(subscriptionID, u.DB)
+}
+
+func (u *SubscriptionService) GetSubscription(payload *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	subscription, err := u.subscriptionRepo.GetSubscription(int(payload.SubscriptionId), u.DB)
+	if err != nil {
+		if errors.Is(err, repository.ErrSubscriptionNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+	if payload.UserId != 0 && subscription.UserId != payload.UserId {
+		return nil, status.Error(codes.NotFound, repository.ErrSubscriptionNotFound.Error())
+	}
+
+	return subscription, nil
+}
+
+func (u *SubscriptionService) ListSubscriptions(payload *proto.ListSubscriptionsRequest) ([]*proto.Subscription, error) {
+	return u.subscriptionRepo.ListSubscriptions(&repository.SubscriptionFilter{
+		UserID: int(payload.UserId),
+		Status: payload.Status,
+		Limit:  maxListLimit,
+	}, u.DB)
+}
+
+// PauseSubscription stops the cycles of an active subscription until it is resumed, a cycle that is
+// still being paid is cancelled together with its order unless its payment went through.
+func (u *SubscriptionService) PauseSubscription(payload *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	var orderIDs []int
+	subscription, err := u.changeSubscription(payload, func(subscription *proto.Subscription, tx *sql.Tx) error {
+		if subscription.Status != SubscriptionStatusActive {
+			return status.Errorf(codes.FailedPrecondition, "subscription %d is %s", subscription.Id, subscription.Status)
+		}
+		var err error
+		if orderIDs, err = u.subscriptionRepo.CancelPendingCycles(int(subscription.Id), tx); err != nil {
+			return err
+		}
+		return u.subscriptionRepo.UpdateSubscriptionStatus(int(subscription.Id), SubscriptionStatusPaused, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.cancelCycleOrders(orderIDs, "subscription was paused")
+
+	return subscription, nil
+}
+
+// ResumeSubscription restarts a paused subscription, a next run that passed while it was paused runs
+// right away.
+func (u *SubscriptionService) ResumeSubscription(payload *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	return u.changeSubscription(payload, func(subscription *proto.Subscription, tx *sql.Tx) error {
+		if subscription.Status != SubscriptionStatusPaused {
+			return status.Errorf(codes.FailedPrecondition, "subscription %d is %s", subscription.Id, subscription.Status)
+		}
+		return u.subscriptionRepo.UpdateSubscriptionStatus(int(subscription.Id), SubscriptionStatusActive, tx)
+	})
+}
+
+// SkipSubscriptionCycle skips the next cycle of a subscription, the one after it is ordered as usual.
+func (u *SubscriptionService) SkipSubscriptionCycle(payload *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	return u.changeSubscription(payload, func(subscription *proto.Subscription, tx *sql.Tx) error {
+		if subscription.Status == SubscriptionStatusCancelled {
+			return status.Errorf(codes.FailedPrecondition, "subscription %d is %s", subscription.Id, subscription.Status)
+		}
+		return u.subscriptionRepo.SetSkipNext(int(subscription.Id), true, tx)
+	})
+}
+
+// CancelSubscription ends a subscription for good, a cycle that is still being paid is cancelled
+// together with its order unless its payment went through.
+func (u *SubscriptionService) CancelSubscription(payload *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	var orderIDs []int
+	subscription, err := u.changeSubscription(payload, func(subscription *proto.Subscription, tx *sql.Tx) error {
+		if subscription.Status == SubscriptionStatusCancelled {
+			return status.Errorf(codes.FailedPrecondition, "subscription %d is already cancelled", subscription.Id)
+		}
+		var err error
+		if orderIDs, err = u.subscriptionRepo.CancelPendingCycles(int(subscription.Id), tx); err != nil {
+			return err
+		}
+		return u.subscriptionRepo.UpdateSubscriptionStatus(int(subscription.Id), SubscriptionStatusCancelled, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.cancelCycleOrders(orderIDs, "subscription was cancelled")
+
+	return subscription, nil
+}
+
+// cancelCycleOrders cancels the unpaid orders of the cycles of a subscription that was paused or
+// cancelled. The scheduler settles the cycles, and cancels an order that could not be cancelled here.
+func (u *SubscriptionService) cancelCycleOrders(orderIDs []int, reason string) {
+	for _, orderID := range orderIDs {
+		if _, err := u.cancelUnpaidOrder(orderID, reason); err != nil {
+			logrus.Errorf("Failed to cancel subscription order %d: %v", orderID, err)
+		}
+	}
+}
+
+// cancelUnpaidOrder cancels the order of a cycle unless its payment is no longer pending, it reports
+// whether the order was cancelled.
+func (u *SubscriptionService) cancelUnpaidOrder(orderID int, reason string) (bool, error) {
+	payment, err := u.paymentRepo.GetPayment(&proto.GetPaymentRequest{OrderId: int32(orderID)})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return false, err
+	}
+	if err == nil && payment.Status != "pending" {
+		return false, nil
+	}
+
+	if _, err := u.orderService.CancelOrder(&proto.CancelOrderRequest{
+		OrderId: int32(orderID),
+		Reason:  reason,
+	}); err != nil {
+		if status.Code(err) == codes.FailedPrecondition {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// changeSubscription locks a subscription of the user of payload and applies change to it.
+func (u *SubscriptionService) changeSubscription(payload *proto.GetSubscriptionRequest, change func(*proto.Subscription, *sql.Tx) error) (*proto.Subscription, error) {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	subscription, err := u.subscriptionRepo.GetSubscriptionForUpdate(int(payload.SubscriptionId), tx)
+	if err != nil {
+		if errors.Is(err, repository.ErrSubscriptionNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+	if payload.UserId != 0 && subscription.UserId != payload.UserId {
+		return nil, status.Error(codes.NotFound, repository.ErrSubscriptionNotFound.Error())
+	}
+
+	if err := change(subscription, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return u.subscriptionRepo.GetSubscription(int(subscription.Id), u.DB)
+}
+
+// Run starts the cycles of the subscriptions that are due and attempts the pending cycles.
+func (u *SubscriptionService) Run(ctx context.Context) {
+	logrus.Info("Starting subscription scheduler")
+	ticker := time.NewTicker(subscriptionPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Subscription scheduler stopping...")
+			return
+		case <-ticker.C:
+			if err := u.scheduleCycles(); err != nil {
+				logrus.Errorf("failed to schedule subscription cycles: %v", err)
+			}
+
+			cycleIDs, err := u.subscriptionRepo.GetDueCycleIDs(subscriptionBatchSize, u.DB)
+			if err != nil {
+				logrus.Errorf("failed to get due subscription cycles: %v", err)
+				continue
+			}
+			for _, cycleID := range cycleIDs {
+				if err := u.processCycle(cycleID); err != nil {
+					logrus.Errorf("failed to process subscription cycle %d: %v", cycleID, err)
+				}
+			}
+		}
+	}
+}
+
+func (u *SubscriptionService) scheduleCycles() error {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	subscriptionIDs, err := u.subscriptionRepo.GetDueSubscriptionIDs(subscriptionBatchSize, tx)
+	if err != nil {
+		return err
+	}
+	for _, subscriptionID := range subscriptionIDs {
+		logrus.Infof("Scheduling the next cycle of subscription %d", subscriptionID)
+		if err := u.subscriptionRepo.ScheduleCycle(subscriptionID, tx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// processCycle makes one attempt at a pending cycle. A failed attempt is retried on the dunning
+// schedule, after the last one the cycle fails, its order is cancelled and the subscription paused
+// until the customer resumes it. The cycle is only locked to claim it and to store the outcome, not
+// while the order is created and paid.
+func (u *SubscriptionService) processCycle(cycleID int) error {
+	cycle, err := u.claimCycle(cycleID)
+	if err != nil || cycle == nil {
+		return err
+	}
+	orderID := cycle.OrderID
+
+	subscription, err := u.subscriptionRepo.GetSubscription(cycle.SubscriptionID, u.DB)
+	if err != nil {
+		return err
+	}
+
+	var retryIn time.Duration
+	var attemptErr error
+	if subscription.Status != SubscriptionStatusActive {
+		if retryIn, err = u.settleCycle(cycle, subscription); err != nil {
+			logrus.Warnf("Failed to settle cycle %d of subscription %d: %v", cycle.ID, subscription.Id, err)
+			retryIn = subscriptionPaymentPoll
+		}
+	} else {
+		retryIn, attemptErr = u.attemptCycle(cycle, subscription)
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := u.subscriptionRepo.LockCycle(cycle.ID, tx)
+	if err != nil {
+		return err
+	}
+	if current.Status != CycleStatusPending {
+		// The subscription was paused or cancelled during the attempt, an order it created is not wanted
+		if cycle.OrderID != 0 && cycle.OrderID != orderID {
+			u.cancelCycleOrders([]int{cycle.OrderID}, "subscription is "+subscription.Status)
+		}
+		return nil
+	}
+
+	var unpaidOrderID int
+	if attemptErr != nil {
+		cycle.Attempts++
+		cycle.LastError = attemptErr.Error()
+		if cycle.Attempts > len(subscriptionRetryDelays) {
+			logrus.Errorf("Subscription %d cycle %d failed after %d attempts, pausing the subscription: %v", subscription.Id, cycle.ID, cycle.Attempts, attemptErr)
+			cycle.Status = CycleStatusFailed
+			unpaidOrderID = cycle.OrderID
+			if err := u.subscriptionRepo.UpdateSubscriptionStatus(cycle.SubscriptionID, SubscriptionStatusPaused, tx); err != nil {
+				return err
+			}
+		} else {
+			retryIn = subscriptionRetryDelays[cycle.Attempts-1]
+			logrus.Warnf("Subscription %d cycle %d attempt %d failed, retrying in %s: %v", subscription.Id, cycle.ID, cycle.Attempts, retryIn, attemptErr)
+		}
+	}
+
+	if err := u.subscriptionRepo.UpdateCycle(cycle, retryIn, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if unpaidOrderID != 0 {
+		if _, err := u.orderService.CancelOrder(&proto.CancelOrderRequest{
+			OrderId: int32(unpaidOrderID),
+			Reason:  "subscription payment failed",
+		}); err != nil && status.Code(err) != codes.FailedPrecondition {
+			logrus.Errorf("Failed to cancel order %d of failed subscription cycle %d: %v", unpaidOrderID, cycle.ID, err)
+		}
+	}
+	return nil
+}
+
+// claimCycle locks a due pending cycle and moves its next attempt a lease on, so no other scheduler
+// attempts it while this one does. It returns nil when the cycle is locked or no longer pending.
+func (u *SubscriptionService) claimCycle(cycleID int) (*repository.SubscriptionCycle, error) {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	cycle, err := u.subscriptionRepo.GetCycleForUpdate(cycleID, tx)
+	if err != nil {
+		if errors.Is(err, repository.ErrSubscriptionCycleNotAvailable) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if cycle.Status != CycleStatusPending {
+		return nil, nil
+	}
+	if err := u.subscriptionRepo.UpdateCycle(cycle, subscriptionCycleLease, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return cycle, nil
+}
+
+// settleCycle ends a pending cycle of a subscription that is no longer active: a cycle without an
+// order or whose order was cancelled is cancelled, a cycle whose payment went through is marked paid
+// once its order is and an unpaid order is cancelled first.
+func (u *SubscriptionService) settleCycle(cycle *repository.SubscriptionCycle, subscription *proto.Subscription) (time.Duration, error) {
+	if cycle.OrderID == 0 {
+		cycle.Status = CycleStatusCancelled
+		return 0, nil
+	}
+
+	order, err := u.orderRepo.GetOrderByID(&proto.GetOrderRequest{OrderId: int32(cycle.OrderID)}, u.DB)
+	if err != nil {
+		return 0, err
+	}
+	switch order.Status {
+	case OrderStatusPaid, OrderStatusFulfilled, OrderStatusCompleted:
+		cycle.Status = CycleStatusPaid
+		return 0, nil
+	case OrderStatusCancelled, OrderStatusFailed:
+		cycle.Status = CycleStatusCancelled
+		return 0, nil
+	}
+
+	cancelled, err := u.cancelUnpaidOrder(cycle.OrderID, "subscription is "+subscription.Status)
+	if err != nil {
+		return 0, err
+	}
+	if cancelled {
+		cycle.Status = CycleStatusCancelled
+		return 0, nil
+	}
+	return subscriptionPaymentPoll, nil
+}
+
+// attemptCycle moves a cycle one step on: it orders the items, pays the pending order and marks the
+// cycle paid once the order is. retryIn is when the cycle is looked at again, an error is a failed
+// attempt. An order that was cancelled or failed is replaced by a new one on the next attempt.
+func (u *SubscriptionService) attemptCycle(cycle *repository.SubscriptionCycle, subscription *proto.Subscription) (time.Duration, error) {
+	if cycle.OrderID == 0 {
+		items := make([]*proto.OrderItemRequest, 0, len(subscription.Items))
+		for _, item := range subscription.Items {
+			items = append(items, &proto.OrderItemRequest{
+				ProductId: item.ProductId,
+				Quantity:  item.Quantity,
+			})
+		}
+
+		logrus.Infof("Ordering cycle %d of subscription %d", cycle.ID, subscription.Id)
+		response, err := u.orderService.CreateOrder(&proto.CreateOrderRequest{
+			UserId:          subscription.UserId,
+			Items:           items,
+			IdempotencyKey:  fmt.Sprintf("%s%d:%d:%d", subscriptionKeyPrefix, subscription.Id, cycle.ID, cycle.Attempts),
+			Region:          subscription.Region,
+			ShippingAddress: subscription.ShippingAddress,
+			ShippingCarrier: subscription.ShippingCarrier,
+			ShippingService: subscription.ShippingService,
+			Currency:        subscription.Currency,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("creating order: %s", status.Convert(err).Message())
+		}
+		cycle.OrderID = int(response.Order.Id)
+		cycle.LastError = ""
+
+		// The saga creates the payment of the order in the background
+		return subscriptionPaymentPoll, nil
+	}
+
+	order, err := u.orderRepo.GetOrderByID(&proto.GetOrderRequest{OrderId: int32(cycle.OrderID)}, u.DB)
+	if err != nil {
+		return 0, err
+	}
+
+	switch order.Status {
+	case OrderStatusPaid, OrderStatusFulfilled, OrderStatusCompleted:
+		logrus.Infof("Subscription %d cycle %d is paid with order %d", subscription.Id, cycle.ID, order.Id)
+		cycle.Status = CycleStatusPaid
+		cycle.LastError = ""
+		return 0, nil
+	case OrderStatusCancelled, OrderStatusFailed:
+		cycle.OrderID = 0
+		return 0, fmt.Errorf("order %d was %s", order.Id, order.Status)
+	}
+
+	payment, err := u.paymentRepo.GetPayment(&proto.GetPaymentRequest{OrderId: order.Id})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return subscriptionPaymentPoll, nil
+		}
+		return 0, fmt.Errorf("getting payment: %s", status.Convert(err).Message())
+	}
+	// A paid payment moves the order on through the payment events
+	if payment.Status != "pending" {
+		return subscriptionPaymentPoll, nil
+	}
+
+	// The payment fails when the order costs more than the subscription allows, dunning then retries
+	// the cycle with a new order at the prices of that time
+	money := payment.TotalPrice
+	if subscription.MaxAmount > 0 && subscription.MaxAmount < money {
+		money = subscription.MaxAmount
+	}
+
+	logrus.Infof("Paying order %d of subscription %d", order.Id, subscription.Id)
+	if _, err := u.paymentRepo.Transaction(&proto.PaymentTransaction{
+		PaymentId:      payment.Id,
+		Money:          money,
+		Currency:       payment.Currency,
+		IdempotencyKey: fmt.Sprintf("%s%d:%d", subscriptionKeyPrefix, cycle.ID, cycle.Attempts),
+	}); err != nil {
+		return 0, fmt.Errorf("paying order %d: %s", order.Id, status.Convert(err).Message())
+	}
+
+	return subscriptionPaymentPoll, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"order/proto"
+	"order/repository"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TaxService manages the tax rules and computes the taxes of orders. A rule applies to an order of its
+// region or of a subregion, and to the items of its tax class; an empty region or class matches all.
+type TaxService struct {
+	DB            *sql.DB
+	taxRepo       repository.TaxRepository
+	orderItemRepo repository.OrderItemsRepository
+}
+
+func NewTaxService(DB *sql.DB, taxRepo repository.TaxRepository, orderItemRepo repository.OrderItemsRepository) *TaxService {
+	return &TaxService{
+		DB:            DB,
+		taxRepo:       taxRepo,
+		orderItemRepo: orderItemRepo,
+	}
+}
+
+func (u *TaxService) CreateTaxRule(payload *proto.TaxRule) (*proto.TaxRule, error) {
+	if payload.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "tax rule name is required")
+	}
+	if payload.Rate <= 0 || payload.Rate > 100 {
+		return nil, status.Error(codes.InvalidArgument, "tax rate must be between 0 and 100")
+	}
+	payload.Region = strings.ToUpper(strings.TrimSpace(payload.Region))
+	payload.TaxClass = strings.TrimSpace(payload.TaxClass)
+
+	logrus.Infof("Creating tax rule %s for region %q and tax class %q", payload.Name, payload.Region, payload.TaxClass)
+	taxRuleID, err := u.taxRepo.CreateTaxRule(payload, u.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.taxRepo.GetTaxRule(taxRuleID, u.DB)
+}
+
+func (u *TaxService) ListTaxRules(payload *proto.ListTaxRulesRequest) ([]*proto.TaxRule, error) {
+	return u.taxRepo.ListTaxRules(payload.IncludeInactive, strings.ToUpper(payload.Region), u.DB)
+}
+
+func (u *TaxService) DeactivateTaxRule(payload *proto.GetTaxRuleRequest) (*proto.TaxRule, error) {
+	if err := u.taxRepo.DeactivateTaxRule(int(payload.TaxRuleId), u.DB); err != nil {
+		if errors.Is(err, repository.ErrTaxRuleNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	return u.taxRepo.GetTaxRule(int(payload.TaxRuleId), u.DB)
+}
+
+// ApplyTaxes stores the tax lines of every item and their sum per rule for the order, replacing the
+// ones it had before. Items are taxed on their price after discounts, the discounts of the order are
+// shared by the items in proportion to their price. The items get their tax lines and amount.
+func (u *TaxService) ApplyTaxes(order *proto.Order, items []*proto.OrderItem, pricing *orderPricing, tx *sql.Tx) ([]*proto.OrderTax, error) {
+	if err := u.taxRepo.DeleteOrderTaxes(int(order.Id), tx); err != nil {
+		return nil, err
+	}
+
+	taxRules, err := u.taxRepo.GetRegionTaxRules(order.Region, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderTaxes []*proto.OrderTax
+	orderTaxesByRule := make(map[int32]*proto.OrderTax)
+	for _, item := range items {
+		item.Taxes = nil
+		item.TaxAmount = 0
+		taxableAmount := scaleAmount(int64(item.Quantity)*item.Price, pricing.Subtotal-pricing.DiscountTotal, pricing.Subtotal)
+
+		for _, rule := range taxRules {
+			if rule.TaxClass != "" && !strings.EqualFold(rule.TaxClass, item.TaxClass) {
+				continue
+			}
+
+			itemTax := &proto.OrderTax{
+				OrderId:       order.Id,
+				OrderItemId:   item.Id,
+				TaxRuleId:     rule.Id,
+				Name:          rule.Name,
+				Rate:          rule.Rate,
+				TaxableAmount: taxableAmount,
+				Amount:        percentOf(taxableAmount, rule.Rate),
+			}
+			if err := u.taxRepo.CreateOrderItemTax(itemTax, tx); err != nil {
+				return nil, err
+			}
+			item.Taxes = append(item.Taxes, itemTax)
+			item.TaxAmount += itemTax.Amount
+
+			orderTax, ok := orderTaxesByRule[rule.Id]
+			if !ok {
+				orderTax = &proto.OrderTax{
+					OrderId:   order.Id,
+					TaxRuleId: rule.Id,
+					Name:      rule.Name,
+					Rate:      rule.Rate,
+				}
+				orderTaxesByRule[rule.Id] = orderTax
+				orderTaxes = append(orderTaxes, orderTax)
+			}
+			orderTax.TaxableAmount += itemTax.TaxableAmount
+			orderTax.Amount += itemTax.Amount
+		}
+
+		if err := u.orderItemRepo.UpdateOrderItemTax(int(item.Id), item.TaxAmount, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range orderTaxes {
+		if err := u.taxRepo.CreateOrderTax(v, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	return orderTaxes, nil
+}
+
+// AttachTaxes adds the stored tax lines to an order and its items.
+func (u *TaxService) AttachTaxes(order *proto.Order) error {
+	orderTaxes, err := u.taxRepo.GetOrderTaxes(int(order.Id), u.DB)
+	if err != nil {
+		return err
+	}
+	order.Taxes = orderTaxes
+
+	itemTaxes, err := u.taxRepo.GetOrderItemTaxes(int(order.Id), u.DB)
+	if err != nil {
+		return err
+	}
+	itemsByID := make(map[int32]*proto.OrderItem, len(order.OrderItems))
+	for _, v := range order.OrderItems {
+		itemsByID[v.Id] = v
+	}
+	for _, v := range itemTaxes {
+		if item, ok := itemsByID[v.OrderItemId]; ok {
+			item.Taxes = append(item.Taxes, v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package grpc
+
+import (
+	"context"
+	"order/proto"
+	"order/service"
+	"slices"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Metadata keys the broker sets with the user of the JWT, and the key the internal services set with
+// their name.
+const (
+	metadataUserID  = "x-user-id"
+	metadataRole    = "x-user-role"
+	metadataService = "x-internal-service"
+)
+
+const roleAdmin = "admin"
+
+// internalServices are the services trusted to call without a user, the broker always calls for one.
+var internalServices = []string{"order-service", "payment-service"}
+
+// adminMethods can only be called by admins and by the internalServices.
+var adminMethods = map[string]bool{
+	proto.OrderService_UpdateOrderStatus_FullMethodName:    true,
+	proto.OrderService_ApproveReturn_FullMethodName:        true,
+	proto.OrderService_RejectReturn_FullMethodName:         true,
+	proto.OrderService_CreatePromotion_FullMethodName:      true,
+	proto.OrderService_ListPromotions_FullMethodName:       true,
+	proto.OrderService_DeactivatePromotion_FullMethodName:  true,
+	proto.OrderService_CreateTaxRule_FullMethodName:        true,
+	proto.OrderService_ListTaxRules_FullMethodName:         true,
+	proto.OrderService_DeactivateTaxRule_FullMethodName:    true,
+	proto.OrderService_SetExchangeRate_FullMethodName:      true,
+	proto.OrderService_CreateShipment_FullMethodName:       true,
+	proto.OrderService_UpdateShipmentStatus_FullMethodName: true,
+	proto.OrderService_GetRevenueReport_FullMethodName:     true,
+	proto.OrderService_GetTopProducts_FullMethodName:       true,
+	proto.OrderService_GetOrderValueReport_FullMethodName:  true,
+}
+
+// ownerMethods create or change what belongs to the caller, admins keep their own user_id on them.
+var ownerMethods = map[string]bool{
+	proto.OrderService_CreateOrder_FullMethodName:               true,
+	proto.OrderService_CreateReturn_FullMethodName:              true,
+	proto.CartService_GetCart_FullMethodName:                    true,
+	proto.CartService_AddCartItem_FullMethodName:                true,
+	proto.CartService_UpdateCartItem_FullMethodName:             true,
+	proto.CartService_RemoveCartItem_FullMethodName:             true,
+	proto.CartService_ClearCart_FullMethodName:                  true,
+	proto.CartService_Checkout_FullMethodName:                   true,
+	proto.SubscriptionService_CreateSubscription_FullMethodName: true,
+}
+
+// authorizeUnary checks the caller of a unary call, see authorize.
+func authorizeUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := authorize(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authorizeStream checks the caller of a streaming call when its request is received, see authorize.
+func authorizeStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &authorizedStream{ServerStream: ss, method: info.FullMethod})
+}
+
+type authorizedStream struct {
+	grpc.ServerStream
+	method string
+}
+
+func (s *authorizedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return authorize(s.Context(), s.method, m)
+}
+
+// authorize lets admins call everything and keeps users to their own resources: the user_id of their
+// request is set to them, the services then answer NotFound for orders, returns, carts, subscriptions
+// and payments of other users. The user_id of admins is cleared so they reach the resources of every
+// user, except on ownerMethods. Calls without a user are only trusted from the internalServices,
+// users cannot send the idempotency keys the services make up for themselves.
+func authorize(ctx context.Context, method string, req any) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	userIDs := md.Get(metadataUserID)
+	if len(userIDs) == 0 {
+		if !slices.ContainsFunc(md.Get(metadataService), func(name string) bool {
+			return slices.Contains(internalServices, name)
+		}) {
+			return status.Error(codes.Unauthenticated, "missing caller")
+		}
+		return nil
+	}
+	if service.ReservedIdempotencyKey(idempotencyKey(req)) {
+		return status.Error(codes.InvalidArgument, "idempotency key uses a reserved prefix")
+	}
+	admin := slices.Contains(md.Get(metadataRole), roleAdmin)
+	if adminMethods[method] && !admin {
+		return status.Error(codes.PermissionDenied, "admin role required")
+	}
+	if admin {
+		if !ownerMethods[method] {
+			setUserID(req, 0)
+		}
+		return nil
+	}
+
+	userID, err := strconv.Atoi(userIDs[0])
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "invalid user ID")
+	}
+	setUserID(req, userID)
+	return nil
+}
+
+// setUserID sets the user_id field of req, if it has one.
+func setUserID(req any, userID int) {
+	message, ok := req.(protoreflect.ProtoMessage)
+	if !ok {
+		return
+	}
+	field := message.ProtoReflect().Descriptor().Fields().ByName("user_id")
+	if field != nil && field.Kind() == protoreflect.Int32Kind {
+		message.ProtoReflect().Set(field, protoreflect.ValueOfInt32(int32(userID)))
+	}
+}
+
+// idempotencyKey returns the idempotency_key field of req, if it has one.
+func idempotencyKey(req any) string {
+	message, ok := req.(protoreflect.ProtoMessage)
+	if !ok {
+		return ""
+	}
+	field := message.ProtoReflect().Descriptor().Fields().ByName("idempotency_key")
+	if field == nil || field.Kind() != protoreflect.StringKind {
+		return ""
+	}
+	return message.ProtoReflect().Get(field).String()
+}
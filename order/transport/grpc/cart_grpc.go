@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"context"
+	"order/proto"
+	"order/service"
+)
+
+type CartGRPCServer struct {
+	service *service.CartService
+	proto.UnimplementedCartServiceServer
+}
+
+func NewCartGRPCServer(service *service.CartService) *CartGRPCServer {
+	return &CartGRPCServer{
+		service: service,
+	}
+}
+
+func (u *CartGRPCServer) GetCart(ctx context.Context, req *proto.GetCartRequest) (*proto.Cart, error) {
+	return u.service.GetCart(req)
+}
+
+func (u *CartGRPCServer) AddCartItem(ctx context.Context, req *proto.CartItemRequest) (*proto.Cart, error) {
+	return u.service.AddCartItem(req)
+}
+
+func (u *CartGRPCServer) UpdateCartItem(ctx context.Context, req *proto.CartItemRequest) (*proto.Cart, error) {
+	return u.service.UpdateCartItem(req)
+}
+
+func (u *CartGRPCServer) RemoveCartItem(ctx context.Context, req *proto.RemoveCartItemRequest) (*proto.Cart, error) {
+	return u.service.RemoveCartItem(req)
+}
+
+func (u *CartGRPCServer) ClearCart(ctx context.Context, req *proto.GetCartRequest) (*proto.Cart, error) {
+	return u.service.ClearCart(req)
+}
+
+func (u *CartGRPCServer) Checkout(ctx context.Context, req *proto.CheckoutRequest) (*proto.OrderResponse, error) {
+	return u.service.Checkout(req)
+}
@@ -1,89 +1,357 @@
-package grpc
-
-import (
-	"context"
-	"net"
-	"order/cmd/db"
-	"order/proto"
-	"order/repository"
-	"order/service"
-	"order/transport/kafka"
-	"os"
-
-	"github.com/sirupsen/logrus"
-	"google.golang.org/grpc"
-)
-
-type OrderGRPCServer struct {
-	service *service.OrderService
-	proto.UnimplementedOrderServiceServer
-}
-
-func NewOrderGRPCServer(service *service.OrderService) *OrderGRPCServer {
-	return &OrderGRPCServer{
-		service: service,
-	}
-}
-
-func (u *OrderGRPCServer) CreateOrder(ctx context.Context, req *proto.CreateOrderRequest) (*proto.OrderResponse, error) {
-	logrus.Info("create order")
-
-	order, err := u.service.CreateOrder(req)
-	if err != nil {
-		return nil, err
-	}
-
-	return order, nil
-}
-
-func (u *OrderGRPCServer) GetOrder(ctx context.Context, req *proto.GetOrderRequest) (*proto.OrderResponse, error) {
-	order, err := u.service.GetOrderByID(req)
-	if err != nil {
-		return nil, err
-	}
-
-	return &proto.OrderResponse{
-		Order: order,
-	}, nil
-}
-
-func (u *OrderGRPCServer) UpdateOrderStatus(ctx context.Context, req *proto.UpdateOrderStatusRequest) (*proto.EmptyOrder, error) {
-	if err := u.service.UpdateOrderStatus(req.Status, int(req.OrderId)); err != nil {
-		return nil, err
-	}
-
-	return &proto.EmptyOrder{}, nil
-}
-
-func GRPCListen() {
-	DB, err := db.Connect()
-	if err != nil {
-		logrus.Fatalf("failed to connect to database: %v", err)
-	}
-
-	addr := []string{os.Getenv("KAFKA_BROKER_URL")}
-
-	orderRepo := repository.NewOrderRepositoryImpl()
-	orderItemRepo := repository.NewOrderItemsRepositoryImpl()
-	productRepo := repository.NewProductRepositoryImpl()
-
-	orderService := service.NewOrderItemService(DB, orderRepo, orderItemRepo, productRepo)
-	orderGRPC := NewOrderGRPCServer(orderService)
-
-	if err := kafka.ConnectProducer(addr); err != nil {
-		logrus.Fatalf("failed to connect to kafka: %v", err)
-	}
-
-	conn, err := net.Listen("tcp", ":30001")
-	if err != nil {
-		logrus.Fatalf("failed to listen for gRPC: %v", err)
-	}
-
-	srv := grpc.NewServer()
-	proto.RegisterOrderServiceServer(srv, orderGRPC)
-	logrus.Infof("gRPC Server started on port 30001")
-
-	if err := srv.Serve(conn); err != nil {
-		logrus.Fatalf("error when connect to gRPC Server  %v", err)
-	}
-}
+package grpc
+
+import (
+	"context"
+	"net"
+	"order/cmd/db"
+	"order/proto"
+	"order/repository"
+	"order/service"
+	"order/transport/kafka"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+type OrderGRPCServer struct {
+	service          *service.OrderService
+	returnService    *service.ReturnService
+	promotionService *service.PromotionService
+	taxService       *service.TaxService
+	shippingService  *service.ShippingService
+	exchangeRates    *service.ExchangeRateService
+	orderWatcher     *service.OrderWatcher
+	fulfillment      *service.FulfillmentService
+	reports          *service.ReportService
+	proto.UnimplementedOrderServiceServer
+}
+
+func NewOrderGRPCServer(service *service.OrderService, returnService *service.ReturnService, promotionService *service.PromotionService, taxService *service.TaxService, shippingService *service.ShippingService, exchangeRates *service.ExchangeRateService, orderWatcher *service.OrderWatcher, fulfillment *service.FulfillmentService, reports *service.ReportService) *OrderGRPCServer {
+	return &OrderGRPCServer{
+		service:          service,
+		returnService:    returnService,
+		promotionService: promotionService,
+		taxService:       taxService,
+		shippingService:  shippingService,
+		exchangeRates:    exchangeRates,
+		orderWatcher:     orderWatcher,
+		fulfillment:      fulfillment,
+		reports:          reports,
+	}
+}
+
+func (u *OrderGRPCServer) CreateOrder(ctx context.Context, req *proto.CreateOrderRequest) (*proto.OrderResponse, error) {
+	logrus.Info("create order")
+
+	order, err := u.service.CreateOrder(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (u *OrderGRPCServer) GetOrder(ctx context.Context, req *proto.GetOrderRequest) (*proto.OrderResponse, error) {
+	order, err := u.service.GetOrderByID(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.OrderResponse{
+		Order: order,
+	}, nil
+}
+
+func (u *OrderGRPCServer) UpdateOrderStatus(ctx context.Context, req *proto.UpdateOrderStatusRequest) (*proto.EmptyOrder, error) {
+	if err := u.service.UpdateOrderStatus(req); err != nil {
+		return nil, err
+	}
+
+	return &proto.EmptyOrder{}, nil
+}
+
+func (u *OrderGRPCServer) ListOrders(ctx context.Context, req *proto.ListOrdersRequest) (*proto.ListOrdersResponse, error) {
+	orders, err := u.service.ListOrders(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (u *OrderGRPCServer) CancelOrder(ctx context.Context, req *proto.CancelOrderRequest) (*proto.OrderResponse, error) {
+	order, err := u.service.CancelOrder(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.OrderResponse{
+		Order: order,
+	}, nil
+}
+
+func (u *OrderGRPCServer) GetOrderHistory(ctx context.Context, req *proto.GetOrderHistoryRequest) (*proto.GetOrderHistoryResponse, error) {
+	history, err := u.service.GetOrderHistory(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.GetOrderHistoryResponse{
+		History: history,
+	}, nil
+}
+
+func (u *OrderGRPCServer) CreateReturn(ctx context.Context, req *proto.CreateReturnRequest) (*proto.OrderReturn, error) {
+	return u.returnService.CreateReturn(req)
+}
+
+func (u *OrderGRPCServer) ApproveReturn(ctx context.Context, req *proto.ReviewReturnRequest) (*proto.OrderReturn, error) {
+	return u.returnService.ApproveReturn(req)
+}
+
+func (u *OrderGRPCServer) RejectReturn(ctx context.Context, req *proto.ReviewReturnRequest) (*proto.OrderReturn, error) {
+	return u.returnService.RejectReturn(req)
+}
+
+func (u *OrderGRPCServer) GetReturn(ctx context.Context, req *proto.GetReturnRequest) (*proto.OrderReturn, error) {
+	return u.returnService.GetReturn(req)
+}
+
+func (u *OrderGRPCServer) ListReturns(ctx context.Context, req *proto.ListReturnsRequest) (*proto.ListReturnsResponse, error) {
+	orderReturns, err := u.returnService.ListReturns(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ListReturnsResponse{
+		Returns: orderReturns,
+	}, nil
+}
+
+func (u *OrderGRPCServer) AddOrderItem(ctx context.Context, req *proto.AddOrderItemRequest) (*proto.OrderResponse, error) {
+	return u.service.AddOrderItem(req)
+}
+
+func (u *OrderGRPCServer) UpdateOrderItemQuantity(ctx context.Context, req *proto.UpdateOrderItemQuantityRequest) (*proto.OrderResponse, error) {
+	return u.service.UpdateOrderItemQuantity(req)
+}
+
+func (u *OrderGRPCServer) RemoveOrderItem(ctx context.Context, req *proto.RemoveOrderItemRequest) (*proto.OrderResponse, error) {
+	return u.service.RemoveOrderItem(req)
+}
+
+func (u *OrderGRPCServer) CreatePromotion(ctx context.Context, req *proto.Promotion) (*proto.Promotion, error) {
+	return u.promotionService.CreatePromotion(req)
+}
+
+func (u *OrderGRPCServer) ListPromotions(ctx context.Context, req *proto.ListPromotionsRequest) (*proto.ListPromotionsResponse, error) {
+	promotions, err := u.promotionService.ListPromotions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ListPromotionsResponse{
+		Promotions: promotions,
+	}, nil
+}
+
+func (u *OrderGRPCServer) DeactivatePromotion(ctx context.Context, req *proto.GetPromotionRequest) (*proto.Promotion, error) {
+	return u.promotionService.DeactivatePromotion(req)
+}
+
+func (u *OrderGRPCServer) CreateTaxRule(ctx context.Context, req *proto.TaxRule) (*proto.TaxRule, error) {
+	return u.taxService.CreateTaxRule(req)
+}
+
+func (u *OrderGRPCServer) ListTaxRules(ctx context.Context, req *proto.ListTaxRulesRequest) (*proto.ListTaxRulesResponse, error) {
+	taxRules, err := u.taxService.ListTaxRules(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ListTaxRulesResponse{
+		TaxRules: taxRules,
+	}, nil
+}
+
+func (u *OrderGRPCServer) DeactivateTaxRule(ctx context.Context, req *proto.GetTaxRuleRequest) (*proto.TaxRule, error) {
+	return u.taxService.DeactivateTaxRule(req)
+}
+
+func (u *OrderGRPCServer) GetShippingQuotes(ctx context.Context, req *proto.GetShippingQuotesRequest) (*proto.GetShippingQuotesResponse, error) {
+	return u.shippingService.GetShippingQuotes(req)
+}
+
+func (u *OrderGRPCServer) WatchOrder(req *proto.WatchOrderRequest, stream proto.OrderService_WatchOrderServer) error {
+	logrus.Infof("watch order %d", req.OrderId)
+	return u.orderWatcher.WatchOrder(stream.Context(), req, stream.Send)
+}
+
+func (u *OrderGRPCServer) SetExchangeRate(ctx context.Context, req *proto.ExchangeRate) (*proto.ExchangeRate, error) {
+	return u.exchangeRates.SetExchangeRate(req)
+}
+
+func (u *OrderGRPCServer) GetExchangeRate(ctx context.Context, req *proto.GetExchangeRateRequest) (*proto.ExchangeRate, error) {
+	return u.exchangeRates.GetExchangeRate(req)
+}
+
+func (u *OrderGRPCServer) ListExchangeRates(ctx context.Context, req *proto.ListExchangeRatesRequest) (*proto.ListExchangeRatesResponse, error) {
+	exchangeRates, err := u.exchangeRates.ListExchangeRates(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ListExchangeRatesResponse{
+		ExchangeRates: exchangeRates,
+	}, nil
+}
+
+func (u *OrderGRPCServer) ConvertAmounts(ctx context.Context, req *proto.ConvertAmountsRequest) (*proto.ConvertAmountsResponse, error) {
+	return u.exchangeRates.ConvertAmounts(req)
+}
+
+func (u *OrderGRPCServer) CreateShipment(ctx context.Context, req *proto.CreateShipmentRequest) (*proto.Shipment, error) {
+	return u.fulfillment.CreateShipment(req)
+}
+
+func (u *OrderGRPCServer) UpdateShipmentStatus(ctx context.Context, req *proto.UpdateShipmentStatusRequest) (*proto.Shipment, error) {
+	return u.fulfillment.UpdateShipmentStatus(req)
+}
+
+func (u *OrderGRPCServer) GetShipment(ctx context.Context, req *proto.GetShipmentRequest) (*proto.Shipment, error) {
+	return u.fulfillment.GetShipment(req)
+}
+
+func (u *OrderGRPCServer) ListShipments(ctx context.Context, req *proto.ListShipmentsRequest) (*proto.ListShipmentsResponse, error) {
+	shipments, err := u.fulfillment.ListShipments(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ListShipmentsResponse{
+		Shipments: shipments,
+	}, nil
+}
+
+func (u *OrderGRPCServer) GetRevenueReport(ctx context.Context, req *proto.ReportRequest) (*proto.RevenueReport, error) {
+	report, err := u.reports.GetRevenueReport(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (u *OrderGRPCServer) GetTopProducts(ctx context.Context, req *proto.ReportRequest) (*proto.TopProductsReport, error) {
+	report, err := u.reports.GetTopProducts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (u *OrderGRPCServer) GetOrderValueReport(ctx context.Context, req *proto.ReportRequest) (*proto.OrderValueReport, error) {
+	report, err := u.reports.GetOrderValueReport(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func GRPCListen() {
+	DB, err := db.Connect()
+	if err != nil {
+		logrus.Fatalf("failed to connect to database: %v", err)
+	}
+
+	addr := []string{os.Getenv("KAFKA_BROKER_URL")}
+
+	orderRepo := repository.NewOrderRepositoryImpl()
+	orderItemRepo := repository.NewOrderItemsRepositoryImpl()
+	productRepo := repository.NewProductRepositoryImpl()
+	outboxRepo := repository.NewOutboxRepositoryImpl()
+	paymentRepo := repository.NewPaymentRepositoryImpl()
+	sagaRepo := repository.NewSagaRepositoryImpl()
+	idempotencyRepo := repository.NewIdempotencyRepositoryImpl()
+	historyRepo := repository.NewOrderHistoryRepositoryImpl()
+	returnRepo := repository.NewReturnRepositoryImpl()
+	cartRepo := repository.NewCartRepositoryImpl()
+	promotionRepo := repository.NewPromotionRepositoryImpl()
+	taxRepo := repository.NewTaxRepositoryImpl()
+	shippingRepo := repository.NewShippingRepositoryImpl()
+	exchangeRateRepo := repository.NewExchangeRateRepositoryImpl()
+	shipmentRepo := repository.NewShipmentRepositoryImpl()
+	reportRepo := repository.NewReportRepositoryImpl()
+	subscriptionRepo := repository.NewSubscriptionRepositoryImpl()
+
+	orderSaga := service.NewOrderSaga(DB, sagaRepo, orderRepo, productRepo, paymentRepo, historyRepo, promotionRepo)
+	promotionService := service.NewPromotionService(DB, promotionRepo)
+	taxService := service.NewTaxService(DB, taxRepo, orderItemRepo)
+	exchangeRateService := service.NewExchangeRateService(DB, exchangeRateRepo)
+	if path := os.Getenv("EXCHANGE_RATES_FILE"); path != "" {
+		if err := exchangeRateService.LoadFile(path); err != nil {
+			logrus.Fatalf("failed to load exchange rates: %v", err)
+		}
+	}
+	shippingService := service.NewShippingService(DB, shippingRepo, productRepo, exchangeRateService,
+		service.NewTableRateProvider(DB, shippingRepo),
+		service.NewLocalCourierProvider(),
+	)
+	orderService := service.NewOrderItemService(DB, orderRepo, orderItemRepo, productRepo, paymentRepo, outboxRepo, idempotencyRepo, historyRepo, shipmentRepo, promotionService, taxService, shippingService, exchangeRateService, orderSaga)
+	returnService := service.NewReturnService(DB, orderRepo, orderItemRepo, returnRepo, productRepo, paymentRepo)
+	cartService := service.NewCartService(DB, cartRepo, productRepo, idempotencyRepo, exchangeRateService, orderService)
+	orderWatcher := service.NewOrderWatcher(DB, orderRepo, historyRepo)
+	fulfillmentService := service.NewFulfillmentService(DB, orderRepo, orderItemRepo, shipmentRepo, shippingRepo, historyRepo)
+	reportService := service.NewReportService(DB, reportRepo)
+	orderGRPC := NewOrderGRPCServer(orderService, returnService, promotionService, taxService, shippingService, exchangeRateService, orderWatcher, fulfillmentService, reportService)
+	subscriptionService := service.NewSubscriptionService(DB, subscriptionRepo, orderRepo, productRepo, paymentRepo, orderService)
+	cartGRPC := NewCartGRPCServer(cartService)
+	subscriptionGRPC := NewSubscriptionGRPCServer(subscriptionService)
+
+	if err := kafka.ConnectProducer(addr); err != nil {
+		logrus.Fatalf("failed to connect to kafka: %v", err)
+	}
+
+	outboxRelay := service.NewOutboxRelay(DB, outboxRepo)
+	go outboxRelay.Run(context.Background())
+	go orderSaga.Run(context.Background())
+	go orderService.ExpirePendingOrders(context.Background())
+	go orderWatcher.Run(context.Background())
+	go subscriptionService.Run(context.Background())
+	go kafka.ConsumeEvents(addr, []string{paymentTopic()}, paymentGroupID(), orderService.HandlePaymentEvent)
+
+	conn, err := net.Listen("tcp", ":30001")
+	if err != nil {
+		logrus.Fatalf("failed to listen for gRPC: %v", err)
+	}
+
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(authorizeUnary),
+		grpc.StreamInterceptor(authorizeStream),
+	)
+	proto.RegisterOrderServiceServer(srv, orderGRPC)
+	proto.RegisterCartServiceServer(srv, cartGRPC)
+	proto.RegisterSubscriptionServiceServer(srv, subscriptionGRPC)
+	logrus.Infof("gRPC Server started on port 30001")
+
+	if err := srv.Serve(conn); err != nil {
+		logrus.Fatalf("error when connect to gRPC Server  %v", err)
+	}
+}
+
+func paymentTopic() string {
+	if topic := os.Getenv("KAFKA_PAYMENT_TOPIC"); topic != "" {
+		return topic
+	}
+	return "payment.events"
+}
+
+func paymentGroupID() string {
+	if groupID := os.Getenv("KAFKA_PAYMENT_GROUP_ID"); groupID != "" {
+		return groupID
+	}
+	return "order-payment-group"
+}
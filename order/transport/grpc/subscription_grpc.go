@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"context"
+	"order/proto"
+	"order/service"
+)
+
+type SubscriptionGRPCServer struct {
+	service *service.SubscriptionService
+	proto.UnimplementedSubscriptionServiceServer
+}
+
+func NewSubscriptionGRPCServer(service *service.SubscriptionService) *SubscriptionGRPCServer {
+	return &SubscriptionGRPCServer{
+		service: service,
+	}
+}
+
+func (u *SubscriptionGRPCServer) CreateSubscription(ctx context.Context, req *proto.CreateSubscriptionRequest) (*proto.Subscription, error) {
+	return u.service.CreateSubscription(req)
+}
+
+func (u *SubscriptionGRPCServer) GetSubscription(ctx context.Context, req *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	return u.service.GetSubscription(req)
+}
+
+func (u *SubscriptionGRPCServer) ListSubscriptions(ctx context.Context, req *proto.ListSubscriptionsRequest) (*proto.ListSubscriptionsResponse, error) {
+	subscriptions, err := u.service.ListSubscriptions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ListSubscriptionsResponse{
+		Subscriptions: subscriptions,
+	}, nil
+}
+
+func (u *SubscriptionGRPCServer) PauseSubscription(ctx context.Context, req *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	return u.service.PauseSubscription(req)
+}
+
+func (u *SubscriptionGRPCServer) ResumeSubscription(ctx context.Context, req *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	return u.service.ResumeSubscription(req)
+}
+
+func (u *SubscriptionGRPCServer) SkipSubscriptionCycle(ctx context.Context, req *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	return u.service.SkipSubscriptionCycle(req)
+}
+
+func (u *SubscriptionGRPCServer) CancelSubscription(ctx context.Context, req *proto.GetSubscriptionRequest) (*proto.Subscription, error) {
+	return u.service.CancelSubscription(req)
+}
@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"crypto/rand"
+	"fmt"
+	"order/proto"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Event types published by the order service, consumers dispatch on them.
+const (
+	EventOrderCreated   = "order.created"
+	EventOrderCancelled = "order.cancelled"
+	EventOrderExpired   = "order.expired"
+)
+
+// OrderEventVersion is the version of the proto.Order payload of the order events.
+const OrderEventVersion = 1
+
+// Headers of every event message, consumers can route a message without decoding it.
+const (
+	HeaderEventType     = "event-type"
+	HeaderEventVersion  = "event-version"
+	HeaderContentType   = "content-type"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// MarshalEvent wraps payload in an EventEnvelope and encodes it, the result is what the outbox stores
+// and SendMessage publishes. version is the version of the payload of eventType, it changes when
+// consumers need to tell an old payload from a new one.
+func MarshalEvent(eventType string, version int32, aggregateID int, payload protobuf.Message) ([]byte, error) {
+	eventID, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+	data, err := anypb.New(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return protobuf.Marshal(&proto.EventEnvelope{
+		EventId:     eventID,
+		Type:        eventType,
+		Version:     version,
+		OccurredAt:  timestamppb.New(time.Now()),
+		AggregateId: strconv.Itoa(aggregateID),
+		Payload:     data,
+	})
+}
+
+// eventHeaders returns the headers of an encoded EventEnvelope. Outbox rows written before the envelope
+// hold JSON, they get no headers and consumers read them as legacy messages.
+func eventHeaders(data []byte) []sarama.RecordHeader {
+	envelope := &proto.EventEnvelope{}
+	if err := protobuf.Unmarshal(data, envelope); err != nil || envelope.Type == "" {
+		return nil
+	}
+
+	return []sarama.RecordHeader{
+		{Key: []byte(HeaderEventType), Value: []byte(envelope.Type)},
+		{Key: []byte(HeaderEventVersion), Value: []byte(strconv.Itoa(int(envelope.Version)))},
+		{Key: []byte(HeaderContentType), Value: []byte(ContentTypeProtobuf)},
+	}
+}
+
+// newEventID returns a random (version 4) UUID.
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,147 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"order/proto"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// Event types published by the payment service, version 1 carries a proto.PaymentResult.
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+)
+
+const (
+	handlerBaseBackoff = time.Second
+	handlerMaxBackoff  = time.Minute
+)
+
+// EventHandler handles one event, an error means the event should be handled again later.
+type EventHandler func(envelope *proto.EventEnvelope) error
+
+type ConsumerHandler struct {
+	handle EventHandler
+}
+
+func connectKafka(addr []string, groupID string) (sarama.ConsumerGroup, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Group.Rebalance.Strategy = sarama.NewBalanceStrategyRange()
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Return.Errors = true
+
+	var CGError error
+
+	for i := 0; i < 5; i++ {
+		worker, err := sarama.NewConsumerGroup(addr, groupID, config)
+		if err == nil {
+			logrus.Info("Connected to kafka")
+			return worker, nil
+		}
+
+		CGError = err
+		logrus.Warnf("failed connect to kafka, retrying...(%d/5)", i+1)
+		time.Sleep(5 * time.Second)
+		continue
+	}
+
+	return nil, CGError
+}
+
+// ConsumeEvents passes the events of topic to handle until the service is stopped. An event is only
+// committed once handle succeeds, so events that arrive while the database or another service is
+// down are handled when it is back, and again after a restart if they were not handled before.
+func ConsumeEvents(addr []string, topic []string, groupID string, handle EventHandler) {
+	sigchan := make(chan os.Signal, 1)
+	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
+
+	consumerGroup, err := connectKafka(addr, groupID)
+	if err != nil {
+		logrus.Fatalf("error when connect to kafka: %v", err)
+	}
+	defer consumerGroup.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handler := &ConsumerHandler{handle: handle}
+
+	go func() {
+		logrus.Infof("addr: %s topic: %s groupID: %s", addr, topic, groupID)
+		for {
+			select {
+			case <-ctx.Done():
+				logrus.Info("Consumer stopping...")
+				return
+			default:
+				if err := consumerGroup.Consume(ctx, topic, handler); err != nil {
+					logrus.Errorf("failed when consume partition, retrying: %v", err)
+					time.Sleep(2 * time.Second)
+				}
+			}
+		}
+	}()
+
+	<-sigchan
+	logrus.Info("shutting down consumer...")
+	cancel()
+}
+
+func (h *ConsumerHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
+func (h *ConsumerHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
+
+func (h *ConsumerHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		envelope, err := decodeEvent(msg)
+		if err != nil {
+			logrus.Errorf("Skipping message at offset %d: %v", msg.Offset, err)
+			sess.MarkMessage(msg, "")
+			continue
+		}
+
+		// Later events of the partition wait, the events of an order have to be handled in order
+		backoff := handlerBaseBackoff
+		for {
+			err := h.handle(envelope)
+			if err == nil {
+				break
+			}
+			logrus.Errorf("Error handling %s event %s, retrying in %s: %v", envelope.Type, envelope.EventId, backoff, err)
+			select {
+			case <-sess.Context().Done():
+				return nil
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, handlerMaxBackoff)
+		}
+
+		sess.MarkMessage(msg, "")
+	}
+
+	return nil
+}
+
+// decodeEvent reads the EventEnvelope of a message published by SendMessage.
+func decodeEvent(msg *sarama.ConsumerMessage) (*proto.EventEnvelope, error) {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, v := range msg.Headers {
+		headers[string(v.Key)] = string(v.Value)
+	}
+	if headers[HeaderContentType] != ContentTypeProtobuf {
+		return nil, errors.New("message is not an event envelope")
+	}
+
+	envelope := &proto.EventEnvelope{}
+	if err := protobuf.Unmarshal(msg.Value, envelope); err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
@@ -19,8 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PaymentService_PayOrder_FullMethodName    = "/payment.PaymentService/PayOrder"
-	PaymentService_Transaction_FullMethodName = "/payment.PaymentService/Transaction"
+	PaymentService_PayOrder_FullMethodName            = "/payment.PaymentService/PayOrder"
+	PaymentService_Transaction_FullMethodName         = "/payment.PaymentService/Transaction"
+	PaymentService_GetPayment_FullMethodName          = "/payment.PaymentService/GetPayment"
+	PaymentService_VoidPayment_FullMethodName         = "/payment.PaymentService/VoidPayment"
+	PaymentService_ExpirePayment_FullMethodName       = "/payment.PaymentService/ExpirePayment"
+	PaymentService_RefundPayment_FullMethodName       = "/payment.PaymentService/RefundPayment"
+	PaymentService_UpdatePaymentAmount_FullMethodName = "/payment.PaymentService/UpdatePaymentAmount"
+	PaymentService_GetPaymentReport_FullMethodName    = "/payment.PaymentService/GetPaymentReport"
 )
 
 // PaymentServiceClient is the client API for PaymentService service.
@@ -29,6 +35,12 @@ const (
 type PaymentServiceClient interface {
 	PayOrder(ctx context.Context, in *CreatePaymentRequest, opts ...grpc.CallOption) (*OrderPayment, error)
 	Transaction(ctx context.Context, in *PaymentTransaction, opts ...grpc.CallOption) (*EmptyPayment, error)
+	GetPayment(ctx context.Context, in *GetPaymentRequest, opts ...grpc.CallOption) (*OrderPayment, error)
+	VoidPayment(ctx context.Context, in *GetPaymentRequest, opts ...grpc.CallOption) (*OrderPayment, error)
+	ExpirePayment(ctx context.Context, in *GetPaymentRequest, opts ...grpc.CallOption) (*OrderPayment, error)
+	RefundPayment(ctx context.Context, in *RefundPaymentRequest, opts ...grpc.CallOption) (*PaymentRefund, error)
+	UpdatePaymentAmount(ctx context.Context, in *UpdatePaymentAmountRequest, opts ...grpc.CallOption) (*OrderPayment, error)
+	GetPaymentReport(ctx context.Context, in *PaymentReportRequest, opts ...grpc.CallOption) (*PaymentReport, error)
 }
 
 type paymentServiceClient struct {
@@ -59,12 +71,78 @@ func (c *paymentServiceClient) Transaction(ctx context.Context, in *PaymentTrans
 	return out, nil
 }
 
+func (c *paymentServiceClient) GetPayment(ctx context.Context, in *GetPaymentRequest, opts ...grpc.CallOption) (*OrderPayment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderPayment)
+	err := c.cc.Invoke(ctx, PaymentService_GetPayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) VoidPayment(ctx context.Context, in *GetPaymentRequest, opts ...grpc.CallOption) (*OrderPayment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderPayment)
+	err := c.cc.Invoke(ctx, PaymentService_VoidPayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) ExpirePayment(ctx context.Context, in *GetPaymentRequest, opts ...grpc.CallOption) (*OrderPayment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderPayment)
+	err := c.cc.Invoke(ctx, PaymentService_ExpirePayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) RefundPayment(ctx context.Context, in *RefundPaymentRequest, opts ...grpc.CallOption) (*PaymentRefund, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PaymentRefund)
+	err := c.cc.Invoke(ctx, PaymentService_RefundPayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) UpdatePaymentAmount(ctx context.Context, in *UpdatePaymentAmountRequest, opts ...grpc.CallOption) (*OrderPayment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderPayment)
+	err := c.cc.Invoke(ctx, PaymentService_UpdatePaymentAmount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) GetPaymentReport(ctx context.Context, in *PaymentReportRequest, opts ...grpc.CallOption) (*PaymentReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PaymentReport)
+	err := c.cc.Invoke(ctx, PaymentService_GetPaymentReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentServiceServer is the server API for PaymentService service.
 // All implementations must embed UnimplementedPaymentServiceServer
 // for forward compatibility.
 type PaymentServiceServer interface {
 	PayOrder(context.Context, *CreatePaymentRequest) (*OrderPayment, error)
 	Transaction(context.Context, *PaymentTransaction) (*EmptyPayment, error)
+	GetPayment(context.Context, *GetPaymentRequest) (*OrderPayment, error)
+	VoidPayment(context.Context, *GetPaymentRequest) (*OrderPayment, error)
+	ExpirePayment(context.Context, *GetPaymentRequest) (*OrderPayment, error)
+	RefundPayment(context.Context, *RefundPaymentRequest) (*PaymentRefund, error)
+	UpdatePaymentAmount(context.Context, *UpdatePaymentAmountRequest) (*OrderPayment, error)
+	GetPaymentReport(context.Context, *PaymentReportRequest) (*PaymentReport, error)
 	mustEmbedUnimplementedPaymentServiceServer()
 }
 
@@ -81,6 +159,24 @@ func (UnimplementedPaymentServiceServer) PayOrder(context.Context, *CreatePaymen
 func (UnimplementedPaymentServiceServer) Transaction(context.Context, *PaymentTransaction) (*EmptyPayment, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Transaction not implemented")
 }
+func (UnimplementedPaymentServiceServer) GetPayment(context.Context, *GetPaymentRequest) (*OrderPayment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPayment not implemented")
+}
+func (UnimplementedPaymentServiceServer) VoidPayment(context.Context, *GetPaymentRequest) (*OrderPayment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VoidPayment not implemented")
+}
+func (UnimplementedPaymentServiceServer) ExpirePayment(context.Context, *GetPaymentRequest) (*OrderPayment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExpirePayment not implemented")
+}
+func (UnimplementedPaymentServiceServer) RefundPayment(context.Context, *RefundPaymentRequest) (*PaymentRefund, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundPayment not implemented")
+}
+func (UnimplementedPaymentServiceServer) UpdatePaymentAmount(context.Context, *UpdatePaymentAmountRequest) (*OrderPayment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePaymentAmount not implemented")
+}
+func (UnimplementedPaymentServiceServer) GetPaymentReport(context.Context, *PaymentReportRequest) (*PaymentReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentReport not implemented")
+}
 func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
 func (UnimplementedPaymentServiceServer) testEmbeddedByValue()                        {}
 
@@ -138,6 +234,114 @@ func _PaymentService_Transaction_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentService_GetPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetPayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetPayment(ctx, req.(*GetPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_VoidPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).VoidPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_VoidPayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).VoidPayment(ctx, req.(*GetPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_ExpirePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).ExpirePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_ExpirePayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).ExpirePayment(ctx, req.(*GetPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_RefundPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).RefundPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_RefundPayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).RefundPayment(ctx, req.(*RefundPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_UpdatePaymentAmount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePaymentAmountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).UpdatePaymentAmount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_UpdatePaymentAmount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).UpdatePaymentAmount(ctx, req.(*UpdatePaymentAmountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_GetPaymentReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PaymentReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetPaymentReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetPaymentReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetPaymentReport(ctx, req.(*PaymentReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PaymentService_ServiceDesc is the grpc.ServiceDesc for PaymentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +357,30 @@ var PaymentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Transaction",
 			Handler:    _PaymentService_Transaction_Handler,
 		},
+		{
+			MethodName: "GetPayment",
+			Handler:    _PaymentService_GetPayment_Handler,
+		},
+		{
+			MethodName: "VoidPayment",
+			Handler:    _PaymentService_VoidPayment_Handler,
+		},
+		{
+			MethodName: "ExpirePayment",
+			Handler:    _PaymentService_ExpirePayment_Handler,
+		},
+		{
+			MethodName: "RefundPayment",
+			Handler:    _PaymentService_RefundPayment_Handler,
+		},
+		{
+			MethodName: "UpdatePaymentAmount",
+			Handler:    _PaymentService_UpdatePaymentAmount_Handler,
+		},
+		{
+			MethodName: "GetPaymentReport",
+			Handler:    _PaymentService_GetPaymentReport_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "payment.proto",
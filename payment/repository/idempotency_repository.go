@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+type IdempotencyRecord struct {
+	UserID       int32
+	Key          string
+	RequestHash  string
+	Response     []byte
+	ErrorMessage string
+}
+
+type IdempotencyRepository interface {
+	GetIdempotencyKey(ctx context.Context, userID int32, key string, tx *sql.Tx) (*IdempotencyRecord, error)
+	CreateIdempotencyKey(ctx context.Context, record *IdempotencyRecord, tx *sql.Tx) (bool, error)
+}
+
+type IdempotencyRepositoryImpl struct{}
+
+func NewIdempotencyRepository() *IdempotencyRepositoryImpl {
+	return &IdempotencyRepositoryImpl{}
+}
+
+func (u *IdempotencyRepositoryImpl) GetIdempotencyKey(ctx context.Context, userID int32, key string, tx *sql.Tx) (*IdempotencyRecord, error) {
+	SQL := "SELECT user_id, idempotency_key, request_hash, response, COALESCE(error_message, '') FROM payment_idempotency_keys WHERE user_id = $1 AND idempotency_key = $2"
+	record := &IdempotencyRecord{}
+	if err := tx.QueryRowContext(ctx, SQL, userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.RequestHash,
+		&record.Response,
+		&record.ErrorMessage,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrIdempotencyKeyNotFound
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// CreateIdempotencyKey returns false when another request already stored the key.
+func (u *IdempotencyRepositoryImpl) CreateIdempotencyKey(ctx context.Context, record *IdempotencyRecord, tx *sql.Tx) (bool, error) {
+	SQL := `INSERT INTO payment_idempotency_keys(user_id, idempotency_key, request_hash, response, error_message) VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+        ON CONFLICT (user_id, idempotency_key) DO NOTHING`
+	result, err := tx.ExecContext(ctx, SQL, record.UserID, record.Key, record.RequestHash, record.Response, record.ErrorMessage)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type OutboxEvent struct {
+	ID          int
+	AggregateID int
+	Topic       string
+	Payload     []byte
+	Attempts    int
+}
+
+type OutboxRepository interface {
+	CreateEvent(ctx context.Context, event *OutboxEvent, tx *sql.Tx) error
+	GetPendingEvents(ctx context.Context, limit int, tx *sql.Tx) ([]*OutboxEvent, error)
+	MarkSent(ctx context.Context, ID int, tx *sql.Tx) error
+	MarkFailed(ctx context.Context, ID int, errMessage string, backoff time.Duration, tx *sql.Tx) error
+}
+
+type OutboxRepositoryImpl struct{}
+
+func NewOutboxRepository() *OutboxRepositoryImpl {
+	return &OutboxRepositoryImpl{}
+}
+
+func (u *OutboxRepositoryImpl) CreateEvent(ctx context.Context, event *OutboxEvent, tx *sql.Tx) error {
+	SQL := "INSERT INTO payment_outbox(aggregate_id, topic, payload) VALUES ($1, $2, $3)"
+	if _, err := tx.ExecContext(ctx, SQL, event.AggregateID, event.Topic, event.Payload); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPendingEvents locks the due rows so several relay instances never publish the same event twice.
+// An event waits while an earlier event of its aggregate is pending, so the events of an aggregate are
+// published in order even when one of them is backing off.
+func (u *OutboxRepositoryImpl) GetPendingEvents(ctx context.Context, limit int, tx *sql.Tx) ([]*OutboxEvent, error) {
+	SQL := `SELECT id, aggregate_id, topic, payload, attempts FROM payment_outbox
+        WHERE status = 'pending' AND next_attempt_at <= NOW()
+            AND NOT EXISTS (
+                SELECT 1 FROM payment_outbox earlier
+                WHERE earlier.aggregate_id = payment_outbox.aggregate_id AND earlier.status = 'pending' AND earlier.id < payment_outbox.id
+            )
+        ORDER BY id ASC
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED`
+	rows, err := tx.QueryContext(ctx, SQL, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*OutboxEvent
+	for rows.Next() {
+		event := &OutboxEvent{}
+		if err := rows.Scan(&event.ID, &event.AggregateID, &event.Topic, &event.Payload, &event.Attempts); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (u *OutboxRepositoryImpl) MarkSent(ctx context.Context, ID int, tx *sql.Tx) error {
+	SQL := "UPDATE payment_outbox SET status = 'sent', sent_at = NOW(), last_error = NULL WHERE id = $1"
+	if _, err := tx.ExecContext(ctx, SQL, ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *OutboxRepositoryImpl) MarkFailed(ctx context.Context, ID int, errMessage string, backoff time.Duration, tx *sql.Tx) error {
+	SQL := "UPDATE payment_outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = NOW() + make_interval(secs => $2) WHERE id = $3"
+	if _, err := tx.ExecContext(ctx, SQL, errMessage, backoff.Seconds(), ID); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,74 +1,163 @@
-package repository
-
-import (
-	"context"
-	"database/sql"
-	"errors"
-	"payment/proto"
-	"time"
-)
-
-type PaymentRepository interface {
-	CreatePayment(payload *proto.CreatePaymentRequest, tx *sql.Tx) (int, error)
-	UpdatePayment(ctx context.Context, status string, ID int, tx *sql.Tx) error
-	GetByID(ctx context.Context, ID int, tx *sql.Tx) (*proto.OrderPayment, error)
-	DeletePayment(ctx context.Context, ID int, tx *sql.Tx) error
-}
-
-type PaymentRepositoryImpl struct{}
-
-func NewPaymentRepository() *PaymentRepositoryImpl {
-	return &PaymentRepositoryImpl{}
-}
-
-func (u *PaymentRepositoryImpl) CreatePayment(payload *proto.CreatePaymentRequest, tx *sql.Tx) (int, error) {
-	var paymentID int
-	SQL := "INSERT INTO payments(order_id, user_id, total_price) VALUES ($1, $2, $3) returning id"
-	if err := tx.QueryRow(SQL, payload.OrderId, payload.UserId, payload.TotalPrice).Scan(&paymentID); err != nil {
-		return 0, err
-	}
-
-	return paymentID, nil
-}
-
-func (u *PaymentRepositoryImpl) UpdatePayment(ctx context.Context, status string, ID int, tx *sql.Tx) error {
-	loc := time.FixedZone("WIB", 7*60*60)
-	SQL := `UPDATE payments SET status = $1, updated_at = $2 WHERE id = $3`
-	now := time.Now().In(loc)
-	if _, err := tx.ExecContext(ctx, SQL, status, now, ID); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (u PaymentRepositoryImpl) GetByID(ctx context.Context, ID int, tx *sql.Tx) (*proto.OrderPayment, error) {
-	SQL := "SELECT id, order_id, user_id, status, total_price, created_at, updated_at FROM payments WHERE id = $1"
-	rows := tx.QueryRowContext(ctx, SQL, ID)
-
-	orderPayment := &proto.OrderPayment{}
-	if err := rows.Scan(
-		&orderPayment.Id,
-		&orderPayment.OrderId,
-		&orderPayment.UserId,
-		&orderPayment.Status,
-		&orderPayment.TotalPrice,
-		&orderPayment.CreatedAt,
-		&orderPayment.UpdatedAt,
-	); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("order payment not found")
-		}
-		return nil, err
-	}
-	return orderPayment, nil
-}
-
-func (u *PaymentRepositoryImpl) DeletePayment(ctx context.Context, ID int, tx *sql.Tx) error {
-	SQL := "DELETE FROM order_items WHERE id = $1"
-	if _, err := tx.Exec(SQL, ID); err != nil {
-		return err
-	}
-
-	return nil
-}
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payment/proto"
+	"time"
+)
+
+var ErrPaymentNotFound = errors.New("order payment not found")
+
+type PaymentRepository interface {
+	CreatePayment(payload *proto.CreatePaymentRequest, tx *sql.Tx) (int, error)
+	UpdatePayment(ctx context.Context, status string, fromStatus string, ID int, tx *sql.Tx) (bool, error)
+	GetByID(ctx context.Context, ID int, tx *sql.Tx) (*proto.OrderPayment, error)
+	GetByOrderID(ctx context.Context, orderID int, tx *sql.Tx) (*proto.OrderPayment, error)
+	DeletePayment(ctx context.Context, ID int, tx *sql.Tx) error
+	GetRefundedAmount(ctx context.Context, ID int, tx *sql.Tx) (int64, error)
+	AddRefundedAmount(ctx context.Context, ID int, amount int64, tx *sql.Tx) (bool, error)
+	UpdatePendingAmount(ctx context.Context, ID int, totalPrice int64, tx *sql.Tx) (bool, error)
+}
+
+type PaymentRepositoryImpl struct{}
+
+func NewPaymentRepository() *PaymentRepositoryImpl {
+	return &PaymentRepositoryImpl{}
+}
+
+func (u *PaymentRepositoryImpl) CreatePayment(payload *proto.CreatePaymentRequest, tx *sql.Tx) (int, error) {
+	var paymentID int
+	SQL := `INSERT INTO payments(order_id, user_id, total_price, currency, base_currency, exchange_rate)
+        VALUES ($1, $2, $3, $4, $5, $6) returning id`
+	if err := tx.QueryRow(SQL, payload.OrderId, payload.UserId, payload.TotalPrice, payload.Currency, payload.BaseCurrency, payload.ExchangeRate).Scan(&paymentID); err != nil {
+		return 0, err
+	}
+
+	return paymentID, nil
+}
+
+// UpdatePayment moves a payment from fromStatus to status, it reports false when the payment is no longer in fromStatus.
+func (u *PaymentRepositoryImpl) UpdatePayment(ctx context.Context, status string, fromStatus string, ID int, tx *sql.Tx) (bool, error) {
+	loc := time.FixedZone("WIB", 7*60*60)
+	SQL := `UPDATE payments SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4`
+	now := time.Now().In(loc)
+	result, err := tx.ExecContext(ctx, SQL, status, now, ID, fromStatus)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (u PaymentRepositoryImpl) GetByID(ctx context.Context, ID int, tx *sql.Tx) (*proto.OrderPayment, error) {
+	SQL := `SELECT id, order_id, user_id, status, total_price, currency, base_currency, exchange_rate, created_at, updated_at
+        FROM payments WHERE id = $1`
+	rows := tx.QueryRowContext(ctx, SQL, ID)
+
+	orderPayment := &proto.OrderPayment{}
+	if err := rows.Scan(
+		&orderPayment.Id,
+		&orderPayment.OrderId,
+		&orderPayment.UserId,
+		&orderPayment.Status,
+		&orderPayment.TotalPrice,
+		&orderPayment.Currency,
+		&orderPayment.BaseCurrency,
+		&orderPayment.ExchangeRate,
+		&orderPayment.CreatedAt,
+		&orderPayment.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return orderPayment, nil
+}
+
+func (u PaymentRepositoryImpl) GetByOrderID(ctx context.Context, orderID int, tx *sql.Tx) (*proto.OrderPayment, error) {
+	SQL := `SELECT id, order_id, user_id, status, total_price, currency, base_currency, exchange_rate, created_at, updated_at
+        FROM payments WHERE order_id = $1`
+	rows := tx.QueryRowContext(ctx, SQL, orderID)
+
+	orderPayment := &proto.OrderPayment{}
+	if err := rows.Scan(
+		&orderPayment.Id,
+		&orderPayment.OrderId,
+		&orderPayment.UserId,
+		&orderPayment.Status,
+		&orderPayment.TotalPrice,
+		&orderPayment.Currency,
+		&orderPayment.BaseCurrency,
+		&orderPayment.ExchangeRate,
+		&orderPayment.CreatedAt,
+		&orderPayment.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return orderPayment, nil
+}
+
+func (u *PaymentRepositoryImpl) GetRefundedAmount(ctx context.Context, ID int, tx *sql.Tx) (int64, error) {
+	var refunded int64
+	if err := tx.QueryRowContext(ctx, "SELECT refunded_amount FROM payments WHERE id = $1", ID).Scan(&refunded); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrPaymentNotFound
+		}
+		return 0, err
+	}
+	return refunded, nil
+}
+
+// AddRefundedAmount returns false when the payment is not paid or the amount exceeds what is left to refund.
+// A payment refunded up to its total becomes refunded.
+func (u *PaymentRepositoryImpl) AddRefundedAmount(ctx context.Context, ID int, amount int64, tx *sql.Tx) (bool, error) {
+	SQL := `UPDATE payments
+        SET refunded_amount = refunded_amount + $1,
+            status = CASE WHEN refunded_amount + $1 = total_price THEN 'refunded' ELSE status END,
+            updated_at = NOW()
+        WHERE id = $2 AND status = 'paid' AND refunded_amount + $1 <= total_price`
+	result, err := tx.ExecContext(ctx, SQL, amount, ID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// UpdatePendingAmount returns false when the payment is no longer pending.
+func (u *PaymentRepositoryImpl) UpdatePendingAmount(ctx context.Context, ID int, totalPrice int64, tx *sql.Tx) (bool, error) {
+	SQL := "UPDATE payments SET total_price = $1, updated_at = NOW() WHERE id = $2 AND status = 'pending'"
+	result, err := tx.ExecContext(ctx, SQL, totalPrice, ID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (u *PaymentRepositoryImpl) DeletePayment(ctx context.Context, ID int, tx *sql.Tx) error {
+	SQL := "DELETE FROM order_items WHERE id = $1"
+	if _, err := tx.Exec(SQL, ID); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payment/proto"
+)
+
+var ErrRefundNotFound = errors.New("payment refund not found")
+
+type RefundRepository interface {
+	CreateRefund(ctx context.Context, refund *proto.PaymentRefund, tx *sql.Tx) (*proto.PaymentRefund, error)
+	GetRefundByReference(ctx context.Context, reference string, tx *sql.Tx) (*proto.PaymentRefund, error)
+}
+
+type RefundRepositoryImpl struct{}
+
+func NewRefundRepository() *RefundRepositoryImpl {
+	return &RefundRepositoryImpl{}
+}
+
+func (u *RefundRepositoryImpl) CreateRefund(ctx context.Context, refund *proto.PaymentRefund, tx *sql.Tx) (*proto.PaymentRefund, error) {
+	SQL := `INSERT INTO payment_refunds(payment_id, amount, reason, reference) VALUES ($1, $2, NULLIF($3, ''), $4)
+        RETURNING id, created_at`
+	if err := tx.QueryRowContext(ctx, SQL, refund.PaymentId, refund.Amount, refund.Reason, refund.Reference).Scan(
+		&refund.Id,
+		&refund.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (u *RefundRepositoryImpl) GetRefundByReference(ctx context.Context, reference string, tx *sql.Tx) (*proto.PaymentRefund, error) {
+	SQL := `SELECT r.id, r.payment_id, r.amount, p.currency, COALESCE(r.reason, ''), r.reference, r.created_at
+        FROM payment_refunds r JOIN payments p ON p.id = r.payment_id
+        WHERE r.reference = $1`
+	refund := &proto.PaymentRefund{}
+	if err := tx.QueryRowContext(ctx, SQL, reference).Scan(
+		&refund.Id,
+		&refund.PaymentId,
+		&refund.Amount,
+		&refund.Currency,
+		&refund.Reason,
+		&refund.Reference,
+		&refund.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, err
+	}
+	return refund, nil
+}
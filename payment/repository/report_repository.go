@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"payment/proto"
+)
+
+// ReportFilter selects the payments created from From up to but not including To, both formatted as
+// timestamps, GroupBy is a DATE_TRUNC field.
+type ReportFilter struct {
+	From    string
+	To      string
+	GroupBy string
+}
+
+type ReportRepository interface {
+	GetPaymentStats(ctx context.Context, filter *ReportFilter, tx *sql.Tx) ([]*proto.PaymentStats, error)
+}
+
+type ReportRepositoryImpl struct{}
+
+func NewReportRepository() *ReportRepositoryImpl {
+	return &ReportRepositoryImpl{}
+}
+
+func (u *ReportRepositoryImpl) GetPaymentStats(ctx context.Context, filter *ReportFilter, tx *sql.Tx) ([]*proto.PaymentStats, error) {
+	SQL := `SELECT TO_CHAR(DATE_TRUNC($1, created_at), 'YYYY-MM-DD') AS period,
+            COUNT(*),
+            COUNT(*) FILTER (WHERE status IN ('paid', 'refunded')),
+            COUNT(*) FILTER (WHERE status = 'failed'),
+            COUNT(*) FILTER (WHERE status = 'pending'),
+            COUNT(*) FILTER (WHERE status IN ('void', 'expired'))
+        FROM payments
+        WHERE created_at >= $2::timestamp AND created_at < $3::timestamp
+        GROUP BY period
+        ORDER BY period ASC`
+	rows, err := tx.QueryContext(ctx, SQL, filter.GroupBy, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var periods []*proto.PaymentStats
+	for rows.Next() {
+		stats := &proto.PaymentStats{}
+		if err := rows.Scan(&stats.Period, &stats.Total, &stats.Succeeded, &stats.Failed, &stats.Pending, &stats.Cancelled); err != nil {
+			return nil, err
+		}
+		periods = append(periods, stats)
+	}
+
+	return periods, rows.Err()
+}
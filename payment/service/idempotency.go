@@ -0,0 +1,101 @@
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"payment/proto"
+	"payment/repository"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// reservedKeyPrefixes start the idempotency keys the order service pays checkouts and subscription
+// cycles with. Clients cannot send them, or they could take the key of a cycle before it is paid.
+var reservedKeyPrefixes = []string{"checkout:", "subscription:"}
+
+// ReservedIdempotencyKey reports whether key starts with a prefix the services keep for their own keys.
+func ReservedIdempotencyKey(key string) bool {
+	for _, prefix := range reservedKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// transactionHash fingerprints a Transaction request so a reused key with another payload is detected.
+func transactionHash(payload *proto.PaymentTransaction) (string, error) {
+	request := protobuf.Clone(payload).(*proto.PaymentTransaction)
+	request.IdempotencyKey = ""
+
+	data, err := protobuf.MarshalOptions{Deterministic: true}.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// replayTransaction returns the outcome stored for a key the payment owner already used, found is false for a new key.
+func (u *PaymentService) replayTransaction(userID int32, key string, requestHash string) (bool, error) {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	record, err := u.idempotencyRepo.GetIdempotencyKey(u.ctx, userID, key, tx)
+	if err != nil {
+		if errors.Is(err, repository.ErrIdempotencyKeyNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if record.RequestHash != requestHash {
+		return true, status.Error(codes.AlreadyExists, "idempotency key was already used with a different request")
+	}
+
+	logrus.Infof("replaying transaction for idempotency key %s", key)
+	if record.ErrorMessage != "" {
+		return true, errors.New(record.ErrorMessage)
+	}
+	return true, nil
+}
+
+// saveTransactionKey stores the outcome in the transaction that settles the payment. When a concurrent
+// request stored the key first, its outcome is replayed and the caller must not commit.
+func (u *PaymentService) saveTransactionKey(transaction *proto.PaymentTransaction, userID int32, requestHash string, errMessage string, tx *sql.Tx) (bool, error) {
+	if transaction.IdempotencyKey == "" {
+		return false, nil
+	}
+
+	response, err := protobuf.Marshal(&proto.EmptyPayment{})
+	if err != nil {
+		return false, err
+	}
+	created, err := u.idempotencyRepo.CreateIdempotencyKey(u.ctx, &repository.IdempotencyRecord{
+		UserID:       userID,
+		Key:          transaction.IdempotencyKey,
+		RequestHash:  requestHash,
+		Response:     response,
+		ErrorMessage: errMessage,
+	}, tx)
+	if err != nil || created {
+		return false, err
+	}
+
+	found, err := u.replayTransaction(userID, transaction.IdempotencyKey, requestHash)
+	if err == nil && !found {
+		err = status.Error(codes.Aborted, "idempotency key is being used by another request")
+	}
+	return true, err
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"payment/repository"
+	"payment/transport/kafka"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	outboxBatchSize   = 50
+	outboxPollPeriod  = 2 * time.Second
+	outboxBaseBackoff = 2 * time.Second
+	outboxMaxBackoff  = 5 * time.Minute
+)
+
+// OutboxRelay publishes the payment events committed to the payment_outbox table to Kafka.
+type OutboxRelay struct {
+	DB         *sql.DB
+	outboxRepo repository.OutboxRepository
+}
+
+func NewOutboxRelay(DB *sql.DB, outboxRepo repository.OutboxRepository) *OutboxRelay {
+	return &OutboxRelay{
+		DB:         DB,
+		outboxRepo: outboxRepo,
+	}
+}
+
+func (u *OutboxRelay) Run(ctx context.Context) {
+	logrus.Info("Starting outbox relay")
+	ticker := time.NewTicker(outboxPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Outbox relay stopping...")
+			return
+		case <-ticker.C:
+			if err := u.publishPending(ctx); err != nil {
+				logrus.Errorf("failed to relay outbox events: %v", err)
+			}
+		}
+	}
+}
+
+func (u *OutboxRelay) publishPending(ctx context.Context) error {
+	tx, err := u.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	events, err := u.outboxRepo.GetPendingEvents(ctx, outboxBatchSize, tx)
+	if err != nil {
+		return err
+	}
+
+	// A failed event holds back the later events of its aggregate so they are not published before it
+	failed := make(map[int]bool)
+	for _, event := range events {
+		if failed[event.AggregateID] {
+			continue
+		}
+		partition, offset, err := kafka.SendMessage(event.Topic, strconv.Itoa(event.AggregateID), event.Payload)
+		if err != nil {
+			failed[event.AggregateID] = true
+			backoff := outboxBackoff(event.Attempts)
+			logrus.Errorf("Failed to send outbox event %d to Kafka, retrying in %s: %v", event.ID, backoff, err)
+			if err := u.outboxRepo.MarkFailed(ctx, event.ID, err.Error(), backoff, tx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := u.outboxRepo.MarkSent(ctx, event.ID, tx); err != nil {
+			return err
+		}
+		logrus.Infof("Message sent to topic: %s partition: %d, offset: %d", event.Topic, partition, offset)
+	}
+
+	return tx.Commit()
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 0; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return backoff
+}
@@ -1,102 +1,414 @@
-package service
-
-import (
-	"context"
-	"database/sql"
-	"errors"
-	"payment/helper"
-	"payment/proto"
-	"payment/repository"
-	"time"
-
-	"github.com/sirupsen/logrus"
-)
-
-type PaymentService struct {
-	paymentRepo repository.PaymentRepository
-	orderRepo   repository.OrderRepository
-	DB          *sql.DB
-	ctx         context.Context
-}
-
-func NewPaymentService(repo repository.PaymentRepository, DB *sql.DB, ctx context.Context, orderRepo repository.OrderRepository) *PaymentService {
-	return &PaymentService{
-		paymentRepo: repo,
-		orderRepo:   orderRepo,
-		DB:          DB,
-		ctx:         ctx,
-	}
-}
-
-func (u *PaymentService) AddPayment(payment *proto.CreatePaymentRequest) (*proto.OrderPayment, error) {
-	logrus.Info("Begin transaction")
-	tx, err := u.DB.Begin()
-	if err != nil {
-		return nil, err
-	}
-	defer helper.CommitOrRollback(tx)
-
-	logrus.Info("create payment")
-	paymentID, err := u.paymentRepo.CreatePayment(payment, tx)
-	if err != nil {
-		tx.Rollback()
-		logrus.Errorf("error when create payment: %v", err)
-		return nil, err
-	}
-
-	return &proto.OrderPayment{
-		Id:         int32(paymentID),
-		OrderId:    payment.OrderId,
-		UserId:     payment.UserId,
-		Status:     "pending",
-		TotalPrice: payment.TotalPrice,
-		CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
-		UpdatedAt:  time.Now().Format("2006-01-02 15:04:05"),
-	}, nil
-}
-
-func (u *PaymentService) Transaction(transaction *proto.PaymentTransaction) error {
-	logrus.Info("create transaction")
-	tx, err := u.DB.Begin()
-	if err != nil {
-		return err
-	}
-	defer helper.CommitOrRollback(tx)
-
-	logrus.Info("get payment by id")
-	payment, err := u.paymentRepo.GetByID(u.ctx, int(transaction.PaymentId), tx)
-	if err != nil {
-		return err
-	}
-
-	logrus.Info("check payment if already paid")
-	if payment.Status == "paid" {
-		return errors.New("payment already paid")
-	}
-
-	logrus.Info("check payment")
-	if transaction.Money < payment.TotalPrice {
-		if _, err := u.orderRepo.UpdateOrderStatus(u.ctx, &proto.UpdateOrderStatusRequest{OrderId: payment.OrderId, Status: "failed"}); err != nil {
-			logrus.Errorf("error when update order status: %v", err)
-			return err
-		}
-		return errors.New("money not enough")
-	}
-
-	logrus.Info("update payment")
-	if err := u.paymentRepo.UpdatePayment(u.ctx, "paid", int(transaction.PaymentId), tx); err != nil {
-		if _, err := u.orderRepo.UpdateOrderStatus(u.ctx, &proto.UpdateOrderStatusRequest{OrderId: payment.OrderId, Status: "failed"}); err != nil {
-			return err
-		}
-		tx.Rollback()
-		return err
-	}
-
-	logrus.Info("update order status")
-	if _, err := u.orderRepo.UpdateOrderStatus(u.ctx, &proto.UpdateOrderStatusRequest{OrderId: payment.OrderId, Status: "paid"}); err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	return nil
-}
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"payment/proto"
+	"payment/repository"
+	"payment/transport/kafka"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type PaymentService struct {
+	paymentRepo     repository.PaymentRepository
+	outboxRepo      repository.OutboxRepository
+	idempotencyRepo repository.IdempotencyRepository
+	refundRepo      repository.RefundRepository
+	DB              *sql.DB
+	ctx             context.Context
+}
+
+func NewPaymentService(repo repository.PaymentRepository, DB *sql.DB, ctx context.Context, outboxRepo repository.OutboxRepository, idempotencyRepo repository.IdempotencyRepository, refundRepo repository.RefundRepository) *PaymentService {
+	return &PaymentService{
+		paymentRepo:     repo,
+		outboxRepo:      outboxRepo,
+		idempotencyRepo: idempotencyRepo,
+		refundRepo:      refundRepo,
+		DB:              DB,
+		ctx:             ctx,
+	}
+}
+
+// AddPayment is idempotent per order, both the Kafka consumer and the order saga may request the same payment.
+func (u *PaymentService) AddPayment(payment *proto.CreatePaymentRequest) (*proto.OrderPayment, error) {
+	logrus.Info("Begin transaction")
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	existing, err := u.paymentRepo.GetByOrderID(u.ctx, int(payment.OrderId), tx)
+	if err == nil {
+		logrus.Infof("payment for order %d already exists with ID: %d", payment.OrderId, existing.Id)
+		return existing, nil
+	}
+	if !errors.Is(err, repository.ErrPaymentNotFound) {
+		return nil, err
+	}
+
+	// A payment in the currency of the products has no conversion
+	if payment.BaseCurrency == "" || payment.ExchangeRate <= 0 {
+		payment.BaseCurrency, payment.ExchangeRate = payment.Currency, 1
+	}
+
+	logrus.Info("create payment")
+	paymentID, err := u.paymentRepo.CreatePayment(payment, tx)
+	if err != nil {
+		logrus.Errorf("error when create payment: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &proto.OrderPayment{
+		Id:           int32(paymentID),
+		OrderId:      payment.OrderId,
+		UserId:       payment.UserId,
+		Status:       "pending",
+		TotalPrice:   payment.TotalPrice,
+		Currency:     payment.Currency,
+		BaseCurrency: payment.BaseCurrency,
+		ExchangeRate: payment.ExchangeRate,
+		CreatedAt:    time.Now().Format("2006-01-02 15:04:05"),
+		UpdatedAt:    time.Now().Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// Transaction settles the payment and commits a payment.succeeded or payment.failed event with it,
+// OutboxRelay publishes the event and the order service moves the order on it.
+func (u *PaymentService) Transaction(transaction *proto.PaymentTransaction) error {
+	var requestHash string
+	if transaction.IdempotencyKey != "" {
+		if len(transaction.IdempotencyKey) > maxIdempotencyKeyLength {
+			return status.Error(codes.InvalidArgument, "idempotency key is too long")
+		}
+
+		hash, err := transactionHash(transaction)
+		if err != nil {
+			return err
+		}
+		requestHash = hash
+	}
+
+	logrus.Info("create transaction")
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	logrus.Info("get payment by id")
+	payment, err := u.paymentRepo.GetByID(u.ctx, int(transaction.PaymentId), tx)
+	if err != nil {
+		return err
+	}
+	if transaction.UserId != 0 && payment.UserId != transaction.UserId {
+		return status.Error(codes.NotFound, repository.ErrPaymentNotFound.Error())
+	}
+
+	// A retried request with the same key gets the outcome of the first attempt, keys are scoped to the payment owner
+	if transaction.IdempotencyKey != "" {
+		found, err := u.replayTransaction(payment.UserId, transaction.IdempotencyKey, requestHash)
+		if found || err != nil {
+			return err
+		}
+	}
+
+	logrus.Info("check payment if already paid")
+	if payment.Status == "paid" {
+		return errors.New("payment already paid")
+	}
+	if payment.Status != "pending" {
+		return errors.New("payment is " + payment.Status)
+	}
+
+	if transaction.Currency != "" && !strings.EqualFold(transaction.Currency, payment.Currency) {
+		return status.Errorf(codes.InvalidArgument, "payment %d is in %s", payment.Id, payment.Currency)
+	}
+
+	logrus.Info("check payment")
+	if transaction.Money < payment.TotalPrice {
+		updated, err := u.paymentRepo.UpdatePayment(u.ctx, "failed", "pending", int(transaction.PaymentId), tx)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return status.Errorf(codes.FailedPrecondition, "payment %d is no longer pending", payment.Id)
+		}
+		payment.Status = "failed"
+		if err := u.savePaymentEvent(kafka.EventPaymentFailed, payment, "money not enough", tx); err != nil {
+			return err
+		}
+		if replayed, err := u.saveTransactionKey(transaction, payment.UserId, requestHash, "money not enough", tx); replayed || err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return errors.New("money not enough")
+	}
+
+	logrus.Info("update payment")
+	updated, err := u.paymentRepo.UpdatePayment(u.ctx, "paid", "pending", int(transaction.PaymentId), tx)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return status.Errorf(codes.FailedPrecondition, "payment %d is no longer pending", payment.Id)
+	}
+	payment.Status = "paid"
+	if err := u.savePaymentEvent(kafka.EventPaymentSucceeded, payment, "payment received", tx); err != nil {
+		return err
+	}
+	if replayed, err := u.saveTransactionKey(transaction, payment.UserId, requestHash, "", tx); replayed || err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (u *PaymentService) GetPayment(payload *proto.GetPaymentRequest) (*proto.OrderPayment, error) {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var payment *proto.OrderPayment
+	if payload.PaymentId != 0 {
+		payment, err = u.paymentRepo.GetByID(u.ctx, int(payload.PaymentId), tx)
+	} else {
+		payment, err = u.paymentRepo.GetByOrderID(u.ctx, int(payload.OrderId), tx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if payload.UserId != 0 && payment.UserId != payload.UserId {
+		return nil, status.Error(codes.NotFound, repository.ErrPaymentNotFound.Error())
+	}
+	return payment, nil
+}
+
+// VoidPayment cancels a pending payment, a payment that was already paid is refunded.
+func (u *PaymentService) VoidPayment(payload *proto.GetPaymentRequest) (*proto.OrderPayment, error) {
+	return u.closePayment(payload, "void")
+}
+
+// ExpirePayment closes the payment of an order that was not paid in time.
+func (u *PaymentService) ExpirePayment(payload *proto.GetPaymentRequest) (*proto.OrderPayment, error) {
+	return u.closePayment(payload, "expired")
+}
+
+// closePayment moves an unpaid payment to unpaidStatus and refunds what is left of a paid one, under a
+// reference made of unpaidStatus and the payment so a retry does not refund it twice.
+func (u *PaymentService) closePayment(payload *proto.GetPaymentRequest, unpaidStatus string) (*proto.OrderPayment, error) {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var payment *proto.OrderPayment
+	if payload.PaymentId != 0 {
+		payment, err = u.paymentRepo.GetByID(u.ctx, int(payload.PaymentId), tx)
+	} else {
+		payment, err = u.paymentRepo.GetByOrderID(u.ctx, int(payload.OrderId), tx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next := unpaidStatus
+	switch payment.Status {
+	case "pending", "failed":
+		logrus.Infof("closing payment %d as %s", payment.Id, next)
+		updated, err := u.paymentRepo.UpdatePayment(u.ctx, next, payment.Status, int(payment.Id), tx)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			return nil, status.Errorf(codes.FailedPrecondition, "payment %d changed while closing it, retry", payment.Id)
+		}
+	case "paid":
+		refunded, err := u.paymentRepo.GetRefundedAmount(u.ctx, int(payment.Id), tx)
+		if err != nil {
+			return nil, err
+		}
+		reference := fmt.Sprintf("%s:%d", unpaidStatus, payment.Id)
+		if _, err := u.refund(payment, payment.TotalPrice-refunded, "payment "+unpaidStatus, reference, tx); err != nil {
+			return nil, err
+		}
+		next = "refunded"
+	default:
+		return payment, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	payment.Status = next
+
+	return payment, nil
+}
+
+// RefundPayment refunds part of a paid payment, a reference that was already refunded returns the existing refund.
+func (u *PaymentService) RefundPayment(payload *proto.RefundPaymentRequest) (*proto.PaymentRefund, error) {
+	if payload.Amount <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "refund amount must be greater than zero")
+	}
+	if payload.Reference == "" {
+		return nil, status.Error(codes.InvalidArgument, "refund reference is required")
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	refund, err := u.refundRepo.GetRefundByReference(u.ctx, payload.Reference, tx)
+	if err == nil {
+		logrus.Infof("refund %s already exists with ID: %d", payload.Reference, refund.Id)
+		return refund, nil
+	}
+	if !errors.Is(err, repository.ErrRefundNotFound) {
+		return nil, err
+	}
+
+	var payment *proto.OrderPayment
+	if payload.PaymentId != 0 {
+		payment, err = u.paymentRepo.GetByID(u.ctx, int(payload.PaymentId), tx)
+	} else {
+		payment, err = u.paymentRepo.GetByOrderID(u.ctx, int(payload.OrderId), tx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refund, err = u.refund(payment, payload.Amount, payload.Reason, payload.Reference, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// refund adds amount to the refunded amount of a paid payment and records the refund in tx.
+func (u *PaymentService) refund(payment *proto.OrderPayment, amount int64, reason string, reference string, tx *sql.Tx) (*proto.PaymentRefund, error) {
+	logrus.Infof("refunding %s of payment %d", formatAmount(amount, payment.Currency), payment.Id)
+	ok, err := u.paymentRepo.AddRefundedAmount(u.ctx, int(payment.Id), amount, tx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "payment %d is %s or has less than %s left to refund", payment.Id, payment.Status, formatAmount(amount, payment.Currency))
+	}
+
+	return u.refundRepo.CreateRefund(u.ctx, &proto.PaymentRefund{
+		PaymentId: payment.Id,
+		Amount:    amount,
+		Currency:  payment.Currency,
+		Reason:    reason,
+		Reference: reference,
+	}, tx)
+}
+
+// UpdatePaymentAmount follows the total of an order whose items changed, only while the payment is pending.
+func (u *PaymentService) UpdatePaymentAmount(payload *proto.UpdatePaymentAmountRequest) (*proto.OrderPayment, error) {
+	if payload.TotalPrice <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "total price must be greater than zero")
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	payment, err := u.paymentRepo.GetByOrderID(u.ctx, int(payload.OrderId), tx)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("updating amount of payment %d to %s", payment.Id, formatAmount(payload.TotalPrice, payment.Currency))
+	ok, err := u.paymentRepo.UpdatePendingAmount(u.ctx, int(payment.Id), payload.TotalPrice, tx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "payment %d is %s", payment.Id, payment.Status)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	payment.TotalPrice = payload.TotalPrice
+
+	return payment, nil
+}
+
+// savePaymentEvent adds an eventType event of payment to the outbox of tx, keyed by the order so the
+// events of an order stay in order.
+func (u *PaymentService) savePaymentEvent(eventType string, payment *proto.OrderPayment, reason string, tx *sql.Tx) error {
+	logrus.Infof("Saving %s event to outbox", eventType)
+	event, err := kafka.MarshalEvent(eventType, kafka.PaymentEventVersion, int(payment.OrderId), &proto.PaymentResult{
+		Payment: payment,
+		Reason:  reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	return u.outboxRepo.CreateEvent(u.ctx, &repository.OutboxEvent{
+		AggregateID: int(payment.OrderId),
+		Topic:       paymentTopic(),
+		Payload:     event,
+	}, tx)
+}
+
+func paymentTopic() string {
+	if topic := os.Getenv("KAFKA_PAYMENT_TOPIC"); topic != "" {
+		return topic
+	}
+	return "payment.events"
+}
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not 1/100 of the currency unit.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// formatAmount writes an amount in minor units with its currency, e.g. 1500.50 IDR or 1500 JPY.
+func formatAmount(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		exponent = 2
+	}
+	if exponent == 0 {
+		return fmt.Sprintf("%s%d %s", sign, amount, currency)
+	}
+	unit := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/unit, exponent, amount%unit, currency)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"payment/proto"
+	"payment/repository"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultReportDays = 30
+	maxReportDays     = 3 * 366
+)
+
+// ReportService answers the payment reports of the admins.
+type ReportService struct {
+	reportRepo repository.ReportRepository
+	DB         *sql.DB
+	ctx        context.Context
+}
+
+func NewReportService(reportRepo repository.ReportRepository, DB *sql.DB, ctx context.Context) *ReportService {
+	return &ReportService{
+		reportRepo: reportRepo,
+		DB:         DB,
+		ctx:        ctx,
+	}
+}
+
+// GetPaymentReport counts the payments per day, week or month by outcome, total covers the whole range.
+func (u *ReportService) GetPaymentReport(payload *proto.PaymentReportRequest) (*proto.PaymentReport, error) {
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if payload.To != "" {
+		date, err := time.Parse("2006-01-02", payload.To)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid date %q", payload.To)
+		}
+		to = date
+	}
+
+	from := to.AddDate(0, 0, 1-defaultReportDays)
+	if payload.From != "" {
+		date, err := time.Parse("2006-01-02", payload.From)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid date %q", payload.From)
+		}
+		from = date
+	}
+
+	if from.After(to) {
+		return nil, status.Error(codes.InvalidArgument, "from must not be after to")
+	}
+	if to.Sub(from) > maxReportDays*24*time.Hour {
+		return nil, status.Errorf(codes.InvalidArgument, "a report covers at most %d days", maxReportDays)
+	}
+
+	filter := &repository.ReportFilter{
+		From: from.Format("2006-01-02 15:04:05"),
+		To:   to.AddDate(0, 0, 1).Format("2006-01-02 15:04:05"),
+	}
+	switch strings.ToLower(payload.GroupBy) {
+	case "", "day":
+		filter.GroupBy = "day"
+	case "week":
+		filter.GroupBy = "week"
+	case "month":
+		filter.GroupBy = "month"
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid group_by %q", payload.GroupBy)
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	periods, err := u.reportRepo.GetPaymentStats(u.ctx, filter, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	total := &proto.PaymentStats{}
+	for _, stats := range periods {
+		stats.SuccessRate = successRate(stats)
+		total.Total += stats.Total
+		total.Succeeded += stats.Succeeded
+		total.Failed += stats.Failed
+		total.Pending += stats.Pending
+		total.Cancelled += stats.Cancelled
+	}
+	total.SuccessRate = successRate(total)
+
+	return &proto.PaymentReport{
+		From:    from.Format("2006-01-02"),
+		To:      to.Format("2006-01-02"),
+		GroupBy: filter.GroupBy,
+		Periods: periods,
+		Total:   total,
+	}, nil
+}
+
+// successRate is the share of the settled payments that succeeded, 0 when none was settled yet.
+func successRate(stats *proto.PaymentStats) float64 {
+	settled := stats.Succeeded + stats.Failed
+	if settled == 0 {
+		return 0
+	}
+	return float64(stats.Succeeded) / float64(settled)
+}
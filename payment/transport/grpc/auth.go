@@ -0,0 +1,98 @@
+package grpc
+
+import (
+	"context"
+	"payment/proto"
+	"payment/service"
+	"slices"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Metadata keys the broker sets with the user of the JWT, and the key the internal services set with
+// their name.
+const (
+	metadataUserID  = "x-user-id"
+	metadataRole    = "x-user-role"
+	metadataService = "x-internal-service"
+)
+
+const roleAdmin = "admin"
+
+// internalServices are the services trusted to call without a user, the broker always calls for one.
+var internalServices = []string{"order-service", "payment-service"}
+
+// adminMethods can only be called by admins and by the internalServices.
+var adminMethods = map[string]bool{
+	proto.PaymentService_PayOrder_FullMethodName:            true,
+	proto.PaymentService_VoidPayment_FullMethodName:         true,
+	proto.PaymentService_ExpirePayment_FullMethodName:       true,
+	proto.PaymentService_RefundPayment_FullMethodName:       true,
+	proto.PaymentService_UpdatePaymentAmount_FullMethodName: true,
+	proto.PaymentService_GetPaymentReport_FullMethodName:    true,
+}
+
+// authorizeUnary lets admins call everything and keeps users to their own payments: the user_id of
+// their request is set to them, the service then answers NotFound for payments of other users. The
+// user_id of admins is cleared so they reach every payment. Calls without a user are only trusted from
+// the internalServices, users cannot send the idempotency keys the services make up for themselves.
+func authorizeUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	userIDs := md.Get(metadataUserID)
+	if len(userIDs) == 0 {
+		if !slices.ContainsFunc(md.Get(metadataService), func(name string) bool {
+			return slices.Contains(internalServices, name)
+		}) {
+			return nil, status.Error(codes.Unauthenticated, "missing caller")
+		}
+		return handler(ctx, req)
+	}
+	if service.ReservedIdempotencyKey(idempotencyKey(req)) {
+		return nil, status.Error(codes.InvalidArgument, "idempotency key uses a reserved prefix")
+	}
+	admin := slices.Contains(md.Get(metadataRole), roleAdmin)
+	if adminMethods[info.FullMethod] && !admin {
+		return nil, status.Error(codes.PermissionDenied, "admin role required")
+	}
+	if admin {
+		setUserID(req, 0)
+		return handler(ctx, req)
+	}
+
+	userID, err := strconv.Atoi(userIDs[0])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid user ID")
+	}
+	setUserID(req, userID)
+	return handler(ctx, req)
+}
+
+// setUserID sets the user_id field of req, if it has one.
+func setUserID(req any, userID int) {
+	message, ok := req.(protoreflect.ProtoMessage)
+	if !ok {
+		return
+	}
+	field := message.ProtoReflect().Descriptor().Fields().ByName("user_id")
+	if field != nil && field.Kind() == protoreflect.Int32Kind {
+		message.ProtoReflect().Set(field, protoreflect.ValueOfInt32(int32(userID)))
+	}
+}
+
+// idempotencyKey returns the idempotency_key field of req, if it has one.
+func idempotencyKey(req any) string {
+	message, ok := req.(protoreflect.ProtoMessage)
+	if !ok {
+		return ""
+	}
+	field := message.ProtoReflect().Descriptor().Fields().ByName("idempotency_key")
+	if field == nil || field.Kind() != protoreflect.StringKind {
+		return ""
+	}
+	return message.ProtoReflect().Get(field).String()
+}
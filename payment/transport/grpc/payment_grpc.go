@@ -1,71 +1,154 @@
-package grpc
-
-import (
-	"context"
-	"net"
-	"payment/cmd/db"
-	"payment/proto"
-	"payment/repository"
-	"payment/service"
-	"payment/transport/kafka"
-
-	"github.com/sirupsen/logrus"
-	"google.golang.org/grpc"
-)
-
-type PaymentGRPCServer struct {
-	service *service.PaymentService
-	proto.UnimplementedPaymentServiceServer
-}
-
-func NewPaymentGRPCServer(service *service.PaymentService) *PaymentGRPCServer {
-	return &PaymentGRPCServer{
-		service: service,
-	}
-}
-
-func (u *PaymentGRPCServer) PayOrder(ctx context.Context, req *proto.CreatePaymentRequest) (*proto.OrderPayment, error) {
-	payment, err := u.service.AddPayment(req)
-	if err != nil {
-		return nil, err
-	}
-
-	return payment, nil
-}
-
-func (u *PaymentGRPCServer) Transaction(ctx context.Context, req *proto.PaymentTransaction) (*proto.EmptyPayment, error) {
-	if err := u.service.Transaction(req); err != nil {
-		return nil, err
-	}
-
-	return &proto.EmptyPayment{}, nil
-}
-
-func GRPCListen(addr []string, topic []string, groupID string) {
-	DB, err := db.Connect()
-	if err != nil {
-		logrus.Fatalf("failed to connect to database: %v", err)
-	}
-
-	ctx := context.Background()
-	paymentRepo := repository.NewPaymentRepository()
-	orderRepo := repository.NewOrderRepository()
-	service := service.NewPaymentService(paymentRepo, DB, ctx, orderRepo)
-	connection := NewPaymentGRPCServer(service)
-
-	lis, err := net.Listen("tcp", ":60001")
-	if err != nil {
-		logrus.Fatalf("Failed to listen for gRPC: %v", err)
-	}
-
-	srv := grpc.NewServer()
-	proto.RegisterPaymentServiceServer(srv, connection)
-	logrus.Infof("gRPC Server started on port 60001")
-
-	go func() {
-		if err := srv.Serve(lis); err != nil {
-			logrus.Fatalf("error when connect to gRPC Server: %v", err)
-		}
-	}()
-	go kafka.ProcessMessage(addr, topic, groupID, service)
-}
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"payment/cmd/db"
+	"payment/proto"
+	"payment/repository"
+	"payment/service"
+	"payment/transport/kafka"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type PaymentGRPCServer struct {
+	service *service.PaymentService
+	reports *service.ReportService
+	proto.UnimplementedPaymentServiceServer
+}
+
+func NewPaymentGRPCServer(service *service.PaymentService, reports *service.ReportService) *PaymentGRPCServer {
+	return &PaymentGRPCServer{
+		service: service,
+		reports: reports,
+	}
+}
+
+func (u *PaymentGRPCServer) PayOrder(ctx context.Context, req *proto.CreatePaymentRequest) (*proto.OrderPayment, error) {
+	payment, err := u.service.AddPayment(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (u *PaymentGRPCServer) Transaction(ctx context.Context, req *proto.PaymentTransaction) (*proto.EmptyPayment, error) {
+	if err := u.service.Transaction(req); err != nil {
+		return nil, err
+	}
+
+	return &proto.EmptyPayment{}, nil
+}
+
+func (u *PaymentGRPCServer) GetPayment(ctx context.Context, req *proto.GetPaymentRequest) (*proto.OrderPayment, error) {
+	payment, err := u.service.GetPayment(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (u *PaymentGRPCServer) VoidPayment(ctx context.Context, req *proto.GetPaymentRequest) (*proto.OrderPayment, error) {
+	payment, err := u.service.VoidPayment(req)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (u *PaymentGRPCServer) ExpirePayment(ctx context.Context, req *proto.GetPaymentRequest) (*proto.OrderPayment, error) {
+	payment, err := u.service.ExpirePayment(req)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (u *PaymentGRPCServer) RefundPayment(ctx context.Context, req *proto.RefundPaymentRequest) (*proto.PaymentRefund, error) {
+	refund, err := u.service.RefundPayment(req)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+func (u *PaymentGRPCServer) UpdatePaymentAmount(ctx context.Context, req *proto.UpdatePaymentAmountRequest) (*proto.OrderPayment, error) {
+	payment, err := u.service.UpdatePaymentAmount(req)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (u *PaymentGRPCServer) GetPaymentReport(ctx context.Context, req *proto.PaymentReportRequest) (*proto.PaymentReport, error) {
+	report, err := u.reports.GetPaymentReport(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func GRPCListen(addr []string, topic []string, groupID string) {
+	DB, err := db.Connect()
+	if err != nil {
+		logrus.Fatalf("failed to connect to database: %v", err)
+	}
+
+	ctx := context.Background()
+	paymentRepo := repository.NewPaymentRepository()
+	outboxRepo := repository.NewOutboxRepository()
+	idempotencyRepo := repository.NewIdempotencyRepository()
+	refundRepo := repository.NewRefundRepository()
+	reportRepo := repository.NewReportRepository()
+	outboxRelay := service.NewOutboxRelay(DB, outboxRepo)
+	reports := service.NewReportService(reportRepo, DB, ctx)
+	service := service.NewPaymentService(paymentRepo, DB, ctx, outboxRepo, idempotencyRepo, refundRepo)
+	connection := NewPaymentGRPCServer(service, reports)
+
+	if err := kafka.ConnectProducer(addr); err != nil {
+		logrus.Fatalf("failed to connect to kafka: %v", err)
+	}
+
+	lis, err := net.Listen("tcp", ":60001")
+	if err != nil {
+		logrus.Fatalf("Failed to listen for gRPC: %v", err)
+	}
+
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(authorizeUnary),
+	)
+	proto.RegisterPaymentServiceServer(srv, connection)
+	logrus.Infof("gRPC Server started on port 60001")
+
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			logrus.Fatalf("error when connect to gRPC Server: %v", err)
+		}
+	}()
+	go outboxRelay.Run(context.Background())
+	go kafka.ProcessMessage(addr, topic, groupID, service)
+}
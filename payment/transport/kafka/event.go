@@ -0,0 +1,144 @@
+package kafka
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math"
+	"payment/proto"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// EventOrderCreated is the type of the events of new orders, version 1 carries a proto.Order.
+const EventOrderCreated = "order.created"
+
+// Event types published by the payment service, the order service moves orders to paid or failed on them.
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+)
+
+// PaymentEventVersion is the version of the proto.PaymentResult payload of the payment events.
+const PaymentEventVersion = 1
+
+// Headers of every event message, set by both the order and the payment service.
+const (
+	HeaderEventType     = "event-type"
+	HeaderEventVersion  = "event-version"
+	HeaderContentType   = "content-type"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// legacyOrder is the JSON the order service published for a new order before events had envelopes. Its
+// total_price is in major units of legacyCurrency, the only currency orders had then.
+type legacyOrder struct {
+	Id         int32   `json:"id"`
+	UserId     int32   `json:"user_id"`
+	TotalPrice float64 `json:"total_price"`
+	Status     string  `json:"status"`
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  string  `json:"updated_at"`
+}
+
+// legacyCurrency has 100 minor units, like the stored amounts migration 015 converted.
+const legacyCurrency = "IDR"
+
+// decodeEvent reads the EventEnvelope of a message. Messages without the headers are a legacyOrder, they
+// are converted to a proto.Order in minor units and wrapped in an order.created envelope.
+func decodeEvent(msg *sarama.ConsumerMessage) (*proto.EventEnvelope, error) {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, v := range msg.Headers {
+		headers[string(v.Key)] = string(v.Value)
+	}
+
+	if headers[HeaderContentType] == ContentTypeProtobuf {
+		envelope := &proto.EventEnvelope{}
+		if err := protobuf.Unmarshal(msg.Value, envelope); err != nil {
+			return nil, err
+		}
+		if eventType := headers[HeaderEventType]; eventType != "" && eventType != envelope.Type {
+			return nil, fmt.Errorf("event type header %s does not match envelope type %s", eventType, envelope.Type)
+		}
+		return envelope, nil
+	}
+
+	legacy := &legacyOrder{}
+	if err := json.Unmarshal(msg.Value, legacy); err != nil {
+		return nil, err
+	}
+	order := &proto.Order{
+		Id:           legacy.Id,
+		UserId:       legacy.UserId,
+		TotalPrice:   int64(math.Round(legacy.TotalPrice * 100)),
+		Status:       legacy.Status,
+		Currency:     legacyCurrency,
+		BaseCurrency: legacyCurrency,
+		ExchangeRate: 1,
+		CreatedAt:    legacy.CreatedAt,
+		UpdatedAt:    legacy.UpdatedAt,
+	}
+	payload, err := anypb.New(order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.EventEnvelope{
+		Type:        EventOrderCreated,
+		Version:     1,
+		AggregateId: strconv.Itoa(int(order.Id)),
+		Payload:     payload,
+	}, nil
+}
+
+// MarshalEvent wraps payload in an EventEnvelope and encodes it, the result is what the outbox stores
+// and SendMessage publishes.
+func MarshalEvent(eventType string, version int32, aggregateID int, payload protobuf.Message) ([]byte, error) {
+	eventID, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+	data, err := anypb.New(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return protobuf.Marshal(&proto.EventEnvelope{
+		EventId:     eventID,
+		Type:        eventType,
+		Version:     version,
+		OccurredAt:  timestamppb.New(time.Now()),
+		AggregateId: strconv.Itoa(aggregateID),
+		Payload:     data,
+	})
+}
+
+// eventHeaders returns the headers of an encoded EventEnvelope.
+func eventHeaders(data []byte) []sarama.RecordHeader {
+	envelope := &proto.EventEnvelope{}
+	if err := protobuf.Unmarshal(data, envelope); err != nil || envelope.Type == "" {
+		return nil
+	}
+
+	return []sarama.RecordHeader{
+		{Key: []byte(HeaderEventType), Value: []byte(envelope.Type)},
+		{Key: []byte(HeaderEventVersion), Value: []byte(strconv.Itoa(int(envelope.Version)))},
+		{Key: []byte(HeaderContentType), Value: []byte(ContentTypeProtobuf)},
+	}
+}
+
+// newEventID returns a random (version 4) UUID.
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -1,108 +1,162 @@
-package kafka
-
-import (
-	"context"
-	"encoding/json"
-	"os"
-	"os/signal"
-	"payment/proto"
-	"payment/service"
-	"syscall"
-	"time"
-
-	"github.com/IBM/sarama"
-	"github.com/sirupsen/logrus"
-)
-
-type ConsumerHandler struct {
-	service *service.PaymentService
-}
-
-func connectKafka(addr []string, groupID string) (sarama.ConsumerGroup, error) {
-	config := sarama.NewConfig()
-	config.Consumer.Group.Rebalance.Strategy = sarama.NewBalanceStrategyRange()
-	config.Consumer.Offsets.Initial = sarama.OffsetOldest
-	config.Consumer.Return.Errors = true
-
-	var CGError error
-
-	for i := 0; i < 5; i++ {
-		worker, err := sarama.NewConsumerGroup(addr, groupID, config)
-		if err == nil {
-			logrus.Info("Connected to kafka")
-			return worker, nil
-		}
-
-		CGError = err
-		logrus.Warnf("failed connect to kafka, retrying...(%d/5)", i+1)
-		time.Sleep(5 * time.Second)
-		continue
-	}
-
-	return nil, CGError
-}
-
-func ProcessMessage(addr []string, topic []string, groupID string, service *service.PaymentService) {
-	sigchan := make(chan os.Signal, 1)
-	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
-
-	consumerGroup, err := connectKafka(addr, groupID)
-	if err != nil {
-		logrus.Fatalf("error when connect to kafka: %v", err)
-	}
-	defer consumerGroup.Close()
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	handler := &ConsumerHandler{service: service}
-
-	go func() {
-		logrus.Infof("addr: %s topic: %s groupID: %s", addr, topic, groupID)
-		for {
-			select {
-			case <-ctx.Done():
-				logrus.Info("Consumer stopping...")
-				return
-			default:
-				if err := consumerGroup.Consume(ctx, topic, handler); err != nil {
-					logrus.Errorf("failed when consume partition, retrying: %v", err)
-					time.Sleep(2 * time.Second)
-				}
-			}
-		}
-	}()
-
-	<-sigchan
-	logrus.Info("shutting down consumer...")
-	cancel()
-}
-
-func (h *ConsumerHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
-func (h *ConsumerHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
-
-func (h *ConsumerHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for msg := range claim.Messages() {
-		order := new(proto.Order)
-		if err := json.Unmarshal(msg.Value, &order); err != nil {
-			logrus.Errorf("Error parsing message: %s. Raw data: %s", err, string(msg.Value))
-			continue
-		}
-		logrus.Infof("Received message, UserID: %d with OrderId: %d \n", order.UserId, order.Id)
-		response, err := h.service.AddPayment(&proto.CreatePaymentRequest{
-			OrderId:    order.Id,
-			UserId:     order.UserId,
-			TotalPrice: order.TotalPrice,
-		})
-		if err != nil {
-			logrus.Errorf("Error creating payment: %v", err)
-			continue
-		}
-
-		logrus.Infof("Payment created with ID: %d", response.Id)
-
-		sess.MarkMessage(msg, "")
-	}
-
-	return nil
-}
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"payment/proto"
+	"syscall"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	handlerBaseBackoff = time.Second
+	handlerMaxBackoff  = time.Minute
+)
+
+// PaymentCreator creates the payment of a new order, it is implemented by service.PaymentService.
+type PaymentCreator interface {
+	AddPayment(payment *proto.CreatePaymentRequest) (*proto.OrderPayment, error)
+}
+
+type ConsumerHandler struct {
+	service PaymentCreator
+}
+
+func connectKafka(addr []string, groupID string) (sarama.ConsumerGroup, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Group.Rebalance.Strategy = sarama.NewBalanceStrategyRange()
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Return.Errors = true
+
+	var CGError error
+
+	for i := 0; i < 5; i++ {
+		worker, err := sarama.NewConsumerGroup(addr, groupID, config)
+		if err == nil {
+			logrus.Info("Connected to kafka")
+			return worker, nil
+		}
+
+		CGError = err
+		logrus.Warnf("failed connect to kafka, retrying...(%d/5)", i+1)
+		time.Sleep(5 * time.Second)
+		continue
+	}
+
+	return nil, CGError
+}
+
+// ProcessMessage creates the payments of the new orders of topic until the service is stopped. An event
+// is only committed once its payment is created, a message that cannot be decoded is skipped.
+func ProcessMessage(addr []string, topic []string, groupID string, service PaymentCreator) {
+	sigchan := make(chan os.Signal, 1)
+	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
+
+	consumerGroup, err := connectKafka(addr, groupID)
+	if err != nil {
+		logrus.Fatalf("error when connect to kafka: %v", err)
+	}
+	defer consumerGroup.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handler := &ConsumerHandler{service: service}
+
+	go func() {
+		logrus.Infof("addr: %s topic: %s groupID: %s", addr, topic, groupID)
+		for {
+			select {
+			case <-ctx.Done():
+				logrus.Info("Consumer stopping...")
+				return
+			default:
+				if err := consumerGroup.Consume(ctx, topic, handler); err != nil {
+					logrus.Errorf("failed when consume partition, retrying: %v", err)
+					time.Sleep(2 * time.Second)
+				}
+			}
+		}
+	}()
+
+	<-sigchan
+	logrus.Info("shutting down consumer...")
+	cancel()
+}
+
+func (h *ConsumerHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
+func (h *ConsumerHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
+
+func (h *ConsumerHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		envelope, err := decodeEvent(msg)
+		if err != nil {
+			logrus.Errorf("Skipping message at offset %d: %v. Raw data: %s", msg.Offset, err, string(msg.Value))
+			sess.MarkMessage(msg, "")
+			continue
+		}
+
+		// Later events of the partition wait, an order whose payment cannot be created now is retried
+		backoff := handlerBaseBackoff
+		for {
+			err := h.handleEvent(envelope)
+			if err == nil {
+				break
+			}
+			logrus.Errorf("Error handling %s event %s, retrying in %s: %v", envelope.Type, envelope.EventId, backoff, err)
+			select {
+			case <-sess.Context().Done():
+				return nil
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, handlerMaxBackoff)
+		}
+
+		sess.MarkMessage(msg, "")
+	}
+
+	return nil
+}
+
+// handleEvent dispatches an event by its type, types and versions the payment service does not know
+// are skipped.
+func (h *ConsumerHandler) handleEvent(envelope *proto.EventEnvelope) error {
+	switch envelope.Type {
+	case EventOrderCreated:
+		if envelope.Version != 1 {
+			logrus.Warnf("Skipping %s event %s with unknown version %d", envelope.Type, envelope.EventId, envelope.Version)
+			return nil
+		}
+		order := &proto.Order{}
+		if err := envelope.Payload.UnmarshalTo(order); err != nil {
+			return err
+		}
+		return h.createPayment(order)
+	default:
+		logrus.Warnf("Skipping event %s with unknown type %s", envelope.EventId, envelope.Type)
+		return nil
+	}
+}
+
+func (h *ConsumerHandler) createPayment(order *proto.Order) error {
+	logrus.Infof("Received message, UserID: %d with OrderId: %d \n", order.UserId, order.Id)
+	response, err := h.service.AddPayment(&proto.CreatePaymentRequest{
+		OrderId:      order.Id,
+		UserId:       order.UserId,
+		TotalPrice:   order.TotalPrice,
+		Currency:     order.Currency,
+		BaseCurrency: order.BaseCurrency,
+		ExchangeRate: order.ExchangeRate,
+	})
+	if err != nil {
+		return fmt.Errorf("creating payment: %w", err)
+	}
+
+	logrus.Infof("Payment created with ID: %d", response.Id)
+	return nil
+}
@@ -1,111 +1,174 @@
-package repository
-
-import (
-	"context"
-	"database/sql"
-	"errors"
-	"product_service/proto"
-)
-
-type ProductRepository interface {
-	Create(ctx context.Context, tx *sql.Tx, payload *proto.ProductPayload) error
-	GetProductByID(ctx context.Context, tx *sql.Tx, ID int) (*proto.Product, error)
-	GetAllProduct(ctx context.Context, db *sql.DB, offset int) ([]*proto.Product, int, int, error)
-	UpdateProduct(ctx context.Context, tx *sql.Tx, payload *proto.Product) error
-	DeleteProduct(ctx context.Context, tx *sql.Tx, ID int) error
-}
-
-type ProductRepositoryImpl struct{}
-
-func NewProductRepositoryImpl() *ProductRepositoryImpl {
-	return &ProductRepositoryImpl{}
-}
-
-func (u *ProductRepositoryImpl) Create(ctx context.Context, tx *sql.Tx, payload *proto.ProductPayload) error {
-	SQL := "INSERT INTO products(name, description, price, stock) VALUES ($1, $2, $3, $4)"
-	if _, err := tx.ExecContext(ctx, SQL, payload.Name, payload.Description, payload.Price, payload.Stock); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (u *ProductRepositoryImpl) GetProductByID(ctx context.Context, tx *sql.Tx, ID int) (*proto.Product, error) {
-	SQL := "SELECT id, name, description, price, stock, created_at, updated_at FROM products WHERE id = $1"
-	rows := tx.QueryRowContext(ctx, SQL, ID)
-
-	productResponse := &proto.Product{}
-	if err := rows.Scan(
-		&productResponse.Id,
-		&productResponse.Name,
-		&productResponse.Description,
-		&productResponse.Price,
-		&productResponse.Stock,
-		&productResponse.CreatedAt,
-		&productResponse.UpdatedAt,
-	); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("product not found")
-		}
-		return nil, err
-	}
-	return productResponse, nil
-}
-
-func (u *ProductRepositoryImpl) GetAllProduct(ctx context.Context, db *sql.DB, offset int) ([]*proto.Product, int, int, error) {
-	SQL := "SELECT id, name, description, price, stock, created_at, updated_at FROM products ORDER BY id ASC LIMIT 15 OFFSET $1"
-	rows, err := db.QueryContext(ctx, SQL, offset)
-	if err != nil {
-		return nil, 0, 0, err
-	}
-	defer rows.Close()
-
-	var products []*proto.Product
-	for rows.Next() {
-		productItem := &proto.Product{}
-		if err := rows.Scan(&productItem.Id, &productItem.Name, &productItem.Description, &productItem.Price, &productItem.Stock, &productItem.CreatedAt, &productItem.UpdatedAt); err != nil {
-			return nil, 0, 0, err
-		}
-		products = append(products, productItem)
-	}
-
-	var totalProduct int
-	if err := db.QueryRow("SELECT COUNT(id) FROM products").Scan(&totalProduct); err != nil {
-		return nil, 0, 0, err
-	}
-	totalPage := (totalProduct + 15 - 1) / 15
-
-	return products, totalProduct, totalPage, nil
-}
-
-func (u *ProductRepositoryImpl) UpdateProduct(ctx context.Context, tx *sql.Tx, payload *proto.Product) error {
-	SQL := `UPDATE products
-        SET name = COALESCE(NULLIF($1, ''), name),
-            description = COALESCE(NULLIF($2, ''), description),
-            price = $3,
-            stock = $4,
-            updated_at = NOW()
-        WHERE id = $5`
-	if _, err := tx.ExecContext(ctx, SQL, payload.Name, payload.Description, payload.Price, payload.Stock, payload.Id); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (repository *ProductRepositoryImpl) DeleteProduct(ctx context.Context, tx *sql.Tx, ID int) error {
-	SQL := "DELETE FROM products WHERE id = $1"
-	result, err := tx.ExecContext(ctx, SQL, ID)
-	if err != nil {
-		return err
-	}
-
-	affected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if affected == 0 {
-		return errors.New("product not found")
-	}
-
-	return nil
-}
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"product_service/proto"
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrStockNotEnough  = errors.New("stock is not enough")
+)
+
+type ProductRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, payload *proto.ProductPayload) error
+	GetProductByID(ctx context.Context, tx *sql.Tx, ID int) (*proto.Product, error)
+	GetAllProduct(ctx context.Context, db *sql.DB, offset int) ([]*proto.Product, int, int, error)
+	UpdateProduct(ctx context.Context, tx *sql.Tx, payload *proto.Product) error
+	DeleteProduct(ctx context.Context, tx *sql.Tx, ID int) error
+	DecreaseStock(ctx context.Context, tx *sql.Tx, ID int, quantity int) (*proto.Product, error)
+	IncreaseStock(ctx context.Context, tx *sql.Tx, ID int, quantity int) error
+}
+
+type ProductRepositoryImpl struct{}
+
+func NewProductRepositoryImpl() *ProductRepositoryImpl {
+	return &ProductRepositoryImpl{}
+}
+
+func (u *ProductRepositoryImpl) Create(ctx context.Context, tx *sql.Tx, payload *proto.ProductPayload) error {
+	SQL := `INSERT INTO products(name, description, price, currency, stock, category, tax_class, weight_grams)
+        VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'IDR'), $5, $6, COALESCE(NULLIF($7, ''), 'standard'), $8)`
+	if _, err := tx.ExecContext(ctx, SQL, payload.Name, payload.Description, payload.Price, payload.Currency, payload.Stock, payload.Category, payload.TaxClass, payload.WeightGrams); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *ProductRepositoryImpl) GetProductByID(ctx context.Context, tx *sql.Tx, ID int) (*proto.Product, error) {
+	SQL := "SELECT id, name, description, price, currency, stock, category, tax_class, weight_grams, created_at, updated_at FROM products WHERE id = $1"
+	rows := tx.QueryRowContext(ctx, SQL, ID)
+
+	productResponse := &proto.Product{}
+	if err := rows.Scan(
+		&productResponse.Id,
+		&productResponse.Name,
+		&productResponse.Description,
+		&productResponse.Price,
+		&productResponse.Currency,
+		&productResponse.Stock,
+		&productResponse.Category,
+		&productResponse.TaxClass,
+		&productResponse.WeightGrams,
+		&productResponse.CreatedAt,
+		&productResponse.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return productResponse, nil
+}
+
+func (u *ProductRepositoryImpl) GetAllProduct(ctx context.Context, db *sql.DB, offset int) ([]*proto.Product, int, int, error) {
+	SQL := "SELECT id, name, description, price, currency, stock, category, tax_class, weight_grams, created_at, updated_at FROM products ORDER BY id ASC LIMIT 15 OFFSET $1"
+	rows, err := db.QueryContext(ctx, SQL, offset)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer rows.Close()
+
+	var products []*proto.Product
+	for rows.Next() {
+		productItem := &proto.Product{}
+		if err := rows.Scan(&productItem.Id, &productItem.Name, &productItem.Description, &productItem.Price, &productItem.Currency, &productItem.Stock, &productItem.Category, &productItem.TaxClass, &productItem.WeightGrams, &productItem.CreatedAt, &productItem.UpdatedAt); err != nil {
+			return nil, 0, 0, err
+		}
+		products = append(products, productItem)
+	}
+
+	var totalProduct int
+	if err := db.QueryRow("SELECT COUNT(id) FROM products").Scan(&totalProduct); err != nil {
+		return nil, 0, 0, err
+	}
+	totalPage := (totalProduct + 15 - 1) / 15
+
+	return products, totalProduct, totalPage, nil
+}
+
+func (u *ProductRepositoryImpl) UpdateProduct(ctx context.Context, tx *sql.Tx, payload *proto.Product) error {
+	SQL := `UPDATE products
+        SET name = COALESCE(NULLIF($1, ''), name),
+            description = COALESCE(NULLIF($2, ''), description),
+            price = $3,
+            currency = COALESCE(NULLIF($4, ''), currency),
+            stock = $5,
+            category = COALESCE(NULLIF($6, ''), category),
+            tax_class = COALESCE(NULLIF($7, ''), tax_class),
+            weight_grams = COALESCE(NULLIF($8, 0), weight_grams),
+            updated_at = NOW()
+        WHERE id = $9`
+	if _, err := tx.ExecContext(ctx, SQL, payload.Name, payload.Description, payload.Price, payload.Currency, payload.Stock, payload.Category, payload.TaxClass, payload.WeightGrams, payload.Id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *ProductRepositoryImpl) DeleteProduct(ctx context.Context, tx *sql.Tx, ID int) error {
+	SQL := "DELETE FROM products WHERE id = $1"
+	result, err := tx.ExecContext(ctx, SQL, ID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+// DecreaseStock only succeeds when enough stock is left, so concurrent checkouts can never oversell.
+func (u *ProductRepositoryImpl) DecreaseStock(ctx context.Context, tx *sql.Tx, ID int, quantity int) (*proto.Product, error) {
+	SQL := `UPDATE products
+        SET stock = stock - $1,
+            updated_at = NOW()
+        WHERE id = $2 AND stock >= $1
+        RETURNING id, name, description, price, currency, stock, category, tax_class, weight_grams, created_at, updated_at`
+	rows := tx.QueryRowContext(ctx, SQL, quantity, ID)
+
+	productResponse := &proto.Product{}
+	if err := rows.Scan(
+		&productResponse.Id,
+		&productResponse.Name,
+		&productResponse.Description,
+		&productResponse.Price,
+		&productResponse.Currency,
+		&productResponse.Stock,
+		&productResponse.Category,
+		&productResponse.TaxClass,
+		&productResponse.WeightGrams,
+		&productResponse.CreatedAt,
+		&productResponse.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			var exists bool
+			if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", ID).Scan(&exists); err != nil {
+				return nil, err
+			}
+			if !exists {
+				return nil, ErrProductNotFound
+			}
+			return nil, ErrStockNotEnough
+		}
+		return nil, err
+	}
+	return productResponse, nil
+}
+
+func (u *ProductRepositoryImpl) IncreaseStock(ctx context.Context, tx *sql.Tx, ID int, quantity int) error {
+	SQL := "UPDATE products SET stock = stock + $1, updated_at = NOW() WHERE id = $2"
+	if _, err := tx.ExecContext(ctx, SQL, quantity, ID); err != nil {
+		return err
+	}
+
+	return nil
+}
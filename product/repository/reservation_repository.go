@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"product_service/proto"
+)
+
+var ErrReservationNotFound = errors.New("reservation not found")
+
+type ReservationRepository interface {
+	CreateReservation(ctx context.Context, tx *sql.Tx, ttlSeconds int) (*proto.Reservation, error)
+	CreateReservationItem(ctx context.Context, tx *sql.Tx, reservationID string, item *proto.ReservationItem) error
+	GetReservationForUpdate(ctx context.Context, tx *sql.Tx, reservationID string) (*proto.Reservation, error)
+	UpdateReservationStatus(ctx context.Context, tx *sql.Tx, reservationID string, status string, orderID int) error
+	GetExpiredReservationIDs(ctx context.Context, tx *sql.Tx, limit int) ([]string, error)
+	CreateRestock(ctx context.Context, tx *sql.Tx, reference string) (bool, error)
+	SetReservationItemQuantity(ctx context.Context, tx *sql.Tx, reservationID string, productID int, quantity int) error
+}
+
+type ReservationRepositoryImpl struct{}
+
+func NewReservationRepositoryImpl() *ReservationRepositoryImpl {
+	return &ReservationRepositoryImpl{}
+}
+
+func (u *ReservationRepositoryImpl) CreateReservation(ctx context.Context, tx *sql.Tx, ttlSeconds int) (*proto.Reservation, error) {
+	SQL := `INSERT INTO stock_reservations(expires_at) VALUES (NOW() + make_interval(secs => $1))
+        RETURNING id, status, expires_at`
+	reservation := &proto.Reservation{}
+	if err := tx.QueryRowContext(ctx, SQL, ttlSeconds).Scan(
+		&reservation.ReservationId,
+		&reservation.Status,
+		&reservation.ExpiresAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+func (u *ReservationRepositoryImpl) CreateReservationItem(ctx context.Context, tx *sql.Tx, reservationID string, item *proto.ReservationItem) error {
+	SQL := "INSERT INTO stock_reservation_items(reservation_id, product_id, quantity) VALUES ($1, $2, $3)"
+	if _, err := tx.ExecContext(ctx, SQL, reservationID, item.ProductId, item.Quantity); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *ReservationRepositoryImpl) GetReservationForUpdate(ctx context.Context, tx *sql.Tx, reservationID string) (*proto.Reservation, error) {
+	SQL := "SELECT id, COALESCE(order_id, 0), status, expires_at FROM stock_reservations WHERE id = $1 FOR UPDATE"
+	reservation := &proto.Reservation{}
+	if err := tx.QueryRowContext(ctx, SQL, reservationID).Scan(
+		&reservation.ReservationId,
+		&reservation.OrderId,
+		&reservation.Status,
+		&reservation.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReservationNotFound
+		}
+		return nil, err
+	}
+
+	SQL = "SELECT product_id, quantity FROM stock_reservation_items WHERE reservation_id = $1 ORDER BY product_id ASC"
+	rows, err := tx.QueryContext(ctx, SQL, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := &proto.ReservationItem{}
+		if err := rows.Scan(&item.ProductId, &item.Quantity); err != nil {
+			return nil, err
+		}
+		reservation.Items = append(reservation.Items, item)
+	}
+
+	return reservation, rows.Err()
+}
+
+func (u *ReservationRepositoryImpl) UpdateReservationStatus(ctx context.Context, tx *sql.Tx, reservationID string, status string, orderID int) error {
+	SQL := `UPDATE stock_reservations
+        SET status = $1,
+            order_id = COALESCE(NULLIF($2, 0), order_id),
+            updated_at = NOW()
+        WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, SQL, status, orderID, reservationID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *ReservationRepositoryImpl) GetExpiredReservationIDs(ctx context.Context, tx *sql.Tx, limit int) ([]string, error) {
+	SQL := `SELECT id FROM stock_reservations
+        WHERE status = 'reserved' AND expires_at <= NOW()
+        ORDER BY expires_at ASC
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED`
+	rows, err := tx.QueryContext(ctx, SQL, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservationIDs []string
+	for rows.Next() {
+		var reservationID string
+		if err := rows.Scan(&reservationID); err != nil {
+			return nil, err
+		}
+		reservationIDs = append(reservationIDs, reservationID)
+	}
+
+	return reservationIDs, rows.Err()
+}
+
+// CreateRestock returns false when the reference was already restocked.
+func (u *ReservationRepositoryImpl) CreateRestock(ctx context.Context, tx *sql.Tx, reference string) (bool, error) {
+	SQL := "INSERT INTO stock_restocks(reference) VALUES ($1) ON CONFLICT (reference) DO NOTHING"
+	result, err := tx.ExecContext(ctx, SQL, reference)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// SetReservationItemQuantity updates, adds or, for a zero quantity, removes the item of a product.
+func (u *ReservationRepositoryImpl) SetReservationItemQuantity(ctx context.Context, tx *sql.Tx, reservationID string, productID int, quantity int) error {
+	if quantity == 0 {
+		SQL := "DELETE FROM stock_reservation_items WHERE reservation_id = $1 AND product_id = $2"
+		_, err := tx.ExecContext(ctx, SQL, reservationID, productID)
+		return err
+	}
+
+	SQL := "UPDATE stock_reservation_items SET quantity = $1 WHERE reservation_id = $2 AND product_id = $3"
+	result, err := tx.ExecContext(ctx, SQL, quantity, reservationID, productID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	return u.CreateReservationItem(ctx, tx, reservationID, &proto.ReservationItem{
+		ProductId: int32(productID),
+		Quantity:  int32(quantity),
+	})
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"product_service/proto"
+	"product_service/repository"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultReservationTTL  = 15 * time.Minute
+	reservationSweepPeriod = 30 * time.Second
+	reservationSweepBatch  = 50
+)
+
+type ReservationService struct {
+	productRepo     repository.ProductRepository
+	reservationRepo repository.ReservationRepository
+	DB              *sql.DB
+	ctx             context.Context
+}
+
+func NewReservationService(productRepo repository.ProductRepository, reservationRepo repository.ReservationRepository, DB *sql.DB, ctx context.Context) *ReservationService {
+	return &ReservationService{
+		productRepo:     productRepo,
+		reservationRepo: reservationRepo,
+		DB:              DB,
+		ctx:             ctx,
+	}
+}
+
+// Reserve decrements the stock of every item in one transaction, either all items are reserved or none.
+func (u *ReservationService) Reserve(payload *proto.ReserveStockRequest) (*proto.Reservation, error) {
+	if len(payload.Items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "reservation has no items")
+	}
+
+	// Merge duplicate products and lock rows in a stable order to avoid deadlocks
+	quantities := make(map[int32]int32)
+	for _, v := range payload.Items {
+		if v.Quantity <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "quantity must be greater than zero")
+		}
+		quantities[v.ProductId] += v.Quantity
+	}
+	productIDs := make([]int32, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	ttl := int(payload.TtlSeconds)
+	if ttl <= 0 {
+		ttl = int(reservationTTL().Seconds())
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	logrus.Info("creating stock reservation")
+	reservation, err := u.reservationRepo.CreateReservation(u.ctx, tx, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, productID := range productIDs {
+		product, err := u.productRepo.DecreaseStock(u.ctx, tx, int(productID), int(quantities[productID]))
+		if err != nil {
+			logrus.Errorf("failed to reserve product %d: %v", productID, err)
+			return nil, err
+		}
+
+		item := &proto.ReservationItem{
+			ProductId:   productID,
+			Quantity:    quantities[productID],
+			Price:       product.Price,
+			Currency:    product.Currency,
+			Name:        product.Name,
+			Category:    product.Category,
+			TaxClass:    product.TaxClass,
+			WeightGrams: product.WeightGrams,
+		}
+		if err := u.reservationRepo.CreateReservationItem(u.ctx, tx, reservation.ReservationId, item); err != nil {
+			return nil, err
+		}
+		reservation.Items = append(reservation.Items, item)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	logrus.Infof("stock reserved with reservation ID: %s", reservation.ReservationId)
+
+	return reservation, nil
+}
+
+func (u *ReservationService) Commit(payload *proto.ReservationRequest) error {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	reservation, err := u.reservationRepo.GetReservationForUpdate(u.ctx, tx, payload.ReservationId)
+	if err != nil {
+		return err
+	}
+
+	switch reservation.Status {
+	case "committed":
+		return nil
+	case "reserved":
+	default:
+		return status.Error(codes.FailedPrecondition, "reservation is "+reservation.Status)
+	}
+
+	logrus.Infof("committing reservation %s", payload.ReservationId)
+	if err := u.reservationRepo.UpdateReservationStatus(u.ctx, tx, payload.ReservationId, "committed", int(payload.OrderId)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Release gives the stock back, it is a no-op for reservations that are already released or expired.
+func (u *ReservationService) Release(payload *proto.ReservationRequest) error {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := u.release(tx, payload.ReservationId, "released"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (u *ReservationService) release(tx *sql.Tx, reservationID string, status string) error {
+	reservation, err := u.reservationRepo.GetReservationForUpdate(u.ctx, tx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	if reservation.Status != "reserved" && reservation.Status != "committed" {
+		return nil
+	}
+
+	logrus.Infof("releasing reservation %s", reservationID)
+	for _, v := range reservation.Items {
+		if err := u.productRepo.IncreaseStock(u.ctx, tx, int(v.ProductId), int(v.Quantity)); err != nil {
+			return err
+		}
+	}
+
+	return u.reservationRepo.UpdateReservationStatus(u.ctx, tx, reservationID, status, 0)
+}
+
+// Adjust sets the quantity of one product in a reserved or committed reservation and moves the
+// difference in or out of stock. The quantity is absolute so a retried call changes nothing.
+func (u *ReservationService) Adjust(payload *proto.AdjustReservationRequest) (*proto.ReservationItem, error) {
+	if payload.Quantity < 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity must not be negative")
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reservation, err := u.reservationRepo.GetReservationForUpdate(u.ctx, tx, payload.ReservationId)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Status != "reserved" && reservation.Status != "committed" {
+		return nil, status.Error(codes.FailedPrecondition, "reservation is "+reservation.Status)
+	}
+
+	var current int32
+	for _, v := range reservation.Items {
+		if v.ProductId == payload.ProductId {
+			current = v.Quantity
+		}
+	}
+
+	productID := int(payload.ProductId)
+	var product *proto.Product
+	switch delta := int(payload.Quantity - current); {
+	case delta > 0:
+		product, err = u.productRepo.DecreaseStock(u.ctx, tx, productID, delta)
+	case delta < 0:
+		err = u.productRepo.IncreaseStock(u.ctx, tx, productID, -delta)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		if product, err = u.productRepo.GetProductByID(u.ctx, tx, productID); err != nil {
+			return nil, err
+		}
+	}
+
+	logrus.Infof("adjusting product %d in reservation %s from %d to %d", productID, payload.ReservationId, current, payload.Quantity)
+	if err := u.reservationRepo.SetReservationItemQuantity(u.ctx, tx, payload.ReservationId, productID, int(payload.Quantity)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &proto.ReservationItem{
+		ProductId:   payload.ProductId,
+		Quantity:    payload.Quantity,
+		Price:       product.Price,
+		Currency:    product.Currency,
+		Name:        product.Name,
+		Category:    product.Category,
+		TaxClass:    product.TaxClass,
+		WeightGrams: product.WeightGrams,
+	}, nil
+}
+
+// Restock puts returned items back in stock, a reference that was already restocked is a no-op.
+func (u *ReservationService) Restock(payload *proto.RestockRequest) error {
+	if payload.Reference == "" {
+		return status.Error(codes.InvalidArgument, "restock reference is required")
+	}
+	if len(payload.Items) == 0 {
+		return status.Error(codes.InvalidArgument, "restock has no items")
+	}
+
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	created, err := u.reservationRepo.CreateRestock(u.ctx, tx, payload.Reference)
+	if err != nil {
+		return err
+	}
+	if !created {
+		logrus.Infof("restock %s already applied", payload.Reference)
+		return nil
+	}
+
+	// Lock rows in a stable order to avoid deadlocks with reservations
+	items := append([]*proto.ReservationItem(nil), payload.Items...)
+	sort.Slice(items, func(i, j int) bool { return items[i].ProductId < items[j].ProductId })
+
+	logrus.Infof("restocking %s", payload.Reference)
+	for _, v := range items {
+		if v.Quantity <= 0 {
+			return status.Error(codes.InvalidArgument, "quantity must be greater than zero")
+		}
+		if err := u.productRepo.IncreaseStock(u.ctx, tx, int(v.ProductId), int(v.Quantity)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ExpireReservations periodically returns the stock of reservations whose TTL has passed.
+func (u *ReservationService) ExpireReservations(ctx context.Context) {
+	logrus.Info("Starting reservation expiry worker")
+	ticker := time.NewTicker(reservationSweepPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Reservation expiry worker stopping...")
+			return
+		case <-ticker.C:
+			if err := u.expireBatch(); err != nil {
+				logrus.Errorf("failed to expire reservations: %v", err)
+			}
+		}
+	}
+}
+
+func (u *ReservationService) expireBatch() error {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	reservationIDs, err := u.reservationRepo.GetExpiredReservationIDs(u.ctx, tx, reservationSweepBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, reservationID := range reservationIDs {
+		if err := u.release(tx, reservationID, "expired"); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func reservationTTL() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("STOCK_RESERVATION_TTL_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return defaultReservationTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,3 @@
+-- Drop outbox table
+DROP INDEX IF EXISTS idx_outbox_status_next_attempt;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,9 @@
+-- Drop stock reservation tables
+ALTER TABLE orders DROP COLUMN IF EXISTS reservation_id;
+
+DROP INDEX IF EXISTS idx_stock_reservation_items_reservation_id;
+DROP TABLE IF EXISTS stock_reservation_items;
+
+DROP INDEX IF EXISTS idx_stock_reservations_order_id;
+DROP INDEX IF EXISTS idx_stock_reservations_status_expires_at;
+DROP TABLE IF EXISTS stock_reservations;
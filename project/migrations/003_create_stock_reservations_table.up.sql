@@ -0,0 +1,32 @@
+-- Migration: Create stock reservation tables used by the product service
+
+-- Create stock_reservations table
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id INTEGER,
+    status VARCHAR(20) NOT NULL DEFAULT 'reserved',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Expired reservations are swept by status and expires_at
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_status_expires_at ON stock_reservations(status, expires_at);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_order_id ON stock_reservations(order_id);
+
+-- Create stock_reservation_items table
+CREATE TABLE IF NOT EXISTS stock_reservation_items (
+    id SERIAL PRIMARY KEY,
+    reservation_id UUID NOT NULL,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+
+    -- Foreign key constraints
+    CONSTRAINT fk_stock_reservation_items_reservation_id FOREIGN KEY (reservation_id) REFERENCES stock_reservations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_stock_reservation_items_product_id FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservation_items_reservation_id ON stock_reservation_items(reservation_id);
+
+-- Orders keep the reservation holding their stock
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS reservation_id UUID;
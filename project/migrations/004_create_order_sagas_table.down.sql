@@ -0,0 +1,7 @@
+-- Restore non unique payments index
+DROP INDEX IF EXISTS idx_payments_order_id;
+CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments(order_id);
+
+-- Drop order_sagas table
+DROP INDEX IF EXISTS idx_order_sagas_status_next_attempt;
+DROP TABLE IF EXISTS order_sagas;
@@ -0,0 +1,26 @@
+-- Migration: Create order saga state table and make payments unique per order
+
+-- Create order_sagas table
+CREATE TABLE IF NOT EXISTS order_sagas (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL UNIQUE,
+    reservation_id UUID,
+    payment_id INTEGER,
+    step VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- Foreign key constraint
+    CONSTRAINT fk_order_sagas_order_id FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+-- Active sagas are resumed by status and next_attempt_at
+CREATE INDEX IF NOT EXISTS idx_order_sagas_status_next_attempt ON order_sagas(status, next_attempt_at);
+
+-- Payment creation is idempotent per order
+DROP INDEX IF EXISTS idx_payments_order_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_order_id ON payments(order_id);
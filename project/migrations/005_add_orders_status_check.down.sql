@@ -0,0 +1,3 @@
+-- Drop orders status constraint
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_status;
+ALTER TABLE orders ALTER COLUMN status DROP NOT NULL;
@@ -0,0 +1,7 @@
+-- Migration: Normalize order statuses and restrict them to the OrderStatus values
+
+UPDATE orders SET status = LOWER(status);
+
+ALTER TABLE orders ALTER COLUMN status SET NOT NULL;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_status
+    CHECK (status IN ('pending', 'paid', 'fulfilled', 'completed', 'cancelled', 'failed'));
@@ -0,0 +1,2 @@
+-- Drop order history listing index
+DROP INDEX IF EXISTS idx_orders_user_id_created_at;
@@ -0,0 +1,3 @@
+-- Migration: Index used by the order history listing
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id_created_at ON orders(user_id, created_at, id);
@@ -0,0 +1,6 @@
+-- Restore product foreign key and drop price snapshot columns
+DELETE FROM order_items oi WHERE NOT EXISTS (SELECT 1 FROM products p WHERE p.id = oi.product_id);
+ALTER TABLE order_items ADD CONSTRAINT fk_order_items_product_id FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE;
+
+ALTER TABLE order_items DROP COLUMN IF EXISTS product_name;
+ALTER TABLE order_items DROP COLUMN IF EXISTS price;
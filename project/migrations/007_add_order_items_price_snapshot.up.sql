@@ -0,0 +1,14 @@
+-- Migration: Snapshot unit price and product name on order items
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS price DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS product_name VARCHAR(100) NOT NULL DEFAULT '';
+
+-- Backfill existing rows with the current product data
+UPDATE order_items oi
+SET price = p.price,
+    product_name = p.name
+FROM products p
+WHERE p.id = oi.product_id;
+
+-- Order items must survive product deletion
+ALTER TABLE order_items DROP CONSTRAINT IF EXISTS fk_order_items_product_id;
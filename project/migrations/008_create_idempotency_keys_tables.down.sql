@@ -0,0 +1,3 @@
+-- Drop idempotency key tables
+DROP TABLE IF EXISTS payment_idempotency_keys;
+DROP TABLE IF EXISTS order_idempotency_keys;
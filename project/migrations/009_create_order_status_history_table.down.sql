@@ -0,0 +1,3 @@
+-- Drop order status history table
+DROP INDEX IF EXISTS idx_order_status_history_order_id;
+DROP TABLE IF EXISTS order_status_history;
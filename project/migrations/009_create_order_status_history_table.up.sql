@@ -0,0 +1,20 @@
+-- Migration: Audit trail of every order status change
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    old_status VARCHAR(50),
+    new_status VARCHAR(50) NOT NULL,
+    actor VARCHAR(100) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_order_status_history_order_id FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+-- History is read per order in the order it was written
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history(order_id, id);
+
+-- Existing orders start their timeline with the status they have now
+INSERT INTO order_status_history(order_id, old_status, new_status, actor, reason, created_at)
+SELECT id, NULL, status, 'system', 'recorded before status history existed', COALESCE(updated_at, created_at)
+FROM orders;
@@ -0,0 +1,10 @@
+-- Drop returns, restock and refund tables
+DROP INDEX IF EXISTS idx_payment_refunds_payment_id;
+DROP TABLE IF EXISTS payment_refunds;
+ALTER TABLE payments DROP COLUMN IF EXISTS refunded_amount;
+DROP TABLE IF EXISTS stock_restocks;
+DROP INDEX IF EXISTS idx_order_return_items_return_id;
+DROP TABLE IF EXISTS order_return_items;
+DROP INDEX IF EXISTS idx_order_returns_status;
+DROP INDEX IF EXISTS idx_order_returns_order_id;
+DROP TABLE IF EXISTS order_returns;
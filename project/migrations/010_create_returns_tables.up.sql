@@ -0,0 +1,53 @@
+-- Migration: Returns (RMA) with restocking and partial refunds
+
+CREATE TABLE IF NOT EXISTS order_returns (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'requested',
+    reason TEXT,
+    admin_note TEXT,
+    refund_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    refund_id INTEGER,
+    reviewed_by VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_order_returns_order_id FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT chk_order_returns_status CHECK (status IN ('requested', 'approved', 'rejected', 'refunded'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_returns_order_id ON order_returns(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_returns_status ON order_returns(status);
+
+CREATE TABLE IF NOT EXISTS order_return_items (
+    id SERIAL PRIMARY KEY,
+    return_id INTEGER NOT NULL,
+    order_item_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price DOUBLE PRECISION NOT NULL,
+    CONSTRAINT fk_order_return_items_return_id FOREIGN KEY (return_id) REFERENCES order_returns(id) ON DELETE CASCADE,
+    CONSTRAINT fk_order_return_items_order_item_id FOREIGN KEY (order_item_id) REFERENCES order_items(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_return_items_return_id ON order_return_items(return_id);
+
+-- Restocks are recorded by reference so a retried restock does not add the stock twice
+CREATE TABLE IF NOT EXISTS stock_restocks (
+    reference VARCHAR(100) PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id SERIAL PRIMARY KEY,
+    payment_id INTEGER NOT NULL,
+    amount DOUBLE PRECISION NOT NULL CHECK (amount > 0),
+    reason TEXT,
+    reference VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_payment_refunds_payment_id FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_payment_id ON payment_refunds(payment_id);
@@ -0,0 +1,2 @@
+-- Drop cart items table
+DROP TABLE IF EXISTS cart_items;
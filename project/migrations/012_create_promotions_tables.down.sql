@@ -0,0 +1,14 @@
+-- Drop promotion tables and the order pricing columns
+DROP INDEX IF EXISTS idx_order_discounts_order_id;
+DROP TABLE IF EXISTS order_discounts;
+DROP INDEX IF EXISTS idx_promotion_redemptions_user_id;
+DROP TABLE IF EXISTS promotion_redemptions;
+DROP INDEX IF EXISTS idx_promotions_active;
+DROP INDEX IF EXISTS idx_promotions_code;
+DROP TABLE IF EXISTS promotions;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS coupon_code;
+ALTER TABLE orders DROP COLUMN IF EXISTS discount_total;
+ALTER TABLE orders DROP COLUMN IF EXISTS subtotal;
+ALTER TABLE order_items DROP COLUMN IF EXISTS category;
+ALTER TABLE products DROP COLUMN IF EXISTS category;
@@ -0,0 +1,75 @@
+-- Migration: Coupons, automatic promotions and the discounts applied to orders
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS category VARCHAR(100) NOT NULL DEFAULT '';
+
+-- Backfill the category snapshot of existing order items
+UPDATE order_items oi
+SET category = p.category
+FROM products p
+WHERE p.id = oi.product_id;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS subtotal DOUBLE PRECISION;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_total DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS coupon_code VARCHAR(50);
+
+-- Orders placed before promotions had no discount
+UPDATE orders SET subtotal = total_price WHERE subtotal IS NULL;
+ALTER TABLE orders ALTER COLUMN subtotal SET DEFAULT 0;
+ALTER TABLE orders ALTER COLUMN subtotal SET NOT NULL;
+
+-- A promotion without code is applied automatically to every order that qualifies
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50),
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(30) NOT NULL,
+    value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    product_id INTEGER,
+    category VARCHAR(100),
+    buy_quantity INTEGER NOT NULL DEFAULT 0,
+    get_quantity INTEGER NOT NULL DEFAULT 0,
+    min_order_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    usage_limit INTEGER,
+    per_user_limit INTEGER,
+    times_used INTEGER NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_promotions_type CHECK (type IN ('percentage', 'fixed_amount', 'free_item', 'buy_x_get_y', 'category_percentage')),
+    CONSTRAINT chk_promotions_times_used CHECK (usage_limit IS NULL OR times_used <= usage_limit)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promotions_code ON promotions(UPPER(code)) WHERE code IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_promotions_active ON promotions(active);
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id SERIAL PRIMARY KEY,
+    promotion_id INTEGER NOT NULL,
+    order_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_promotion_redemptions_promotion_id FOREIGN KEY (promotion_id) REFERENCES promotions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_promotion_redemptions_order_id FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT uq_promotion_redemptions_order UNIQUE (promotion_id, order_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_user_id ON promotion_redemptions(promotion_id, user_id);
+
+CREATE TABLE IF NOT EXISTS order_discounts (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    promotion_id INTEGER,
+    code VARCHAR(50),
+    type VARCHAR(30) NOT NULL,
+    description TEXT,
+    product_id INTEGER,
+    amount DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_order_discounts_order_id FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT fk_order_discounts_promotion_id FOREIGN KEY (promotion_id) REFERENCES promotions(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_discounts_order_id ON order_discounts(order_id);
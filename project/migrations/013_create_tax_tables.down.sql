@@ -0,0 +1,13 @@
+-- Drop tax tables and the tax columns of orders, order items and products
+DROP INDEX IF EXISTS idx_order_taxes_order_id;
+DROP TABLE IF EXISTS order_taxes;
+DROP INDEX IF EXISTS idx_order_item_taxes_order_id;
+DROP TABLE IF EXISTS order_item_taxes;
+DROP INDEX IF EXISTS idx_tax_rules_region;
+DROP TABLE IF EXISTS tax_rules;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS tax_total;
+ALTER TABLE orders DROP COLUMN IF EXISTS region;
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_class;
+ALTER TABLE products DROP COLUMN IF EXISTS tax_class;
@@ -0,0 +1,54 @@
+-- Migration: Tax rules by region and tax class, with the tax lines of orders and order items
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_class VARCHAR(50) NOT NULL DEFAULT 'standard';
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_class VARCHAR(50) NOT NULL DEFAULT 'standard';
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_amount DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS region VARCHAR(20);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_total DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- An empty region or tax class matches every order or item, a region also covers its subregions (ID covers ID-JK)
+CREATE TABLE IF NOT EXISTS tax_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    region VARCHAR(20) NOT NULL DEFAULT '',
+    tax_class VARCHAR(50) NOT NULL DEFAULT '',
+    rate DOUBLE PRECISION NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_tax_rules_rate CHECK (rate >= 0 AND rate <= 100)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_rules_region ON tax_rules(region) WHERE active;
+
+CREATE TABLE IF NOT EXISTS order_item_taxes (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    order_item_id INTEGER NOT NULL,
+    tax_rule_id INTEGER,
+    name VARCHAR(100) NOT NULL,
+    rate DOUBLE PRECISION NOT NULL,
+    taxable_amount DOUBLE PRECISION NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_order_item_taxes_order_id FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT fk_order_item_taxes_order_item_id FOREIGN KEY (order_item_id) REFERENCES order_items(id) ON DELETE CASCADE,
+    CONSTRAINT fk_order_item_taxes_tax_rule_id FOREIGN KEY (tax_rule_id) REFERENCES tax_rules(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_item_taxes_order_id ON order_item_taxes(order_id);
+
+CREATE TABLE IF NOT EXISTS order_taxes (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    tax_rule_id INTEGER,
+    name VARCHAR(100) NOT NULL,
+    rate DOUBLE PRECISION NOT NULL,
+    taxable_amount DOUBLE PRECISION NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_order_taxes_order_id FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT fk_order_taxes_tax_rule_id FOREIGN KEY (tax_rule_id) REFERENCES tax_rules(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_taxes_order_id ON order_taxes(order_id);
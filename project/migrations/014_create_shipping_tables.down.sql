@@ -0,0 +1,8 @@
+-- Drop shipping tables and the shipping columns of orders, order items and products
+DROP INDEX IF EXISTS idx_shipping_table_rates_zone;
+DROP TABLE IF EXISTS shipping_table_rates;
+DROP TABLE IF EXISTS order_shipping;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_cost;
+ALTER TABLE order_items DROP COLUMN IF EXISTS weight_grams;
+ALTER TABLE products DROP COLUMN IF EXISTS weight_grams;
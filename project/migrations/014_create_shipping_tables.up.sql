@@ -0,0 +1,49 @@
+-- Migration: Shipping addresses, table rates and the shipping cost of orders
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS weight_grams INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS weight_grams INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_cost DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS order_shipping (
+    order_id INTEGER PRIMARY KEY,
+    recipient_name VARCHAR(100) NOT NULL,
+    phone VARCHAR(30),
+    line1 VARCHAR(255) NOT NULL,
+    line2 VARCHAR(255),
+    city VARCHAR(100) NOT NULL,
+    region VARCHAR(50),
+    postal_code VARCHAR(20) NOT NULL,
+    country CHAR(2) NOT NULL,
+    carrier VARCHAR(50) NOT NULL,
+    service VARCHAR(50) NOT NULL,
+    estimated_days INTEGER NOT NULL DEFAULT 0,
+    weight_grams INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_order_shipping_order_id FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+-- Rates of the table-rate carrier; a zone is a country (ID) or a region of it (ID-JK), the most
+-- specific zone wins. A rate without max_weight_grams has no upper bound.
+CREATE TABLE IF NOT EXISTS shipping_table_rates (
+    id SERIAL PRIMARY KEY,
+    zone VARCHAR(20) NOT NULL,
+    service VARCHAR(50) NOT NULL,
+    min_weight_grams INTEGER NOT NULL DEFAULT 0,
+    max_weight_grams INTEGER,
+    price DOUBLE PRECISION NOT NULL,
+    price_per_kg DOUBLE PRECISION NOT NULL DEFAULT 0,
+    estimated_days INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_shipping_table_rates_weight CHECK (max_weight_grams IS NULL OR max_weight_grams > min_weight_grams)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipping_table_rates_zone ON shipping_table_rates(zone) WHERE active;
+
+INSERT INTO shipping_table_rates(zone, service, min_weight_grams, max_weight_grams, price, price_per_kg, estimated_days) VALUES
+    ('ID', 'regular', 0, NULL, 10000, 5000, 4),
+    ('ID', 'express', 0, NULL, 20000, 8000, 2),
+    ('ID-JK', 'regular', 0, NULL, 8000, 3000, 2),
+    ('ID-JK', 'express', 0, NULL, 15000, 5000, 1);
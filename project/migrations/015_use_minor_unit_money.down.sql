@@ -0,0 +1,55 @@
+-- Back to DOUBLE PRECISION amounts in currency units without currency codes
+
+ALTER TABLE shipping_table_rates DROP COLUMN IF EXISTS currency;
+ALTER TABLE promotions DROP COLUMN IF EXISTS currency;
+ALTER TABLE payments DROP COLUMN IF EXISTS currency;
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
+
+UPDATE promotions SET value = amount / 100.0 WHERE type = 'fixed_amount';
+ALTER TABLE promotions DROP COLUMN IF EXISTS amount;
+
+ALTER TABLE products
+    ALTER COLUMN price TYPE DOUBLE PRECISION USING price / 100.0;
+
+ALTER TABLE orders
+    ALTER COLUMN total_price TYPE DOUBLE PRECISION USING total_price / 100.0,
+    ALTER COLUMN subtotal TYPE DOUBLE PRECISION USING subtotal / 100.0,
+    ALTER COLUMN discount_total TYPE DOUBLE PRECISION USING discount_total / 100.0,
+    ALTER COLUMN tax_total TYPE DOUBLE PRECISION USING tax_total / 100.0,
+    ALTER COLUMN shipping_cost TYPE DOUBLE PRECISION USING shipping_cost / 100.0;
+
+ALTER TABLE order_items
+    ALTER COLUMN price TYPE DOUBLE PRECISION USING price / 100.0,
+    ALTER COLUMN tax_amount TYPE DOUBLE PRECISION USING tax_amount / 100.0;
+
+ALTER TABLE order_discounts
+    ALTER COLUMN amount TYPE DOUBLE PRECISION USING amount / 100.0;
+
+ALTER TABLE order_item_taxes
+    ALTER COLUMN taxable_amount TYPE DOUBLE PRECISION USING taxable_amount / 100.0,
+    ALTER COLUMN amount TYPE DOUBLE PRECISION USING amount / 100.0;
+
+ALTER TABLE order_taxes
+    ALTER COLUMN taxable_amount TYPE DOUBLE PRECISION USING taxable_amount / 100.0,
+    ALTER COLUMN amount TYPE DOUBLE PRECISION USING amount / 100.0;
+
+ALTER TABLE order_returns
+    ALTER COLUMN refund_amount TYPE DOUBLE PRECISION USING refund_amount / 100.0;
+
+ALTER TABLE order_return_items
+    ALTER COLUMN price TYPE DOUBLE PRECISION USING price / 100.0;
+
+ALTER TABLE promotions
+    ALTER COLUMN min_order_amount TYPE DOUBLE PRECISION USING min_order_amount / 100.0;
+
+ALTER TABLE shipping_table_rates
+    ALTER COLUMN price TYPE DOUBLE PRECISION USING price / 100.0,
+    ALTER COLUMN price_per_kg TYPE DOUBLE PRECISION USING price_per_kg / 100.0;
+
+ALTER TABLE payments
+    ALTER COLUMN total_price TYPE DOUBLE PRECISION USING total_price / 100.0,
+    ALTER COLUMN refunded_amount TYPE DOUBLE PRECISION USING refunded_amount / 100.0;
+
+ALTER TABLE payment_refunds
+    ALTER COLUMN amount TYPE DOUBLE PRECISION USING amount / 100.0;
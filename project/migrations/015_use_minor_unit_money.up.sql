@@ -0,0 +1,57 @@
+-- Migration: Store money as BIGINT minor units (1/100 of the currency unit) with a currency code
+-- instead of DOUBLE PRECISION, existing amounts are taken as IDR
+
+ALTER TABLE products
+    ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100)::BIGINT;
+
+ALTER TABLE orders
+    ALTER COLUMN total_price TYPE BIGINT USING ROUND(total_price * 100)::BIGINT,
+    ALTER COLUMN subtotal TYPE BIGINT USING ROUND(subtotal * 100)::BIGINT,
+    ALTER COLUMN discount_total TYPE BIGINT USING ROUND(discount_total * 100)::BIGINT,
+    ALTER COLUMN tax_total TYPE BIGINT USING ROUND(tax_total * 100)::BIGINT,
+    ALTER COLUMN shipping_cost TYPE BIGINT USING ROUND(shipping_cost * 100)::BIGINT;
+
+ALTER TABLE order_items
+    ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100)::BIGINT,
+    ALTER COLUMN tax_amount TYPE BIGINT USING ROUND(tax_amount * 100)::BIGINT;
+
+ALTER TABLE order_discounts
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+
+ALTER TABLE order_item_taxes
+    ALTER COLUMN taxable_amount TYPE BIGINT USING ROUND(taxable_amount * 100)::BIGINT,
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+
+ALTER TABLE order_taxes
+    ALTER COLUMN taxable_amount TYPE BIGINT USING ROUND(taxable_amount * 100)::BIGINT,
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+
+ALTER TABLE order_returns
+    ALTER COLUMN refund_amount TYPE BIGINT USING ROUND(refund_amount * 100)::BIGINT;
+
+ALTER TABLE order_return_items
+    ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100)::BIGINT;
+
+ALTER TABLE promotions
+    ALTER COLUMN min_order_amount TYPE BIGINT USING ROUND(min_order_amount * 100)::BIGINT;
+
+ALTER TABLE shipping_table_rates
+    ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100)::BIGINT,
+    ALTER COLUMN price_per_kg TYPE BIGINT USING ROUND(price_per_kg * 100)::BIGINT;
+
+ALTER TABLE payments
+    ALTER COLUMN total_price TYPE BIGINT USING ROUND(total_price * 100)::BIGINT,
+    ALTER COLUMN refunded_amount TYPE BIGINT USING ROUND(refunded_amount * 100)::BIGINT;
+
+ALTER TABLE payment_refunds
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+
+-- The value of fixed amount promotions becomes an amount, value stays the percentage of the others
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS amount BIGINT NOT NULL DEFAULT 0;
+UPDATE promotions SET amount = ROUND(value * 100)::BIGINT, value = 0 WHERE type = 'fixed_amount';
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE shipping_table_rates ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
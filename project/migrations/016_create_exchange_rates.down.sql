@@ -0,0 +1,7 @@
+-- Drop exchange rates and the currency and rate of orders and payments
+ALTER TABLE payments DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE payments DROP COLUMN IF EXISTS base_currency;
+ALTER TABLE orders DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE orders DROP COLUMN IF EXISTS base_currency;
+
+DROP TABLE IF EXISTS exchange_rates;
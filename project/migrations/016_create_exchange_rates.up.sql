@@ -0,0 +1,24 @@
+-- Migration: Exchange rates, and the currency and rate each order and payment was priced with
+
+-- rate is the amount of quote_currency one unit of base_currency buys, a pair also converts the other way
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate DOUBLE PRECISION NOT NULL,
+    source VARCHAR(50) NOT NULL DEFAULT 'admin',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base_currency, quote_currency),
+    CONSTRAINT chk_exchange_rates_rate CHECK (rate > 0),
+    CONSTRAINT chk_exchange_rates_pair CHECK (base_currency <> quote_currency)
+);
+
+-- base_currency is the currency of the product prices, currency the one the customer pays in
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS base_currency CHAR(3);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS exchange_rate DOUBLE PRECISION NOT NULL DEFAULT 1;
+UPDATE orders SET base_currency = currency WHERE base_currency IS NULL;
+ALTER TABLE orders ALTER COLUMN base_currency SET NOT NULL;
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS base_currency CHAR(3);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS exchange_rate DOUBLE PRECISION NOT NULL DEFAULT 1;
+UPDATE payments SET base_currency = currency WHERE base_currency IS NULL;
+ALTER TABLE payments ALTER COLUMN base_currency SET NOT NULL;
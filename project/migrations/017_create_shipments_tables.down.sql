@@ -0,0 +1,9 @@
+-- Drop shipment tables
+DROP INDEX IF EXISTS idx_shipment_events_shipment_id;
+DROP TABLE IF EXISTS shipment_events;
+DROP INDEX IF EXISTS idx_shipment_items_shipment_id;
+DROP TABLE IF EXISTS shipment_items;
+DROP INDEX IF EXISTS idx_shipments_tracking_number;
+DROP INDEX IF EXISTS idx_shipments_status;
+DROP INDEX IF EXISTS idx_shipments_order_id;
+DROP TABLE IF EXISTS shipments;
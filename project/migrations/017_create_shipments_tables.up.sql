@@ -0,0 +1,45 @@
+-- Migration: Shipments of paid orders, their items and tracking events
+
+CREATE TABLE IF NOT EXISTS shipments (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    carrier VARCHAR(50) NOT NULL,
+    service VARCHAR(50),
+    tracking_number VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    shipped_at TIMESTAMP,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_shipments_order_id FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT chk_shipments_status CHECK (status IN ('pending', 'picked', 'packed', 'shipped', 'in_transit', 'delivered', 'cancelled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipments_order_id ON shipments(order_id);
+CREATE INDEX IF NOT EXISTS idx_shipments_status ON shipments(status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shipments_tracking_number ON shipments(carrier, tracking_number) WHERE tracking_number IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS shipment_items (
+    id SERIAL PRIMARY KEY,
+    shipment_id INTEGER NOT NULL,
+    order_item_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    CONSTRAINT fk_shipment_items_shipment_id FOREIGN KEY (shipment_id) REFERENCES shipments(id) ON DELETE CASCADE,
+    CONSTRAINT fk_shipment_items_order_item_id FOREIGN KEY (order_item_id) REFERENCES order_items(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipment_items_shipment_id ON shipment_items(shipment_id);
+
+-- Tracking events customers see, one per status change of the shipment
+CREATE TABLE IF NOT EXISTS shipment_events (
+    id SERIAL PRIMARY KEY,
+    shipment_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    location VARCHAR(255),
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_shipment_events_shipment_id FOREIGN KEY (shipment_id) REFERENCES shipments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipment_events_shipment_id ON shipment_events(shipment_id);
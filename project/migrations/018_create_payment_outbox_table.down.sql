@@ -0,0 +1,3 @@
+-- Drop the payment outbox table
+DROP INDEX IF EXISTS idx_payment_outbox_status_next_attempt;
+DROP TABLE IF EXISTS payment_outbox;
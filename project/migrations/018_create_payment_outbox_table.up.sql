@@ -0,0 +1,18 @@
+-- Migration: Outbox of the payment service, payment results are committed with the payment and
+-- published to Kafka by its relay
+
+CREATE TABLE IF NOT EXISTS payment_outbox (
+    id SERIAL PRIMARY KEY,
+    aggregate_id INTEGER NOT NULL,
+    topic VARCHAR(100) NOT NULL,
+    payload BYTEA NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP
+);
+
+-- Pending rows are polled by next_attempt_at
+CREATE INDEX IF NOT EXISTS idx_payment_outbox_status_next_attempt ON payment_outbox(status, next_attempt_at);
@@ -0,0 +1,3 @@
+-- Drop report indexes
+DROP INDEX IF EXISTS idx_payments_created_at;
+DROP INDEX IF EXISTS idx_orders_created_at_status;
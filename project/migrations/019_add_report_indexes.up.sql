@@ -0,0 +1,4 @@
+-- Migration: Indexes of the sales and payment reports, which select by creation date
+
+CREATE INDEX IF NOT EXISTS idx_orders_created_at_status ON orders(created_at, status);
+CREATE INDEX IF NOT EXISTS idx_payments_created_at ON payments(created_at);
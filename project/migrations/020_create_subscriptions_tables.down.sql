@@ -0,0 +1,10 @@
+-- Drop subscription tables
+DROP INDEX IF EXISTS idx_subscription_cycles_status_next_attempt;
+DROP INDEX IF EXISTS idx_subscription_cycles_scheduled_at;
+DROP TABLE IF EXISTS subscription_cycles;
+DROP TABLE IF EXISTS subscription_shipping;
+DROP INDEX IF EXISTS idx_subscription_items_subscription_id;
+DROP TABLE IF EXISTS subscription_items;
+DROP INDEX IF EXISTS idx_subscriptions_status_next_run;
+DROP INDEX IF EXISTS idx_subscriptions_user_id;
+DROP TABLE IF EXISTS subscriptions;
@@ -0,0 +1,67 @@
+-- Migration: Subscriptions that order the same items every interval, and the order of each cycle
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    interval_unit VARCHAR(10) NOT NULL,
+    interval_count INTEGER NOT NULL DEFAULT 1,
+    next_run_at TIMESTAMP NOT NULL,
+    skip_next BOOLEAN NOT NULL DEFAULT FALSE,
+    currency CHAR(3),
+    region VARCHAR(50),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_subscriptions_status CHECK (status IN ('active', 'paused', 'cancelled')),
+    CONSTRAINT chk_subscriptions_interval CHECK (interval_unit IN ('day', 'week', 'month') AND interval_count > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON subscriptions(user_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_status_next_run ON subscriptions(status, next_run_at);
+
+CREATE TABLE IF NOT EXISTS subscription_items (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    CONSTRAINT fk_subscription_items_subscription_id FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_items_subscription_id ON subscription_items(subscription_id);
+
+-- Address the orders of a subscription are shipped to, like order_shipping
+CREATE TABLE IF NOT EXISTS subscription_shipping (
+    subscription_id INTEGER PRIMARY KEY,
+    recipient_name VARCHAR(100) NOT NULL,
+    phone VARCHAR(30),
+    line1 VARCHAR(255) NOT NULL,
+    line2 VARCHAR(255),
+    city VARCHAR(100) NOT NULL,
+    region VARCHAR(50),
+    postal_code VARCHAR(20) NOT NULL,
+    country CHAR(2) NOT NULL,
+    carrier VARCHAR(50) NOT NULL,
+    service VARCHAR(50) NOT NULL,
+    CONSTRAINT fk_subscription_shipping_subscription_id FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+-- One row per scheduled order, order_id is the order currently being paid for the cycle. A failed
+-- attempt is retried at next_attempt_at until the cycle is paid or failed for good.
+CREATE TABLE IF NOT EXISTS subscription_cycles (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    scheduled_at TIMESTAMP NOT NULL,
+    order_id INTEGER,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_subscription_cycles_subscription_id FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_subscription_cycles_order_id FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE SET NULL,
+    CONSTRAINT chk_subscription_cycles_status CHECK (status IN ('pending', 'paid', 'skipped', 'failed', 'cancelled'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscription_cycles_scheduled_at ON subscription_cycles(subscription_id, scheduled_at);
+CREATE INDEX IF NOT EXISTS idx_subscription_cycles_status_next_attempt ON subscription_cycles(status, next_attempt_at);
@@ -0,0 +1,2 @@
+-- Drop outbox aggregate index
+DROP INDEX IF EXISTS idx_outbox_pending_aggregate;
@@ -0,0 +1,3 @@
+-- Migration: Index of the pending outbox events of an aggregate, the relay publishes them in order
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_aggregate ON outbox(aggregate_id, id) WHERE status = 'pending';
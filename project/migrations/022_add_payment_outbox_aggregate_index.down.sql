@@ -0,0 +1,2 @@
+-- Drop payment outbox aggregate index
+DROP INDEX IF EXISTS idx_payment_outbox_pending_aggregate;
@@ -0,0 +1,3 @@
+-- Migration: Index of the pending payment outbox events of an aggregate, the relay publishes them in order
+
+CREATE INDEX IF NOT EXISTS idx_payment_outbox_pending_aggregate ON payment_outbox(aggregate_id, id) WHERE status = 'pending';
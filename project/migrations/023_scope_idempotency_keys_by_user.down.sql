@@ -0,0 +1,12 @@
+-- Restore global idempotency keys, keeping the oldest row of a key used by several users
+DELETE FROM payment_idempotency_keys a USING payment_idempotency_keys b
+    WHERE a.idempotency_key = b.idempotency_key AND (a.created_at, a.user_id) > (b.created_at, b.user_id);
+ALTER TABLE payment_idempotency_keys DROP CONSTRAINT IF EXISTS payment_idempotency_keys_pkey;
+ALTER TABLE payment_idempotency_keys ADD CONSTRAINT payment_idempotency_keys_pkey PRIMARY KEY (idempotency_key);
+ALTER TABLE payment_idempotency_keys DROP COLUMN IF EXISTS user_id;
+
+DELETE FROM order_idempotency_keys a USING order_idempotency_keys b
+    WHERE a.idempotency_key = b.idempotency_key AND (a.created_at, a.user_id) > (b.created_at, b.user_id);
+ALTER TABLE order_idempotency_keys DROP CONSTRAINT IF EXISTS order_idempotency_keys_pkey;
+ALTER TABLE order_idempotency_keys ADD CONSTRAINT order_idempotency_keys_pkey PRIMARY KEY (idempotency_key);
+ALTER TABLE order_idempotency_keys DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,10 @@
+-- Migration: Scope idempotency keys to the user that sent them, so one user's key never replays or blocks another's
+
+-- Keys stored before this migration belong to no user and are no longer replayed
+ALTER TABLE order_idempotency_keys ADD COLUMN IF NOT EXISTS user_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_idempotency_keys DROP CONSTRAINT IF EXISTS order_idempotency_keys_pkey;
+ALTER TABLE order_idempotency_keys ADD CONSTRAINT order_idempotency_keys_pkey PRIMARY KEY (user_id, idempotency_key);
+
+ALTER TABLE payment_idempotency_keys ADD COLUMN IF NOT EXISTS user_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE payment_idempotency_keys DROP CONSTRAINT IF EXISTS payment_idempotency_keys_pkey;
+ALTER TABLE payment_idempotency_keys ADD CONSTRAINT payment_idempotency_keys_pkey PRIMARY KEY (user_id, idempotency_key);
@@ -0,0 +1,3 @@
+-- Drop subscription max amount
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS chk_subscriptions_max_amount;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS max_amount;
@@ -0,0 +1,4 @@
+-- Migration: The most a subscription cycle is paid automatically, 0 pays any amount
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS max_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD CONSTRAINT chk_subscriptions_max_amount CHECK (max_amount >= 0);